@@ -0,0 +1,264 @@
+/*
+Package appbundle inspects the iOS apps in .ipa files and Xcode archives
+(.xcarchive directories).
+
+For the app and each of its extensions, it decodes the Info.plist, the
+embedded.mobileprovision the app was signed with, and the entitlements in the
+executable's code signature. Plists may be XML or binary; Xcode writes
+Info.plist files in binary when it builds for devices.
+*/
+package appbundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	plist "github.com/zach-klippenstein/goplist"
+	"github.com/zach-klippenstein/goplist/entitlements"
+	"github.com/zach-klippenstein/goplist/infoplist"
+	"github.com/zach-klippenstein/goplist/mobileprovision"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Summary describes an IPA or an archive.
+type Summary struct {
+	App *Bundle
+	// Archive is the archive's Info.plist, or nil for an IPA.
+	Archive *ArchiveInfo
+}
+
+// Bundle is an app or app extension bundle.
+type Bundle struct {
+	// Path is the bundle's path in the IPA or archive, e.g. Payload/Example.app.
+	Path string
+	Info *infoplist.Info
+	// Profile is the bundle's embedded.mobileprovision, or nil if it has none,
+	// e.g. because it was built for the simulator. The profile's signature
+	// isn't checked.
+	Profile *mobileprovision.Profile
+	// Entitlements are the entitlements the bundle was signed with, or nil if
+	// it isn't signed.
+	Entitlements *entitlements.Entitlements
+	// Extensions are the bundles in the PlugIns and Extensions directories.
+	Extensions []*Bundle
+}
+
+// ArchiveInfo is the Info.plist at the root of an Xcode archive.
+type ArchiveInfo struct {
+	Name           string    `plist:"Name"`
+	SchemeName     string    `plist:"SchemeName"`
+	CreationDate   time.Time `plist:"CreationDate"`
+	ArchiveVersion int       `plist:"ArchiveVersion"`
+
+	ApplicationProperties struct {
+		// ApplicationPath is relative to the archive's Products directory.
+		ApplicationPath            string   `plist:"ApplicationPath"`
+		Architectures              []string `plist:"Architectures"`
+		CFBundleIdentifier         string   `plist:"CFBundleIdentifier"`
+		CFBundleShortVersionString string   `plist:"CFBundleShortVersionString"`
+		CFBundleVersion            string   `plist:"CFBundleVersion"`
+		SigningIdentity            string   `plist:"SigningIdentity"`
+		Team                       string   `plist:"Team"`
+	} `plist:"ApplicationProperties"`
+
+	Unknown map[string]interface{} `plist:",unknown"`
+}
+
+// Open inspects the IPA or the archive directory at name.
+func Open(name string) (*Summary, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return readArchive(dirFS(name))
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadIPA(f, info.Size())
+}
+
+// ReadIPA inspects an IPA of size bytes read from r.
+func ReadIPA(r io.ReaderAt, size int64) (*Summary, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	fs := newZipFS(z)
+
+	names, err := fs.List("Payload")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if strings.HasSuffix(name, ".app") {
+			app, err := readBundle(fs, "Payload/"+name)
+			if err != nil {
+				return nil, err
+			}
+			return &Summary{App: app}, nil
+		}
+	}
+	return nil, fmt.Errorf("appbundle: no app in Payload")
+}
+
+func readArchive(fs fileSystem) (*Summary, error) {
+	data, err := fs.ReadFile("Info.plist")
+	if err != nil {
+		return nil, err
+	}
+	archive := new(ArchiveInfo)
+	if err := unmarshal(data, archive); err != nil {
+		return nil, fmt.Errorf("appbundle: Info.plist: %v", err)
+	}
+
+	appPath := archive.ApplicationProperties.ApplicationPath
+	if appPath == "" {
+		names, err := fs.List("Products/Applications")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if strings.HasSuffix(name, ".app") {
+				appPath = "Applications/" + name
+				break
+			}
+		}
+	}
+	if appPath == "" {
+		return nil, fmt.Errorf("appbundle: no app in Products/Applications")
+	}
+	if !isLocal(appPath) {
+		return nil, fmt.Errorf("appbundle: Info.plist: ApplicationPath %q is outside the archive", appPath)
+	}
+
+	app, err := readBundle(fs, path.Join("Products", appPath))
+	if err != nil {
+		return nil, err
+	}
+	return &Summary{App: app, Archive: archive}, nil
+}
+
+func readBundle(fs fileSystem, dir string) (*Bundle, error) {
+	bundle := &Bundle{Path: dir}
+
+	data, err := fs.ReadFile(path.Join(dir, "Info.plist"))
+	if err != nil {
+		return nil, err
+	}
+	bundle.Info = new(infoplist.Info)
+	if err := unmarshal(data, bundle.Info); err != nil {
+		return nil, fmt.Errorf("appbundle: %s/Info.plist: %v", dir, err)
+	}
+	if !isLocal(bundle.Info.CFBundleExecutable) {
+		return nil, fmt.Errorf("appbundle: %s/Info.plist: CFBundleExecutable %q is outside the bundle", dir, bundle.Info.CFBundleExecutable)
+	}
+
+	data, err = fs.ReadFile(path.Join(dir, "embedded.mobileprovision"))
+	if err == nil {
+		if bundle.Profile, err = mobileprovision.Decode(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("appbundle: %s/embedded.mobileprovision: %v", dir, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if bundle.Entitlements, err = readEntitlements(fs, dir, bundle.Info.CFBundleExecutable); err != nil {
+		return nil, err
+	}
+
+	for _, extensionsDir := range []string{"PlugIns", "Extensions"} {
+		names, err := fs.List(path.Join(dir, extensionsDir))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !strings.HasSuffix(name, ".appex") {
+				continue
+			}
+			extension, err := readBundle(fs, path.Join(dir, extensionsDir, name))
+			if err != nil {
+				return nil, err
+			}
+			bundle.Extensions = append(bundle.Extensions, extension)
+		}
+	}
+	return bundle, nil
+}
+
+// readEntitlements reads the entitlements from the code signature of a
+// bundle's executable, or from the archived-expanded-entitlements.xcent file
+// Xcode leaves in simulator builds, which have no code signature.
+func readEntitlements(fs fileSystem, dir, executable string) (*entitlements.Entitlements, error) {
+	var data []byte
+	if executable != "" {
+		name := path.Join(dir, executable)
+		executableData, err := fs.ReadFile(name)
+		switch {
+		case err == nil:
+			if data, err = executableEntitlements(executableData); err != nil {
+				return nil, fmt.Errorf("appbundle: %s: %v", name, err)
+			}
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+	}
+	if data == nil {
+		var err error
+		data, err = fs.ReadFile(path.Join(dir, "archived-expanded-entitlements.xcent"))
+		if os.IsNotExist(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	value, _, err := plist.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("appbundle: %s entitlements: %v", dir, err)
+	}
+	e, err := entitlements.FromValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("appbundle: %s: %v", dir, err)
+	}
+	return e, nil
+}
+
+// isLocal returns true if name, a path read from a plist, is relative and has
+// no ".." elements, so it can't refer to a file outside the directory it's
+// relative to.
+func isLocal(name string) bool {
+	if path.IsAbs(name) || filepath.IsAbs(filepath.FromSlash(name)) {
+		return false
+	}
+	for _, elem := range strings.FieldsFunc(name, isSeparator) {
+		if elem == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func isSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+// unmarshal decodes an XML or binary plist into v.
+func unmarshal(data []byte, v interface{}) error {
+	value, _, err := plist.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	return xml.UnmarshalValue(value, v)
+}
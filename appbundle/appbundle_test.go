@@ -0,0 +1,245 @@
+package appbundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/binary"
+	"github.com/zach-klippenstein/goplist/internal/cms"
+	"github.com/zach-klippenstein/goplist/internal/cms/cmstest"
+)
+
+const profile = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>Name</key>
+	<string>Example Distribution</string>
+	<key>TeamIdentifier</key>
+	<array><string>ABCDE12345</string></array>
+	<key>Entitlements</key>
+	<dict>
+		<key>application-identifier</key>
+		<string>ABCDE12345.*</string>
+		<key>get-task-allow</key>
+		<true/>
+	</dict>
+</dict>
+</plist>`
+
+const extensionInfo = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>com.example.app.share</string>
+	<key>CFBundleExecutable</key>
+	<string>Share</string>
+</dict>
+</plist>`
+
+func signedProfile(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	cert := cmstest.NewCertificate(t, "Profile Signing", key, nil, nil)
+
+	signed, err := cms.Sign([]byte(profile), cert, key, nil)
+	assert.NoError(t, err)
+	return signed
+}
+
+// binaryInfo returns an app's Info.plist in binary format, as Xcode writes it.
+func binaryInfo(t *testing.T) []byte {
+	data, err := binary.Marshal(map[string]interface{}{
+		"CFBundleIdentifier":         "com.example.app",
+		"CFBundleExecutable":         "Example",
+		"CFBundleShortVersionString": "1.2",
+		"CFBundleVersion":            "42",
+	})
+	assert.NoError(t, err)
+	return data
+}
+
+func ipa(t *testing.T, files map[string][]byte) []byte {
+	var b bytes.Buffer
+	z := zip.NewWriter(&b)
+	for name, data := range files {
+		w, err := z.Create(name)
+		assert.NoError(t, err)
+		w.Write(data)
+	}
+	assert.NoError(t, z.Close())
+	return b.Bytes()
+}
+
+func TestReadIPA(t *testing.T) {
+	data := ipa(t, map[string][]byte{
+		"Payload/Example.app/Info.plist":                            binaryInfo(t),
+		"Payload/Example.app/Example":                               machO(testEntitlements),
+		"Payload/Example.app/embedded.mobileprovision":              signedProfile(t),
+		"Payload/Example.app/PlugIns/Share.appex/Info.plist":        []byte(extensionInfo),
+		"Payload/Example.app/PlugIns/Share.appex/Share":             machO(""),
+		"Payload/Example.app/PlugIns/README.txt":                    []byte("not an extension"),
+		"Payload/Example.app/Extensions/Widget.appex/Info.plist":    []byte(extensionInfo),
+		"Payload/Example.app/Extensions/Widget.appex/Share":         machO(testEntitlements),
+		"Payload/Example.app/Extensions/Widget.appex/Assets.car":    nil,
+		"Payload/Example.app/Frameworks/Lib.framework/Info.plist":   []byte(extensionInfo),
+		"Payload/Example.app/Frameworks/Lib.framework/Lib":          nil,
+		"Payload/Example.app/_CodeSignature/CodeResources":          nil,
+		"Payload/Example.app/Base.lproj/LaunchScreen.storyboardc/x": nil,
+	})
+
+	summary, err := ReadIPA(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Nil(t, summary.Archive)
+
+	app := summary.App
+	assert.Equal(t, "Payload/Example.app", app.Path)
+	assert.Equal(t, "com.example.app", app.Info.CFBundleIdentifier)
+	assert.Equal(t, "1.2", app.Info.CFBundleShortVersionString)
+	assert.Equal(t, "Example Distribution", app.Profile.Name)
+	assert.Equal(t, "ABCDE12345.*", app.Profile.Entitlements.ApplicationIdentifier)
+	assert.Equal(t, "ABCDE12345.com.example.app", app.Entitlements.ApplicationIdentifier)
+	assert.True(t, app.Entitlements.GetTaskAllow)
+	assert.True(t, app.Entitlements.Satisfies(app.Profile.Entitlements))
+
+	assert.Len(t, app.Extensions, 2)
+	share := app.Extensions[0]
+	assert.Equal(t, "Payload/Example.app/PlugIns/Share.appex", share.Path)
+	assert.Equal(t, "com.example.app.share", share.Info.CFBundleIdentifier)
+	assert.Nil(t, share.Profile)
+	assert.Nil(t, share.Entitlements)
+	assert.Equal(t, "Payload/Example.app/Extensions/Widget.appex", app.Extensions[1].Path)
+	assert.NotNil(t, app.Extensions[1].Entitlements)
+}
+
+func TestReadIPAErrors(t *testing.T) {
+	for _, test := range []struct {
+		files    map[string][]byte
+		expected string
+	}{
+		{map[string][]byte{"Example.app/Info.plist": nil}, "appbundle: no app in Payload"},
+		{map[string][]byte{"Payload/Example.app/Example": nil}, "Payload/Example.app/Info.plist: file does not exist"},
+		{map[string][]byte{"Payload/Example.app/Info.plist": []byte("<plist><string>x</string></plist>")}, "appbundle: Payload/Example.app/Info.plist: "},
+		{map[string][]byte{
+			"Payload/Example.app/Info.plist":               binaryInfo(t),
+			"Payload/Example.app/embedded.mobileprovision": []byte(profile),
+		}, "appbundle: Payload/Example.app/embedded.mobileprovision: mobileprovision: not a signed profile"},
+		{map[string][]byte{
+			"Payload/Example.app/Info.plist": binaryInfo(t),
+			"Payload/Example.app/Example":    []byte("#!/bin/sh"),
+		}, "appbundle: Payload/Example.app/Example: "},
+		{map[string][]byte{
+			"Payload/Example.app/Info.plist": []byte("<plist><dict><key>CFBundleExecutable</key><string>../../../etc/passwd</string></dict></plist>"),
+		}, `appbundle: Payload/Example.app/Info.plist: CFBundleExecutable "../../../etc/passwd" is outside the bundle`},
+		{map[string][]byte{
+			"Payload/Example.app/Info.plist": []byte("<plist><dict><key>CFBundleExecutable</key><string>/bin/sh</string></dict></plist>"),
+		}, `CFBundleExecutable "/bin/sh" is outside the bundle`},
+	} {
+		data := ipa(t, test.files)
+		_, err := ReadIPA(bytes.NewReader(data), int64(len(data)))
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), test.expected)
+		}
+	}
+}
+
+func TestReadIPATooLarge(t *testing.T) {
+	defer func(max int64) { maxFileSize = max }(maxFileSize)
+	maxFileSize = 16
+
+	data := ipa(t, map[string][]byte{
+		"Payload/Example.app/Info.plist": binaryInfo(t),
+		"Payload/Example.app/Example":    machO(testEntitlements),
+	})
+	_, err := ReadIPA(bytes.NewReader(data), int64(len(data)))
+	assert.EqualError(t, err, "appbundle: Payload/Example.app/Info.plist is larger than 16 bytes")
+}
+
+func TestOpenArchiveOutside(t *testing.T) {
+	dir, err := ioutil.TempDir("", "appbundle")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, appPath := range []string{"../../Other.app", "Applications/../../../Other.app", "/Applications/Other.app"} {
+		info := "<plist><dict><key>ApplicationProperties</key><dict><key>ApplicationPath</key><string>" +
+			appPath + "</string></dict></dict></plist>"
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Info.plist"), []byte(info), 0644))
+
+		_, err := Open(dir)
+		assert.EqualError(t, err, fmt.Sprintf("appbundle: Info.plist: ApplicationPath %q is outside the archive", appPath))
+	}
+}
+
+func TestOpenArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "appbundle")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	archive := filepath.Join(dir, "Example.xcarchive")
+	app := filepath.Join(archive, "Products", "Applications", "Example.app")
+	assert.NoError(t, os.MkdirAll(filepath.Join(app, "PlugIns", "Share.appex"), 0755))
+
+	for name, data := range map[string][]byte{
+		"Info.plist": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>ApplicationProperties</key>
+	<dict>
+		<key>ApplicationPath</key>
+		<string>Applications/Example.app</string>
+		<key>CFBundleIdentifier</key>
+		<string>com.example.app</string>
+		<key>Team</key>
+		<string>ABCDE12345</string>
+	</dict>
+	<key>ArchiveVersion</key>
+	<integer>2</integer>
+	<key>CreationDate</key>
+	<date>2024-01-02T03:04:05Z</date>
+	<key>Name</key>
+	<string>Example</string>
+	<key>SchemeName</key>
+	<string>Example</string>
+</dict>
+</plist>`),
+		"Products/Applications/Example.app/Info.plist":                           binaryInfo(t),
+		"Products/Applications/Example.app/archived-expanded-entitlements.xcent": []byte(testEntitlements),
+		"Products/Applications/Example.app/PlugIns/Share.appex/Info.plist":       []byte(extensionInfo),
+	} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(archive, filepath.FromSlash(name)), data, 0644))
+	}
+
+	summary, err := Open(archive)
+	assert.NoError(t, err)
+	assert.Equal(t, "Example", summary.Archive.SchemeName)
+	assert.Equal(t, 2, summary.Archive.ArchiveVersion)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), summary.Archive.CreationDate)
+	assert.Equal(t, "ABCDE12345", summary.Archive.ApplicationProperties.Team)
+
+	assert.Equal(t, "Products/Applications/Example.app", summary.App.Path)
+	assert.Equal(t, "42", summary.App.Info.CFBundleVersion)
+	assert.Nil(t, summary.App.Profile)
+	assert.Equal(t, "ABCDE12345.com.example.app", summary.App.Entitlements.ApplicationIdentifier)
+	assert.Len(t, summary.App.Extensions, 1)
+}
+
+func TestOpenIPA(t *testing.T) {
+	f, err := ioutil.TempFile("", "appbundle")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.Write(ipa(t, map[string][]byte{"Payload/Example.app/Info.plist": binaryInfo(t)}))
+	f.Close()
+
+	summary, err := Open(f.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, "com.example.app", summary.App.Info.CFBundleIdentifier)
+	assert.Nil(t, summary.App.Entitlements)
+}
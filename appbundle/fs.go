@@ -0,0 +1,111 @@
+package appbundle
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fileSystem is the contents of an IPA or an archive directory. Paths are
+// slash-separated and relative to its root.
+type fileSystem interface {
+	// ReadFile returns the contents of a file, or an error for which
+	// os.IsNotExist is true if there isn't one.
+	ReadFile(name string) ([]byte, error)
+	// List returns the names of the files and directories in dir, sorted.
+	List(dir string) ([]string, error)
+}
+
+// maxFileSize is the size of the largest file ReadFile reads, so that a
+// corrupt or hostile bundle can't exhaust memory. The largest files read are
+// executables, to find their code signatures.
+var maxFileSize int64 = 1 << 30
+
+func errTooLarge(name string) error {
+	return fmt.Errorf("appbundle: %s is larger than %d bytes", name, maxFileSize)
+}
+
+type dirFS string
+
+func (d dirFS) ReadFile(name string) ([]byte, error) {
+	filename := filepath.Join(string(d), filepath.FromSlash(name))
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxFileSize {
+		return nil, errTooLarge(name)
+	}
+	return ioutil.ReadFile(filename)
+}
+
+func (d dirFS) List(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(filepath.Join(string(d), filepath.FromSlash(dir)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names, nil
+}
+
+type zipFS struct {
+	files map[string]*zip.File
+	// dirs maps each directory to the names in it, including directories
+	// that only exist as the prefix of a file's path.
+	dirs map[string][]string
+}
+
+func newZipFS(r *zip.Reader) *zipFS {
+	fs := &zipFS{files: map[string]*zip.File{}, dirs: map[string][]string{}}
+	seen := map[string]bool{}
+	for _, f := range r.File {
+		name := strings.TrimSuffix(f.Name, "/")
+		if !strings.HasSuffix(f.Name, "/") {
+			fs.files[name] = f
+		}
+		for name != "." && name != "" && !seen[name] {
+			seen[name] = true
+			dir := path.Dir(name)
+			if dir == "." {
+				dir = ""
+			}
+			fs.dirs[dir] = append(fs.dirs[dir], path.Base(name))
+			name = dir
+		}
+	}
+	for _, names := range fs.dirs {
+		sort.Strings(names)
+	}
+	return fs
+}
+
+func (fs *zipFS) ReadFile(name string) ([]byte, error) {
+	f, ok := fs.files[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	// archive/zip fails if the data is longer than the header says.
+	if f.UncompressedSize64 > uint64(maxFileSize) {
+		return nil, errTooLarge(name)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func (fs *zipFS) List(dir string) ([]string, error) {
+	return fs.dirs[dir], nil
+}
@@ -0,0 +1,85 @@
+package appbundle
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	loadCmdCodeSignature = 0x1d
+
+	// Code signature blobs are big-endian, whatever the executable's byte order.
+	csMagicEmbeddedSignature    = 0xfade0cc0
+	csMagicEmbeddedEntitlements = 0xfade7171
+)
+
+/*
+executableEntitlements returns the entitlements plist embedded in a Mach-O
+executable's code signature, or nil if it isn't signed or has no entitlements.
+In a universal binary, the first architecture's are returned; codesign signs
+every architecture with the same entitlements.
+*/
+func executableEntitlements(data []byte) ([]byte, error) {
+	fat, err := macho.NewFatFile(bytes.NewReader(data))
+	if err == nil {
+		if len(fat.Arches) == 0 {
+			return nil, nil
+		}
+		arch := fat.Arches[0]
+		if uint64(arch.Offset)+uint64(arch.Size) > uint64(len(data)) {
+			return nil, fmt.Errorf("architecture %s extends past the end of the file", arch.Cpu)
+		}
+		data = data[arch.Offset : arch.Offset+arch.Size]
+	} else if err != macho.ErrNotFat {
+		return nil, err
+	}
+
+	f, err := macho.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for _, load := range f.Loads {
+		raw := load.Raw()
+		if len(raw) < 16 || f.ByteOrder.Uint32(raw) != loadCmdCodeSignature {
+			continue
+		}
+		offset, size := uint64(f.ByteOrder.Uint32(raw[8:])), uint64(f.ByteOrder.Uint32(raw[12:]))
+		if offset+size > uint64(len(data)) {
+			return nil, fmt.Errorf("code signature extends past the end of the file")
+		}
+		return signatureEntitlements(data[offset : offset+size])
+	}
+	return nil, nil
+}
+
+// signatureEntitlements returns the entitlements blob in a code signature's
+// superblob: a magic number, a length and a count, followed by the type and
+// offset of each blob.
+func signatureEntitlements(signature []byte) ([]byte, error) {
+	if len(signature) < 12 || binary.BigEndian.Uint32(signature) != csMagicEmbeddedSignature {
+		return nil, fmt.Errorf("invalid code signature")
+	}
+	count := binary.BigEndian.Uint32(signature[8:])
+	if uint64(count) > uint64(len(signature)-12)/8 {
+		return nil, fmt.Errorf("invalid code signature blob count %d", count)
+	}
+
+	for i := uint32(0); i < count; i++ {
+		offset := binary.BigEndian.Uint32(signature[12+8*i+4:])
+		if uint64(offset)+8 > uint64(len(signature)) {
+			return nil, fmt.Errorf("code signature blob %d is out of range", i)
+		}
+		blob := signature[offset:]
+		if binary.BigEndian.Uint32(blob) != csMagicEmbeddedEntitlements {
+			continue
+		}
+		length := binary.BigEndian.Uint32(blob[4:])
+		if length < 8 || uint64(length) > uint64(len(blob)) {
+			return nil, fmt.Errorf("invalid entitlements blob length %d", length)
+		}
+		return blob[8:length], nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,95 @@
+package appbundle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// machO returns a minimal arm64 executable whose only load command is a code
+// signature holding entitlements, or one with no load commands if
+// entitlements is empty.
+func machO(entitlements string) []byte {
+	var signature bytes.Buffer
+	if entitlements != "" {
+		blobLength := 8 + len(entitlements)
+		for _, v := range []uint32{csMagicEmbeddedSignature, uint32(20 + blobLength), 1, 5, 20, csMagicEmbeddedEntitlements, uint32(blobLength)} {
+			binary.Write(&signature, binary.BigEndian, v)
+		}
+		signature.WriteString(entitlements)
+	}
+
+	var b bytes.Buffer
+	ncmds, sizeofcmds := uint32(0), uint32(0)
+	if signature.Len() > 0 {
+		ncmds, sizeofcmds = 1, 16
+	}
+	// mach_header_64: magic, cputype, cpusubtype, filetype, ncmds, sizeofcmds, flags, reserved.
+	for _, v := range []uint32{0xfeedfacf, 0x0100000c, 0, 2, ncmds, sizeofcmds, 0, 0} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	if signature.Len() > 0 {
+		for _, v := range []uint32{loadCmdCodeSignature, 16, 48, uint32(signature.Len())} {
+			binary.Write(&b, binary.LittleEndian, v)
+		}
+	}
+	b.Write(signature.Bytes())
+	return b.Bytes()
+}
+
+// fat wraps a thin executable in a universal binary.
+func fat(thin []byte) []byte {
+	const offset = 64
+	var b bytes.Buffer
+	// fat_header, then fat_arch: cputype, cpusubtype, offset, size, align.
+	for _, v := range []uint32{0xcafebabe, 1, 0x0100000c, 0, offset, uint32(len(thin)), 6} {
+		binary.Write(&b, binary.BigEndian, v)
+	}
+	b.Write(make([]byte, offset-b.Len()))
+	b.Write(thin)
+	return b.Bytes()
+}
+
+const testEntitlements = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>application-identifier</key>
+	<string>ABCDE12345.com.example.app</string>
+	<key>get-task-allow</key>
+	<true/>
+</dict>
+</plist>`
+
+func TestExecutableEntitlements(t *testing.T) {
+	data, err := executableEntitlements(machO(testEntitlements))
+	assert.NoError(t, err)
+	assert.Equal(t, testEntitlements, string(data))
+
+	data, err = executableEntitlements(fat(machO(testEntitlements)))
+	assert.NoError(t, err)
+	assert.Equal(t, testEntitlements, string(data))
+
+	data, err = executableEntitlements(machO(""))
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func TestExecutableEntitlementsErrors(t *testing.T) {
+	_, err := executableEntitlements([]byte("#!/bin/sh\n"))
+	assert.Error(t, err)
+
+	truncated := machO(testEntitlements)
+	truncated = truncated[:len(truncated)-1]
+	_, err = executableEntitlements(truncated)
+	assert.EqualError(t, err, "code signature extends past the end of the file")
+
+	badBlob := machO(testEntitlements)
+	binary.BigEndian.PutUint32(badBlob[48+8:], 1000)
+	_, err = executableEntitlements(badBlob)
+	assert.EqualError(t, err, "invalid code signature blob count 1000")
+
+	_, err = signatureEntitlements([]byte("not a signature"))
+	assert.EqualError(t, err, "invalid code signature")
+}
@@ -0,0 +1,131 @@
+/*
+Package binary reads and writes plists in Apple's binary format (bplist00).
+
+A binary plist is a table of objects that refer to each other by index,
+followed by a table of the objects' offsets and a trailer that says where the
+root object is. Unmarshal converts a plist to the same Go values as
+xml.PlistDecoder.DecodeValue:
+
+	string           string
+	integer          int64, uint64 or xml.Int128, depending on its size
+	real             float64
+	boolean          bool
+	date             time.Time, in UTC
+	data             []byte
+	array, set       []interface{}
+	dict             map[string]interface{}
+
+UIDs, which only appear in NSKeyedArchiver archives, are converted to a dict
+with a single CF$UID key holding the UID as an int64, as CoreFoundation does
+when it converts an archive to XML. Marshal converts such dicts back to UIDs.
+
+An array or dict that more than one container refers to is decoded once, and
+the same slice or map is returned everywhere it's referred to.
+
+Reader reads objects from an io.ReaderAt as they're needed, for reading a few
+values out of large plists without decoding them.
+*/
+package binary
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Magic is the header every binary plist starts with.
+const Magic = "bplist00"
+
+const (
+	trailerSize = 32
+	uidKey      = "CF$UID"
+)
+
+// Object markers. The low nibble of the first byte of an object holds its
+// length, or extra type information.
+const (
+	markerNull   = 0x00
+	markerFalse  = 0x08
+	markerTrue   = 0x09
+	markerInt    = 0x10
+	markerReal   = 0x20
+	markerDate   = 0x33
+	markerData   = 0x40
+	markerASCII  = 0x50
+	markerUTF16  = 0x60
+	markerUID    = 0x80
+	markerArray  = 0xA0
+	markerSet    = 0xC0
+	markerDict   = 0xD0
+	lengthFollow = 0x0F
+)
+
+// referenceDate is the epoch of dates in binary plists.
+var referenceDate = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// IsBinary reports whether data starts with the binary plist header.
+func IsBinary(data []byte) bool {
+	return len(data) >= len(Magic) && string(data[:len(Magic)]) == Magic
+}
+
+// trailer is the last 32 bytes of a binary plist.
+type trailer struct {
+	offsetIntSize     int
+	objectRefSize     int
+	numObjects        uint64
+	topObject         uint64
+	offsetTableOffset uint64
+}
+
+func parseTrailer(b []byte) trailer {
+	return trailer{
+		offsetIntSize:     int(b[6]),
+		objectRefSize:     int(b[7]),
+		numObjects:        readUint(b[8:16]),
+		topObject:         readUint(b[16:24]),
+		offsetTableOffset: readUint(b[24:32]),
+	}
+}
+
+// validate checks that the tables the trailer describes fit in a plist of size bytes.
+func (t trailer) validate(size uint64) error {
+	if t.offsetIntSize < 1 || t.offsetIntSize > 8 || t.objectRefSize < 1 || t.objectRefSize > 8 {
+		return fmt.Errorf("binary: invalid offset size %d or reference size %d", t.offsetIntSize, t.objectRefSize)
+	}
+	if t.topObject >= t.numObjects {
+		return fmt.Errorf("binary: top object %d is out of range", t.topObject)
+	}
+	tableEnd := size - trailerSize
+	if t.offsetTableOffset < uint64(len(Magic)) || t.offsetTableOffset > tableEnd ||
+		t.numObjects > (tableEnd-t.offsetTableOffset)/uint64(t.offsetIntSize) {
+		return errors.New("binary: offset table is out of range")
+	}
+	return nil
+}
+
+func (t trailer) bytes() []byte {
+	b := make([]byte, trailerSize)
+	b[6] = byte(t.offsetIntSize)
+	b[7] = byte(t.objectRefSize)
+	putUint(b[8:16], t.numObjects)
+	putUint(b[16:24], t.topObject)
+	putUint(b[24:32], t.offsetTableOffset)
+	return b
+}
+
+// readUint reads a big-endian unsigned integer of 1 to 8 bytes.
+func readUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+// putUint writes n as a big-endian unsigned integer that fills b.
+func putUint(b []byte, n uint64) {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = byte(n)
+		n >>= 8
+	}
+}
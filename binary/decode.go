@@ -0,0 +1,358 @@
+package binary
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+	"unicode/utf16"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Decode reads a binary plist from r. See Unmarshal.
+func Decode(r io.Reader) (interface{}, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal parses a binary plist and returns its root object. See the package
+// documentation for the types of the values returned.
+func Unmarshal(data []byte) (interface{}, error) {
+	d, err := newDecoder(data)
+	if err != nil {
+		return nil, err
+	}
+	return newObjectDecoder(d).decode(d.trailer.topObject)
+}
+
+// objectTable is the table of objects in a plist, read from memory by decoder
+// or from an io.ReaderAt by Reader.
+type objectTable interface {
+	// readObject decodes object ref, unless it's an array or dict. Then it
+	// returns the object's kind, and the references to its elements, or to its
+	// keys followed by its values.
+	readObject(ref uint64) (value interface{}, kind Kind, refs []uint64, err error)
+}
+
+// objectDecoder decodes objects from an objectTable, along with everything
+// they contain.
+type objectDecoder struct {
+	table objectTable
+	// decoded holds the arrays and dicts already decoded. Containers can be
+	// referred to more than once, and decoding them again each time would take
+	// time exponential in the size of the plist.
+	decoded map[uint64]interface{}
+	// decoding marks the containers being decoded, to detect cycles.
+	decoding map[uint64]bool
+}
+
+func newObjectDecoder(table objectTable) *objectDecoder {
+	return &objectDecoder{
+		table:    table,
+		decoded:  map[uint64]interface{}{},
+		decoding: map[uint64]bool{},
+	}
+}
+
+func (d *objectDecoder) decode(ref uint64) (interface{}, error) {
+	if value, ok := d.decoded[ref]; ok {
+		return value, nil
+	}
+	value, kind, refs, err := d.table.readObject(ref)
+	if err != nil || (kind != ArrayKind && kind != DictKind) {
+		return value, err
+	}
+
+	if d.decoding[ref] {
+		return nil, fmt.Errorf("binary: object %d contains itself", ref)
+	}
+	d.decoding[ref] = true
+	defer delete(d.decoding, ref)
+
+	if kind == ArrayKind {
+		value, err = d.decodeArray(refs)
+	} else {
+		value, err = d.decodeDict(refs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.decoded[ref] = value
+	return value, nil
+}
+
+func (d *objectDecoder) decodeArray(refs []uint64) (interface{}, error) {
+	array := make([]interface{}, len(refs))
+	for i, ref := range refs {
+		value, err := d.decode(ref)
+		if err != nil {
+			return nil, err
+		}
+		array[i] = value
+	}
+	return array, nil
+}
+
+func (d *objectDecoder) decodeDict(refs []uint64) (interface{}, error) {
+	n := len(refs) / 2
+	dict := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := d.decode(refs[i])
+		if err != nil {
+			return nil, err
+		}
+		keyString, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("binary: dict key must be a string, found %T", key)
+		}
+		value, err := d.decode(refs[n+i])
+		if err != nil {
+			return nil, err
+		}
+		dict[keyString] = value
+	}
+	return dict, nil
+}
+
+type decoder struct {
+	data    []byte
+	trailer trailer
+}
+
+func newDecoder(data []byte) (*decoder, error) {
+	if !IsBinary(data) {
+		return nil, errors.New("binary: missing bplist00 header")
+	}
+	if len(data) < len(Magic)+trailerSize {
+		return nil, errors.New("binary: plist is too short")
+	}
+
+	t := parseTrailer(data[len(data)-trailerSize:])
+	if err := t.validate(uint64(len(data))); err != nil {
+		return nil, err
+	}
+	return &decoder{data: data, trailer: t}, nil
+}
+
+// offset returns the offset of object ref.
+func (d *decoder) offset(ref uint64) (uint64, error) {
+	if ref >= d.trailer.numObjects {
+		return 0, fmt.Errorf("binary: object reference %d is out of range", ref)
+	}
+	start := d.trailer.offsetTableOffset + ref*uint64(d.trailer.offsetIntSize)
+	offset := readUint(d.data[start : start+uint64(d.trailer.offsetIntSize)])
+	if offset < uint64(len(Magic)) || offset >= d.trailer.offsetTableOffset {
+		return 0, fmt.Errorf("binary: object %d is at invalid offset %d", ref, offset)
+	}
+	return offset, nil
+}
+
+// bytes returns n bytes at offset, which must be before the offset table.
+func (d *decoder) bytes(offset, n uint64) ([]byte, error) {
+	end := offset + n
+	if end < offset || end > d.trailer.offsetTableOffset {
+		return nil, fmt.Errorf("binary: object at offset %d overruns the object table", offset)
+	}
+	return d.data[offset:end], nil
+}
+
+func (d *decoder) readObject(ref uint64) (interface{}, Kind, []uint64, error) {
+	offset, err := d.offset(ref)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	marker := d.data[offset]
+	offset++
+
+	switch marker & 0xF0 {
+	case markerArray, markerSet:
+		refs, err := d.containerRefs(marker, offset)
+		return nil, ArrayKind, refs, err
+	case markerDict:
+		refs, err := d.containerRefs(marker, offset)
+		return nil, DictKind, refs, err
+	}
+	value, err := d.decodeScalar(marker, offset)
+	return value, 0, nil, err
+}
+
+// decodeScalar decodes an object other than an array or dict, whose contents
+// start at offset.
+func (d *decoder) decodeScalar(marker byte, offset uint64) (interface{}, error) {
+	switch marker & 0xF0 {
+	case 0x00:
+		switch marker {
+		case markerFalse:
+			return false, nil
+		case markerTrue:
+			return true, nil
+		}
+	case markerInt:
+		b, err := d.bytes(offset, 1<<(marker&0x0F))
+		if err != nil {
+			return nil, err
+		}
+		return decodeInt(b)
+	case markerReal:
+		b, err := d.bytes(offset, 1<<(marker&0x0F))
+		if err != nil {
+			return nil, err
+		}
+		return decodeReal(b)
+	case markerDate & 0xF0:
+		if marker != markerDate {
+			break
+		}
+		b, err := d.bytes(offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		return decodeDate(math.Float64frombits(readUint(b))), nil
+	case markerData:
+		n, offset, err := d.length(marker, offset)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.bytes(offset, n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case markerASCII:
+		n, offset, err := d.length(marker, offset)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.bytes(offset, n)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case markerUTF16:
+		n, offset, err := d.length(marker, offset)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.bytes(offset, 2*n)
+		if err != nil {
+			return nil, err
+		}
+		return decodeUTF16(b), nil
+	case markerUID:
+		b, err := d.bytes(offset, uint64(marker&0x0F)+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > 4 {
+			return nil, fmt.Errorf("binary: UID at offset %d is too large", offset)
+		}
+		return map[string]interface{}{uidKey: int64(readUint(b))}, nil
+	}
+	return nil, fmt.Errorf("binary: unknown object type 0x%02x at offset %d", marker, offset-1)
+}
+
+// length returns the length of the object with marker, and the offset of its
+// contents.
+func (d *decoder) length(marker byte, offset uint64) (uint64, uint64, error) {
+	if marker&0x0F != lengthFollow {
+		return uint64(marker & 0x0F), offset, nil
+	}
+
+	b, err := d.bytes(offset, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if b[0]&0xF0 != markerInt || b[0]&0x0F > 3 {
+		return 0, 0, fmt.Errorf("binary: invalid length at offset %d", offset)
+	}
+	size := uint64(1) << (b[0] & 0x0F)
+	b, err = d.bytes(offset+1, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	return readUint(b), offset + 1 + size, nil
+}
+
+// containerRefs returns the references in the array or dict with marker.
+func (d *decoder) containerRefs(marker byte, offset uint64) ([]uint64, error) {
+	n, offset, err := d.length(marker, offset)
+	if err != nil {
+		return nil, err
+	}
+	if marker&0xF0 == markerDict {
+		n *= 2
+	}
+	size := uint64(d.trailer.objectRefSize)
+	if n > d.trailer.offsetTableOffset/size {
+		return nil, fmt.Errorf("binary: container at offset %d overruns the object table", offset)
+	}
+	b, err := d.bytes(offset, n*size)
+	if err != nil {
+		return nil, err
+	}
+	return readRefs(b, size), nil
+}
+
+// readRefs reads the object references of size bytes each in b.
+func readRefs(b []byte, size uint64) []uint64 {
+	refs := make([]uint64, uint64(len(b))/size)
+	for i := range refs {
+		refs[i] = readUint(b[uint64(i)*size : uint64(i+1)*size])
+	}
+	return refs
+}
+
+/*
+decodeInt decodes an integer of 1, 2, 4, 8 or 16 bytes. Integers of up to 4
+bytes are unsigned, and of 8 bytes signed. 16-byte integers are returned as
+uint64 if they fit, as CoreFoundation writes unsigned 64-bit values that way,
+and otherwise as xml.Int128.
+*/
+func decodeInt(b []byte) (interface{}, error) {
+	switch len(b) {
+	case 1, 2, 4, 8:
+		return int64(readUint(b)), nil
+	case 16:
+		hi, lo := readUint(b[:8]), readUint(b[8:])
+		if hi == 0 {
+			if lo <= math.MaxInt64 {
+				return int64(lo), nil
+			}
+			return lo, nil
+		}
+		return xml.Int128{Hi: int64(hi), Lo: lo}, nil
+	}
+	return nil, fmt.Errorf("binary: invalid integer size %d", len(b))
+}
+
+func decodeReal(b []byte) (interface{}, error) {
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(uint32(readUint(b)))), nil
+	case 8:
+		return math.Float64frombits(readUint(b)), nil
+	}
+	return nil, fmt.Errorf("binary: invalid real size %d", len(b))
+}
+
+// decodeUTF16 decodes a big-endian UTF-16 string.
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeDate converts seconds since the reference date to a time.
+func decodeDate(seconds float64) time.Time {
+	whole := math.Floor(seconds)
+	nanos := math.Floor((seconds-whole)*1e9 + 0.5)
+	return time.Unix(referenceDate.Unix()+int64(whole), int64(nanos)).UTC()
+}
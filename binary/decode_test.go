@@ -0,0 +1,118 @@
+package binary
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fromHex(s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// sample was written by Python's plistlib, which writes the same format as
+// CoreFoundation.
+var sample = fromHex("62706c6973743030dc0102030405060708090a0b0c0d0e0f1011141516191a1b1c534269675f1012434642756e646c654964656e74696669657255436f756e745444617461544c697374544c6f6e67534e6567564e6573746564534f6666524f6e525069545768656e14000000000000000080000000000000015f100f636f6d2e6578616d706c652e617070102a43000102a312131251616300e9d83dde005f1014787878787878787878787878787878787878787813fffffffffffffffbd11718516b5176080923400c0000000000003341c5a1da528000000821253a40454a4f535a5e6164697a8c8e9296989fb6bfc2c4c6c7c8d10000000000000101000000000000001d000000000000000000000000000000da")
+
+func TestUnmarshal(t *testing.T) {
+	value, err := Unmarshal(sample)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"CFBundleIdentifier": "com.example.app",
+		"Count":              int64(42),
+		"Neg":                int64(-5),
+		"Big":                uint64(1<<63 + 1),
+		"Pi":                 3.5,
+		"On":                 true,
+		"Off":                false,
+		"When":               time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"Data":               []byte{0, 1, 2},
+		"List":               []interface{}{"a", "é😀", "a"},
+		"Nested":             map[string]interface{}{"k": "v"},
+		"Long":               "xxxxxxxxxxxxxxxxxxxx",
+	}, value)
+
+	value, err = Decode(bytes.NewReader(sample))
+	assert.NoError(t, err)
+	assert.Len(t, value, 12)
+}
+
+func TestUnmarshalUID(t *testing.T) {
+	value, err := Unmarshal(fromHex("62706c6973743030d1010258246f626a65637473a1038001080b14160000000000000101000000000000000400000000000000000000000000000018"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"$objects": []interface{}{map[string]interface{}{"CF$UID": int64(1)}},
+	}, value)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	truncated := append([]byte(nil), sample[:len(sample)-trailerSize]...)
+	badTop := append([]byte(nil), sample...)
+	badTop[len(badTop)-9] = 0xFF
+	// A one-element array that contains itself.
+	cycle := fromHex("62706c6973743030a10008000000000000010100000000000000010000000000000000000000000000000a")
+	badMarker := fromHex("62706c6973743030700800000000000001010000000000000001000000000000000000000000000000" + "09")
+
+	for _, test := range []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"xml", []byte("<plist/>"), "binary: missing bplist00 header"},
+		{"short", []byte("bplist00"), "binary: plist is too short"},
+		{"truncated", truncated, "binary: invalid offset size 58 or reference size 64"},
+		{"top", badTop, "binary: top object 255 is out of range"},
+		{"cycle", cycle, "binary: object 0 contains itself"},
+		{"marker", badMarker, "binary: unknown object type 0x70 at offset 8"},
+	} {
+		_, err := Unmarshal(test.data)
+		assert.EqualError(t, err, test.expected, test.name)
+	}
+}
+
+// sharedArrays returns a plist of depth arrays, each of which refers to the
+// next one twice, so it has 2^depth paths to the last one, which is empty.
+func sharedArrays(depth int) []byte {
+	data := []byte(Magic)
+	var offsets []byte
+	for i := 0; i < depth; i++ {
+		offsets = append(offsets, byte(len(data)))
+		data = append(data, markerArray|2, byte(i+1), byte(i+1))
+	}
+	offsets = append(offsets, byte(len(data)))
+	data = append(data, markerArray)
+
+	t := trailer{
+		offsetIntSize:     1,
+		objectRefSize:     1,
+		numObjects:        uint64(depth + 1),
+		offsetTableOffset: uint64(len(data)),
+	}
+	data = append(data, offsets...)
+	return append(data, t.bytes()...)
+}
+
+func TestUnmarshalSharedContainers(t *testing.T) {
+	// Decoding each reference to a container separately would never finish.
+	value, err := Unmarshal(sharedArrays(64))
+	assert.NoError(t, err)
+	for i := 0; i < 64; i++ {
+		array := value.([]interface{})
+		assert.Len(t, array, 2)
+		value = array[0]
+	}
+	assert.Equal(t, []interface{}{}, value)
+}
+
+func TestIsBinary(t *testing.T) {
+	assert.True(t, IsBinary(sample))
+	assert.False(t, IsBinary([]byte("<?xml")))
+	assert.False(t, IsBinary(nil))
+}
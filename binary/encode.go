@@ -0,0 +1,348 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"time"
+	"unicode/utf16"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Encode writes value to w as a binary plist. See Marshal.
+func Encode(w io.Writer, value interface{}) error {
+	data, err := Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+/*
+Marshal returns value as a binary plist. It accepts the same values as
+xml.DictEncoder.WriteValue: anything Unmarshal returns, any slice, map with
+string keys, integer or float type, big.Int and big.Float, and pointers to any
+of them. Dict keys are sorted, and equal strings and numbers are written once.
+*/
+func Marshal(value interface{}) ([]byte, error) {
+	e := encoder{unique: map[interface{}]uint64{}}
+	if _, err := e.flatten(reflect.ValueOf(value)); err != nil {
+		return nil, err
+	}
+	return e.write(), nil
+}
+
+// uid is a UID, flattened from a CF$UID dict.
+type uid uint64
+
+// array and dict are flattened containers, holding references to their contents.
+type array []uint64
+
+type dict struct {
+	keys, values []uint64
+}
+
+type encoder struct {
+	// objects holds the flattened objects, with the root first.
+	objects []interface{}
+	// unique maps scalars to the objects already written for them.
+	unique map[interface{}]uint64
+}
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	int128Type   = reflect.TypeOf(xml.Int128{})
+	timeType     = reflect.TypeOf(time.Time{})
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+// flatten adds v and everything in it to e.objects, and returns its reference.
+func (e *encoder) flatten(v reflect.Value) (uint64, error) {
+	if !v.IsValid() {
+		return 0, fmt.Errorf("binary: cannot encode nil value")
+	}
+
+	switch v.Type() {
+	case bigIntType:
+		i := v.Interface().(big.Int)
+		value, ok := xml.Int128FromBig(&i)
+		if !ok {
+			return 0, fmt.Errorf("binary: integer %s does not fit in 128 bits", i.String())
+		}
+		return e.add(value, true), nil
+	case bigFloatType:
+		f := v.Interface().(big.Float)
+		value, _ := f.Float64()
+		return e.add(value, true), nil
+	case int128Type:
+		return e.add(v.Interface(), true), nil
+	case timeType:
+		// time.Time can't be compared with ==, so dates aren't made unique.
+		return e.add(v.Interface(), false), nil
+	case bytesType:
+		return e.add(v.Bytes(), false), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return e.add(v.String(), true), nil
+	case reflect.Bool:
+		return e.add(v.Bool(), true), nil
+	case reflect.Float32:
+		return e.add(float32(v.Float()), true), nil
+	case reflect.Float64:
+		return e.add(v.Float(), true), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.add(v.Int(), true), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n := v.Uint(); n > math.MaxInt64 {
+			return e.add(n, true), nil
+		}
+		return e.add(int64(v.Uint()), true), nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 0, fmt.Errorf("binary: cannot encode nil %s", v.Type())
+		}
+		return e.flatten(v.Elem())
+	case reflect.Slice, reflect.Array:
+		ref := e.add(nil, false)
+		refs := make(array, v.Len())
+		for i := range refs {
+			var err error
+			if refs[i], err = e.flatten(v.Index(i)); err != nil {
+				return 0, err
+			}
+		}
+		e.objects[ref] = refs
+		return ref, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return 0, fmt.Errorf("binary: cannot encode map with %s keys", v.Type().Key())
+		}
+		if id, ok := uidValue(v); ok {
+			return e.add(id, false), nil
+		}
+		return e.flattenDict(v)
+	}
+	return 0, fmt.Errorf("binary: cannot encode value of type %s", v.Type())
+}
+
+func (e *encoder) flattenDict(v reflect.Value) (uint64, error) {
+	ref := e.add(nil, false)
+	keys := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+
+	d := dict{keys: make([]uint64, len(keys)), values: make([]uint64, len(keys))}
+	for i, key := range keys {
+		d.keys[i] = e.add(key, true)
+	}
+	for i, key := range keys {
+		var err error
+		d.values[i], err = e.flatten(v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())))
+		if err != nil {
+			return 0, err
+		}
+	}
+	e.objects[ref] = d
+	return ref, nil
+}
+
+// uidValue returns the UID in a dict with only a CF$UID key and an integer value.
+func uidValue(v reflect.Value) (uid, bool) {
+	if v.Len() != 1 {
+		return 0, false
+	}
+	value := v.MapIndex(reflect.ValueOf(uidKey).Convert(v.Type().Key()))
+	for value.IsValid() && value.Kind() == reflect.Interface && !value.IsNil() {
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return 0, false
+	}
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := value.Int(); n >= 0 && n <= math.MaxUint32 {
+			return uid(n), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n := value.Uint(); n <= math.MaxUint32 {
+			return uid(n), true
+		}
+	}
+	return 0, false
+}
+
+// add appends an object, or returns the existing one for an equal scalar if
+// unique is true.
+func (e *encoder) add(value interface{}, unique bool) uint64 {
+	if unique {
+		if ref, ok := e.unique[value]; ok {
+			return ref
+		}
+	}
+	ref := uint64(len(e.objects))
+	e.objects = append(e.objects, value)
+	if unique {
+		e.unique[value] = ref
+	}
+	return ref
+}
+
+func (e *encoder) write() []byte {
+	refSize := sizeFor(uint64(len(e.objects)))
+
+	var b bytes.Buffer
+	b.WriteString(Magic)
+	offsets := make([]uint64, len(e.objects))
+	for i, object := range e.objects {
+		offsets[i] = uint64(b.Len())
+		writeObject(&b, object, refSize)
+	}
+
+	t := trailer{
+		offsetIntSize:     sizeFor(uint64(b.Len())),
+		objectRefSize:     refSize,
+		numObjects:        uint64(len(e.objects)),
+		offsetTableOffset: uint64(b.Len()),
+	}
+	offset := make([]byte, t.offsetIntSize)
+	for _, o := range offsets {
+		putUint(offset, o)
+		b.Write(offset)
+	}
+	b.Write(t.bytes())
+	return b.Bytes()
+}
+
+// sizeFor returns the number of bytes needed for references to or offsets of n
+// objects or bytes.
+func sizeFor(n uint64) int {
+	switch {
+	case n <= 0xFF:
+		return 1
+	case n <= 0xFFFF:
+		return 2
+	case n <= 0xFFFFFFFF:
+		return 4
+	}
+	return 8
+}
+
+func writeObject(b *bytes.Buffer, object interface{}, refSize int) {
+	switch object := object.(type) {
+	case bool:
+		if object {
+			b.WriteByte(markerTrue)
+		} else {
+			b.WriteByte(markerFalse)
+		}
+	case int64:
+		writeInt(b, object)
+	case uint64:
+		b.WriteByte(markerInt | 4)
+		writeUint(b, 0, 8)
+		writeUint(b, object, 8)
+	case xml.Int128:
+		b.WriteByte(markerInt | 4)
+		writeUint(b, uint64(object.Hi), 8)
+		writeUint(b, object.Lo, 8)
+	case float32:
+		b.WriteByte(markerReal | 2)
+		writeUint(b, uint64(math.Float32bits(object)), 4)
+	case float64:
+		b.WriteByte(markerReal | 3)
+		writeUint(b, math.Float64bits(object), 8)
+	case time.Time:
+		b.WriteByte(markerDate)
+		writeUint(b, math.Float64bits(encodeDate(object)), 8)
+	case []byte:
+		writeLength(b, markerData, len(object))
+		b.Write(object)
+	case string:
+		writeString(b, object)
+	case uid:
+		size := sizeFor(uint64(object))
+		b.WriteByte(markerUID | byte(size-1))
+		writeUint(b, uint64(object), size)
+	case array:
+		writeLength(b, markerArray, len(object))
+		writeRefs(b, object, refSize)
+	case dict:
+		writeLength(b, markerDict, len(object.keys))
+		writeRefs(b, object.keys, refSize)
+		writeRefs(b, object.values, refSize)
+	}
+}
+
+// writeInt writes non-negative integers in as few bytes as possible, and
+// negative ones in 8 bytes, as CoreFoundation does.
+func writeInt(b *bytes.Buffer, n int64) {
+	size := 8
+	if n >= 0 {
+		size = sizeFor(uint64(n))
+	}
+	var exponent byte
+	for 1<<exponent < size {
+		exponent++
+	}
+	b.WriteByte(markerInt | exponent)
+	writeUint(b, uint64(n), size)
+}
+
+func writeUint(b *bytes.Buffer, n uint64, size int) {
+	buf := make([]byte, size)
+	putUint(buf, n)
+	b.Write(buf)
+}
+
+// writeLength writes a marker with a length, which follows as an integer if
+// it doesn't fit in the marker.
+func writeLength(b *bytes.Buffer, marker byte, n int) {
+	if n < lengthFollow {
+		b.WriteByte(marker | byte(n))
+		return
+	}
+	b.WriteByte(marker | lengthFollow)
+	writeInt(b, int64(n))
+}
+
+// writeString writes s as ASCII if it is, and otherwise as UTF-16.
+func writeString(b *bytes.Buffer, s string) {
+	ascii := true
+	for i := 0; i < len(s) && ascii; i++ {
+		ascii = s[i] < 0x80
+	}
+	if ascii {
+		writeLength(b, markerASCII, len(s))
+		b.WriteString(s)
+		return
+	}
+
+	units := utf16.Encode([]rune(s))
+	writeLength(b, markerUTF16, len(units))
+	for _, unit := range units {
+		b.WriteByte(byte(unit >> 8))
+		b.WriteByte(byte(unit))
+	}
+}
+
+func writeRefs(b *bytes.Buffer, refs []uint64, refSize int) {
+	for _, ref := range refs {
+		writeUint(b, ref, refSize)
+	}
+}
+
+// encodeDate converts a time to seconds since the reference date.
+func encodeDate(t time.Time) float64 {
+	return float64(t.Unix()-referenceDate.Unix()) + float64(t.Nanosecond())/1e9
+}
@@ -0,0 +1,114 @@
+package binary
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+func TestMarshal(t *testing.T) {
+	value := map[string]interface{}{
+		"string":  "hello",
+		"unicode": "é😀",
+		"long":    strings.Repeat("x", 300),
+		"ints":    []interface{}{int64(0), int64(255), int64(256), int64(70000), int64(1 << 40), int64(-1)},
+		"uint":    uint64(1<<63 + 1),
+		"int128":  xml.Int128{Hi: -1, Lo: 0},
+		"real":    2.5,
+		"bools":   []interface{}{true, false},
+		"date":    time.Date(2024, 1, 2, 3, 4, 5, 500000000, time.UTC),
+		"data":    bytes.Repeat([]byte{0xAB}, 20),
+		"empty":   map[string]interface{}{},
+		"uid":     map[string]interface{}{"CF$UID": int64(3)},
+	}
+
+	data, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.True(t, IsBinary(data))
+
+	decoded, err := Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, value, decoded)
+}
+
+func TestMarshalGoTypes(t *testing.T) {
+	type strings map[string]string
+	n := 7
+	value := map[string]interface{}{
+		"map":      strings{"a": "b"},
+		"slice":    []int{1, 2},
+		"array":    [2]string{"x", "y"},
+		"float32":  float32(1.5),
+		"pointer":  &n,
+		"uint8":    uint8(200),
+		"bigInt":   new(big.Int).Lsh(big.NewInt(1), 100),
+		"bigFloat": big.NewFloat(0.25),
+		"uid":      map[string]uint64{"CF$UID": 9},
+	}
+
+	data, err := Marshal(value)
+	assert.NoError(t, err)
+	decoded, err := Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"map":      map[string]interface{}{"a": "b"},
+		"slice":    []interface{}{int64(1), int64(2)},
+		"array":    []interface{}{"x", "y"},
+		"float32":  1.5,
+		"pointer":  int64(7),
+		"uint8":    int64(200),
+		"bigInt":   xml.Int128{Hi: 1 << 36, Lo: 0},
+		"bigFloat": 0.25,
+		"uid":      map[string]interface{}{"CF$UID": int64(9)},
+	}, decoded)
+}
+
+func TestMarshalUnique(t *testing.T) {
+	data, err := Marshal([]interface{}{"same", "same", int64(1), int64(1), map[string]interface{}{"same": int64(1)}})
+	assert.NoError(t, err)
+	// The array, "same", 1 and the dict.
+	assert.Equal(t, byte(4), data[len(data)-trailerSize+15])
+}
+
+func TestMarshalManyObjects(t *testing.T) {
+	// Enough objects and bytes for 2-byte references and 4-byte offsets.
+	var array []interface{}
+	for i := 0; i < 70000; i++ {
+		array = append(array, int64(i))
+	}
+
+	data, err := Marshal(array)
+	assert.NoError(t, err)
+	decoded, err := Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, array, decoded)
+}
+
+func TestMarshalErrors(t *testing.T) {
+	var nilMap map[string]interface{}
+	for _, test := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "binary: cannot encode nil value"},
+		{[]interface{}{nil}, "binary: cannot encode nil interface {}"},
+		{(*int)(nil), "binary: cannot encode nil *int"},
+		{map[int]string{}, "binary: cannot encode map with int keys"},
+		{struct{}{}, "binary: cannot encode value of type struct {}"},
+		{new(big.Int).Lsh(big.NewInt(1), 200), "binary: integer 1606938044258990275541962092341162602522202993782792835301376 does not fit in 128 bits"},
+	} {
+		_, err := Marshal(test.value)
+		assert.EqualError(t, err, test.expected)
+	}
+
+	// A nil map is an empty dict.
+	data, err := Marshal(nilMap)
+	assert.NoError(t, err)
+	decoded, _ := Unmarshal(data)
+	assert.Equal(t, map[string]interface{}{}, decoded)
+}
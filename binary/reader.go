@@ -0,0 +1,429 @@
+package binary
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Kind is the type of an object in a binary plist.
+type Kind int
+
+const (
+	BoolKind Kind = iota + 1
+	IntegerKind
+	RealKind
+	DateKind
+	DataKind
+	StringKind
+	UIDKind
+	ArrayKind
+	DictKind
+)
+
+func (k Kind) String() string {
+	switch k {
+	case BoolKind:
+		return "boolean"
+	case IntegerKind:
+		return "integer"
+	case RealKind:
+		return "real"
+	case DateKind:
+		return "date"
+	case DataKind:
+		return "data"
+	case StringKind:
+		return "string"
+	case UIDKind:
+		return "UID"
+	case ArrayKind:
+		return "array"
+	case DictKind:
+		return "dict"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+/*
+Reader reads objects out of a binary plist as they're needed, instead of
+decoding the whole plist like Unmarshal. Looking up a key in a dict only reads
+the dict's keys whose lengths match, and the value found, so a few keys can be
+read out of many large plists quickly. For example:
+
+	r, err := binary.NewReader(f, size)
+	...
+	root, err := r.Root()
+	...
+	id, ok, err := root.Lookup("CFBundleIdentifier")
+	...
+	value, err := id.Value()
+
+The plist is read with ReadAt, so r can be an *os.File, or a memory-mapped
+file. A Reader and its Objects are safe for concurrent use if r is.
+*/
+type Reader struct {
+	r       io.ReaderAt
+	trailer trailer
+}
+
+// NewReader returns a Reader for the binary plist of size bytes in r. It
+// reads the header and trailer, but no objects.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < int64(len(Magic)) {
+		return nil, errors.New("binary: missing bplist00 header")
+	}
+	header := make([]byte, len(Magic))
+	if err := readAt(r, header, 0); err != nil {
+		return nil, err
+	}
+	if !IsBinary(header) {
+		return nil, errors.New("binary: missing bplist00 header")
+	}
+	if size < int64(len(Magic)+trailerSize) {
+		return nil, errors.New("binary: plist is too short")
+	}
+
+	b := make([]byte, trailerSize)
+	if err := readAt(r, b, size-trailerSize); err != nil {
+		return nil, err
+	}
+	t := parseTrailer(b)
+	if err := t.validate(uint64(size)); err != nil {
+		return nil, err
+	}
+	return &Reader{r: r, trailer: t}, nil
+}
+
+// Root returns the plist's root object.
+func (r *Reader) Root() (Object, error) {
+	return r.object(r.trailer.topObject)
+}
+
+// read fills b from offset, which must be in the object table.
+func (r *Reader) read(b []byte, offset uint64) error {
+	end := offset + uint64(len(b))
+	if end < offset || end > r.trailer.offsetTableOffset {
+		return fmt.Errorf("binary: object at offset %d overruns the object table", offset)
+	}
+	return readAt(r.r, b, int64(offset))
+}
+
+// readAt fills b from offset in r. Unlike r.ReadAt, it doesn't fail with
+// io.EOF if it fills b.
+func readAt(r io.ReaderAt, b []byte, offset int64) error {
+	n, err := r.ReadAt(b, offset)
+	if n == len(b) {
+		return nil
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// offset returns the offset of object ref.
+func (r *Reader) offset(ref uint64) (uint64, error) {
+	if ref >= r.trailer.numObjects {
+		return 0, fmt.Errorf("binary: object reference %d is out of range", ref)
+	}
+	var b [8]byte
+	start := r.trailer.offsetTableOffset + ref*uint64(r.trailer.offsetIntSize)
+	if err := readAt(r.r, b[:r.trailer.offsetIntSize], int64(start)); err != nil {
+		return 0, err
+	}
+	offset := readUint(b[:r.trailer.offsetIntSize])
+	if offset < uint64(len(Magic)) || offset >= r.trailer.offsetTableOffset {
+		return 0, fmt.Errorf("binary: object %d is at invalid offset %d", ref, offset)
+	}
+	return offset, nil
+}
+
+// ref returns the object reference at offset.
+func (r *Reader) ref(offset uint64) (uint64, error) {
+	var b [8]byte
+	if err := r.read(b[:r.trailer.objectRefSize], offset); err != nil {
+		return 0, err
+	}
+	return readUint(b[:r.trailer.objectRefSize]), nil
+}
+
+// object reads the marker and length of object ref.
+func (r *Reader) object(ref uint64) (Object, error) {
+	offset, err := r.offset(ref)
+	if err != nil {
+		return Object{}, err
+	}
+
+	var marker [1]byte
+	if err := r.read(marker[:], offset); err != nil {
+		return Object{}, err
+	}
+	o := Object{r: r, ref: ref, marker: marker[0], offset: offset + 1}
+
+	switch o.marker & 0xF0 {
+	case 0x00:
+		if o.marker == markerFalse || o.marker == markerTrue {
+			o.kind = BoolKind
+		}
+	case markerInt:
+		o.kind, o.length = IntegerKind, 1<<(o.marker&0x0F)
+	case markerReal:
+		o.kind, o.length = RealKind, 1<<(o.marker&0x0F)
+	case markerDate & 0xF0:
+		if o.marker == markerDate {
+			o.kind, o.length = DateKind, 8
+		}
+	case markerData:
+		o.kind = DataKind
+	case markerASCII, markerUTF16:
+		o.kind = StringKind
+	case markerUID:
+		o.kind, o.length = UIDKind, uint64(o.marker&0x0F)+1
+	case markerArray, markerSet:
+		o.kind = ArrayKind
+	case markerDict:
+		o.kind = DictKind
+	}
+	if o.kind == 0 {
+		return Object{}, fmt.Errorf("binary: unknown object type 0x%02x at offset %d", o.marker, offset)
+	}
+
+	switch o.kind {
+	case DataKind, StringKind, ArrayKind, DictKind:
+		if o.length, o.offset, err = r.length(o.marker, o.offset); err != nil {
+			return Object{}, err
+		}
+		size := uint64(1)
+		switch {
+		case o.marker&0xF0 == markerUTF16:
+			size = 2
+		case o.kind == ArrayKind:
+			size = uint64(r.trailer.objectRefSize)
+		case o.kind == DictKind:
+			size = 2 * uint64(r.trailer.objectRefSize)
+		}
+		if o.length > r.trailer.offsetTableOffset/size || o.offset+o.length*size > r.trailer.offsetTableOffset {
+			return Object{}, fmt.Errorf("binary: object at offset %d overruns the object table", offset)
+		}
+	}
+	return o, nil
+}
+
+// length returns the length of the object with marker, and the offset of its
+// contents.
+func (r *Reader) length(marker byte, offset uint64) (uint64, uint64, error) {
+	if marker&0x0F != lengthFollow {
+		return uint64(marker & 0x0F), offset, nil
+	}
+
+	var b [8]byte
+	if err := r.read(b[:1], offset); err != nil {
+		return 0, 0, err
+	}
+	if b[0]&0xF0 != markerInt || b[0]&0x0F > 3 {
+		return 0, 0, fmt.Errorf("binary: invalid length at offset %d", offset)
+	}
+	size := uint64(1) << (b[0] & 0x0F)
+	if err := r.read(b[:size], offset+1); err != nil {
+		return 0, 0, err
+	}
+	return readUint(b[:size]), offset + 1 + size, nil
+}
+
+// Object is an object in a Reader's plist. Only its type and length have
+// been read.
+type Object struct {
+	r      *Reader
+	ref    uint64
+	marker byte
+	kind   Kind
+	// offset is the offset of the object's contents, after its marker and length.
+	offset uint64
+	// length is the number of entries in an array or dict, characters in a
+	// string, or bytes in anything else.
+	length uint64
+}
+
+// Kind returns the object's type.
+func (o Object) Kind() Kind {
+	return o.kind
+}
+
+// Len returns the number of entries in an array or dict, bytes in data, or
+// characters in a string, counted in UTF-16 code units if it isn't ASCII. It
+// returns 0 for other objects.
+func (o Object) Len() int {
+	switch o.kind {
+	case DataKind, StringKind, ArrayKind, DictKind:
+		return int(o.length)
+	}
+	return 0
+}
+
+// Index returns the i'th value in an array or dict.
+func (o Object) Index(i int) (Object, error) {
+	if o.kind != ArrayKind && o.kind != DictKind {
+		return Object{}, fmt.Errorf("binary: cannot index %s", o.kind)
+	}
+	if i < 0 || uint64(i) >= o.length {
+		return Object{}, fmt.Errorf("binary: index %d out of range for %s of length %d", i, o.kind, o.length)
+	}
+	if o.kind == DictKind {
+		i += int(o.length)
+	}
+	return o.elem(uint64(i))
+}
+
+// Key returns the i'th key in a dict.
+func (o Object) Key(i int) (string, error) {
+	if o.kind != DictKind {
+		return "", fmt.Errorf("binary: cannot get key from %s", o.kind)
+	}
+	if i < 0 || uint64(i) >= o.length {
+		return "", fmt.Errorf("binary: index %d out of range for dict of length %d", i, o.length)
+	}
+	key, err := o.elem(uint64(i))
+	if err != nil {
+		return "", err
+	}
+	return key.string()
+}
+
+/*
+Lookup returns the value for key in a dict, and whether the dict has key.
+Keys whose lengths differ from key's aren't read, so Lookup reads little more
+than the dict's references if keys are different lengths, as they usually are.
+*/
+func (o Object) Lookup(key string) (Object, bool, error) {
+	if o.kind != DictKind {
+		return Object{}, false, fmt.Errorf("binary: cannot look up key in %s", o.kind)
+	}
+
+	var buf []byte
+	for i := uint64(0); i < o.length; i++ {
+		k, err := o.elem(i)
+		if err != nil {
+			return Object{}, false, err
+		}
+		if k.kind != StringKind {
+			return Object{}, false, fmt.Errorf("binary: dict key must be a string, found %s", k.kind)
+		}
+
+		var match bool
+		if k.marker&0xF0 == markerASCII {
+			if k.length != uint64(len(key)) {
+				continue
+			}
+			if uint64(cap(buf)) < k.length {
+				buf = make([]byte, k.length)
+			}
+			buf = buf[:k.length]
+			if err := o.r.read(buf, k.offset); err != nil {
+				return Object{}, false, err
+			}
+			match = string(buf) == key
+		} else {
+			// UTF-16 keys have at most as many code units as key has bytes.
+			if k.length > uint64(len(key)) {
+				continue
+			}
+			s, err := k.string()
+			if err != nil {
+				return Object{}, false, err
+			}
+			match = s == key
+		}
+
+		if match {
+			value, err := o.elem(o.length + i)
+			return value, err == nil, err
+		}
+	}
+	return Object{}, false, nil
+}
+
+// elem returns the object referred to by the i'th reference in a container.
+func (o Object) elem(i uint64) (Object, error) {
+	ref, err := o.r.ref(o.offset + i*uint64(o.r.trailer.objectRefSize))
+	if err != nil {
+		return Object{}, err
+	}
+	return o.r.object(ref)
+}
+
+func (o Object) string() (string, error) {
+	if o.kind != StringKind {
+		return "", fmt.Errorf("binary: dict key must be a string, found %s", o.kind)
+	}
+	if o.marker&0xF0 == markerUTF16 {
+		b := make([]byte, 2*o.length)
+		if err := o.r.read(b, o.offset); err != nil {
+			return "", err
+		}
+		return decodeUTF16(b), nil
+	}
+	b := make([]byte, o.length)
+	if err := o.r.read(b, o.offset); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Value reads the object, and all the objects it contains, and returns it as
+// Unmarshal would.
+func (o Object) Value() (interface{}, error) {
+	return newObjectDecoder(o.r).decode(o.ref)
+}
+
+func (r *Reader) readObject(ref uint64) (interface{}, Kind, []uint64, error) {
+	o, err := r.object(ref)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	switch o.kind {
+	case ArrayKind, DictKind:
+		n := o.length
+		if o.kind == DictKind {
+			n *= 2
+		}
+		size := uint64(r.trailer.objectRefSize)
+		b := make([]byte, n*size)
+		if err := r.read(b, o.offset); err != nil {
+			return nil, 0, nil, err
+		}
+		return nil, o.kind, readRefs(b, size), nil
+	}
+	value, err := o.scalarValue()
+	return value, o.kind, nil, err
+}
+
+// scalarValue reads an object other than an array or dict.
+func (o Object) scalarValue() (interface{}, error) {
+	switch o.kind {
+	case BoolKind:
+		return o.marker == markerTrue, nil
+	case StringKind:
+		return o.string()
+	}
+
+	b := make([]byte, o.length)
+	if err := o.r.read(b, o.offset); err != nil {
+		return nil, err
+	}
+	switch o.kind {
+	case IntegerKind:
+		return decodeInt(b)
+	case RealKind:
+		return decodeReal(b)
+	case DateKind:
+		return decodeDate(math.Float64frombits(readUint(b))), nil
+	case UIDKind:
+		if len(b) > 4 {
+			return nil, fmt.Errorf("binary: UID at offset %d is too large", o.offset)
+		}
+		return map[string]interface{}{uidKey: int64(readUint(b))}, nil
+	}
+	return b, nil
+}
@@ -0,0 +1,214 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingReaderAt counts the bytes read from a ReaderAt.
+type countingReaderAt struct {
+	r io.ReaderAt
+	n int
+}
+
+func (c *countingReaderAt) ReadAt(b []byte, offset int64) (int, error) {
+	n, err := c.r.ReadAt(b, offset)
+	c.n += n
+	return n, err
+}
+
+func TestReader(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(sample), int64(len(sample)))
+	assert.NoError(t, err)
+	root, err := r.Root()
+	assert.NoError(t, err)
+	assert.Equal(t, DictKind, root.Kind())
+	assert.Equal(t, 12, root.Len())
+
+	expected, err := Unmarshal(sample)
+	assert.NoError(t, err)
+	value, err := root.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, value)
+
+	id, ok, err := root.Lookup("CFBundleIdentifier")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, StringKind, id.Kind())
+	value, err = id.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "com.example.app", value)
+
+	_, ok, err = root.Lookup("Missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	list, ok, err := root.Lookup("List")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ArrayKind, list.Kind())
+	assert.Equal(t, 3, list.Len())
+	item, err := list.Index(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, item.Len())
+	value, err = item.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "é😀", value)
+
+	// Lookup compares UTF-16 keys too.
+	nested, _, _ := root.Lookup("Nested")
+	v, ok, err := nested.Lookup("k")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, StringKind, v.Kind())
+
+	keys := map[string]Kind{}
+	for i := 0; i < root.Len(); i++ {
+		key, err := root.Key(i)
+		assert.NoError(t, err)
+		value, err := root.Index(i)
+		assert.NoError(t, err)
+		keys[key] = value.Kind()
+	}
+	assert.Equal(t, map[string]Kind{
+		"Big": IntegerKind, "CFBundleIdentifier": StringKind, "Count": IntegerKind, "Data": DataKind,
+		"List": ArrayKind, "Long": StringKind, "Neg": IntegerKind, "Nested": DictKind, "Off": BoolKind,
+		"On": BoolKind, "Pi": RealKind, "When": DateKind,
+	}, keys)
+}
+
+func TestReaderUID(t *testing.T) {
+	data := fromHex("62706c6973743030d1010258246f626a65637473a1038001080b14160000000000000101000000000000000400000000000000000000000000000018")
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	root, err := r.Root()
+	assert.NoError(t, err)
+	objects, _, err := root.Lookup("$objects")
+	assert.NoError(t, err)
+	uid, err := objects.Index(0)
+	assert.NoError(t, err)
+	assert.Equal(t, UIDKind, uid.Kind())
+	value, err := uid.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"CF$UID": int64(1)}, value)
+}
+
+func TestReaderReadsLittle(t *testing.T) {
+	dict := map[string]interface{}{"CFBundleIdentifier": "com.example.app"}
+	for i := 0; i < 1000; i++ {
+		dict[fmt.Sprintf("Key%d", i)] = bytes.Repeat([]byte{byte(i)}, 100)
+	}
+	data, err := Marshal(dict)
+	assert.NoError(t, err)
+
+	counter := &countingReaderAt{r: bytes.NewReader(data)}
+	r, err := NewReader(counter, int64(len(data)))
+	assert.NoError(t, err)
+	root, err := r.Root()
+	assert.NoError(t, err)
+	id, ok, err := root.Lookup("CFBundleIdentifier")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	value, err := id.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "com.example.app", value)
+	assert.True(t, counter.n < len(data)/10, "read %d of %d bytes", counter.n, len(data))
+}
+
+func TestReaderErrors(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"xml", []byte("<plist/>"), "binary: missing bplist00 header"},
+		{"empty", nil, "binary: missing bplist00 header"},
+		{"short", []byte("bplist00"), "binary: plist is too short"},
+		{"truncated", sample[:len(sample)-trailerSize], "binary: invalid offset size 58 or reference size 64"},
+	} {
+		_, err := NewReader(bytes.NewReader(test.data), int64(len(test.data)))
+		assert.EqualError(t, err, test.expected, test.name)
+	}
+
+	cycle := fromHex("62706c6973743030a10008000000000000010100000000000000010000000000000000000000000000000a")
+	r, err := NewReader(bytes.NewReader(cycle), int64(len(cycle)))
+	assert.NoError(t, err)
+	root, err := r.Root()
+	assert.NoError(t, err)
+	_, err = root.Value()
+	assert.EqualError(t, err, "binary: object 0 contains itself")
+
+	badMarker := fromHex("62706c6973743030700800000000000001010000000000000001000000000000000000000000000000" + "09")
+	r, err = NewReader(bytes.NewReader(badMarker), int64(len(badMarker)))
+	assert.NoError(t, err)
+	_, err = r.Root()
+	assert.EqualError(t, err, "binary: unknown object type 0x70 at offset 8")
+
+	r, err = NewReader(bytes.NewReader(sample), int64(len(sample)))
+	assert.NoError(t, err)
+	root, err = r.Root()
+	assert.NoError(t, err)
+	_, err = root.Index(12)
+	assert.EqualError(t, err, "binary: index 12 out of range for dict of length 12")
+	count, _, _ := root.Lookup("Count")
+	_, err = count.Index(0)
+	assert.EqualError(t, err, "binary: cannot index integer")
+	_, err = count.Key(0)
+	assert.EqualError(t, err, "binary: cannot get key from integer")
+	_, _, err = count.Lookup("a")
+	assert.EqualError(t, err, "binary: cannot look up key in integer")
+}
+
+func TestReaderSharedContainers(t *testing.T) {
+	data := sharedArrays(64)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	root, err := r.Root()
+	assert.NoError(t, err)
+	value, err := root.Value()
+	assert.NoError(t, err)
+	expected, err := Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, value)
+}
+
+func BenchmarkReaderLookup(b *testing.B) {
+	data := benchmarkPlist()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r, _ := NewReader(bytes.NewReader(data), int64(len(data)))
+		root, _ := r.Root()
+		id, _, _ := root.Lookup("CFBundleIdentifier")
+		if _, err := id.Value(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalLookup(b *testing.B) {
+	data := benchmarkPlist()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		value, err := Unmarshal(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = value.(map[string]interface{})["CFBundleIdentifier"]
+	}
+}
+
+func benchmarkPlist() []byte {
+	dict := map[string]interface{}{"CFBundleIdentifier": "com.example.app"}
+	for i := 0; i < 200; i++ {
+		dict[fmt.Sprintf("Key%d", i)] = map[string]interface{}{"Name": fmt.Sprint(i), "Values": []interface{}{int64(i), true}}
+	}
+	data, err := Marshal(dict)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
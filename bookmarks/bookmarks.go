@@ -0,0 +1,161 @@
+/*
+Package bookmarks reads Safari's Bookmarks.plist, and reads and writes the
+.webloc and .inetloc files the Finder saves links in.
+
+Bookmarks.plist, in ~/Library/Safari, is usually a binary plist, but XML files
+are read too. Its dicts are WebBookmarkTypeList entries, which become Folders,
+and WebBookmarkTypeLeaf entries, which become Bookmarks. Proxy entries, which
+stand for History in Safari's sidebar, have nothing to migrate and are skipped.
+*/
+package bookmarks
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	plist "github.com/zach-klippenstein/goplist"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Values of WebBookmarkType.
+const (
+	ListType  = "WebBookmarkTypeList"
+	LeafType  = "WebBookmarkTypeLeaf"
+	ProxyType = "WebBookmarkTypeProxy"
+)
+
+// Titles of the folders Safari creates at the root of the tree.
+const (
+	BookmarksBarTitle  = "BookmarksBar"
+	BookmarksMenuTitle = "BookmarksMenu"
+	ReadingListTitle   = "com.apple.ReadingList"
+)
+
+// Node is a *Folder or a *Bookmark.
+type Node interface {
+	isNode()
+}
+
+// Folder is a list of bookmarks and other folders.
+type Folder struct {
+	Title    string
+	UUID     string
+	Children []Node
+}
+
+// Bookmark is a URL and the title it is shown with.
+type Bookmark struct {
+	Title string
+	URL   string
+	UUID  string
+	// ReadingList is set for the bookmarks in the Reading List.
+	ReadingList *ReadingListInfo
+}
+
+// ReadingListInfo is what Safari stores about a page added to the Reading List.
+type ReadingListInfo struct {
+	DateAdded       time.Time `plist:"DateAdded"`
+	DateLastFetched time.Time `plist:"DateLastFetched"`
+	DateLastViewed  time.Time `plist:"DateLastViewed"`
+	PreviewText     string    `plist:"PreviewText"`
+}
+
+func (*Folder) isNode()   {}
+func (*Bookmark) isNode() {}
+
+// entry is a dict in Bookmarks.plist.
+type entry struct {
+	Type          string `plist:"WebBookmarkType"`
+	Title         string `plist:"Title"`
+	UUID          string `plist:"WebBookmarkUUID"`
+	URLString     string `plist:"URLString"`
+	URIDictionary struct {
+		Title string `plist:"title"`
+	} `plist:"URIDictionary"`
+	ReadingList *ReadingListInfo `plist:"ReadingList"`
+	Children    []entry          `plist:"Children"`
+}
+
+// Decode reads a Bookmarks.plist file and returns its root folder, which has no title.
+func Decode(r io.Reader) (*Folder, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	value, _, err := plist.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var root entry
+	if err := xml.UnmarshalValue(value, &root); err != nil {
+		return nil, fmt.Errorf("bookmarks: %v", err)
+	}
+	if root.Type != ListType {
+		return nil, fmt.Errorf("bookmarks: root is a %s, not a %s", root.Type, ListType)
+	}
+	return root.folder()
+}
+
+func (e *entry) folder() (*Folder, error) {
+	folder := &Folder{Title: e.Title, UUID: e.UUID}
+	for i := range e.Children {
+		child := &e.Children[i]
+		switch child.Type {
+		case ListType:
+			subfolder, err := child.folder()
+			if err != nil {
+				return nil, err
+			}
+			folder.Children = append(folder.Children, subfolder)
+		case LeafType:
+			folder.Children = append(folder.Children, &Bookmark{
+				Title:       child.URIDictionary.Title,
+				URL:         child.URLString,
+				UUID:        child.UUID,
+				ReadingList: child.ReadingList,
+			})
+		case ProxyType:
+		default:
+			return nil, fmt.Errorf("bookmarks: unknown bookmark type %q in folder %q", child.Type, e.Title)
+		}
+	}
+	return folder, nil
+}
+
+// Folder returns the child folder with title, or nil if there isn't one.
+func (f *Folder) Folder(title string) *Folder {
+	for _, child := range f.Children {
+		if folder, ok := child.(*Folder); ok && folder.Title == title {
+			return folder
+		}
+	}
+	return nil
+}
+
+/*
+Walk calls fn for each bookmark in the folder and its subfolders, in order.
+path holds the titles of the folders between f and the bookmark. If fn returns
+an error, Walk stops and returns it.
+*/
+func (f *Folder) Walk(fn func(path []string, b *Bookmark) error) error {
+	return f.walk(nil, fn)
+}
+
+func (f *Folder) walk(path []string, fn func([]string, *Bookmark) error) error {
+	for _, child := range f.Children {
+		var err error
+		switch child := child.(type) {
+		case *Folder:
+			err = child.walk(append(path[:len(path):len(path)], child.Title), fn)
+		case *Bookmark:
+			err = fn(path, child)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
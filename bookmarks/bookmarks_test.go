@@ -0,0 +1,148 @@
+package bookmarks
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/binary"
+)
+
+func leaf(uuid, title, url string) map[string]interface{} {
+	return map[string]interface{}{
+		"WebBookmarkType": LeafType,
+		"WebBookmarkUUID": uuid,
+		"URLString":       url,
+		"URIDictionary":   map[string]interface{}{"title": title},
+	}
+}
+
+func list(uuid, title string, children ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"WebBookmarkType": ListType,
+		"WebBookmarkUUID": uuid,
+		"Title":           title,
+		"Children":        children,
+	}
+}
+
+var added = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// safari is laid out like the Bookmarks.plist Safari writes.
+var safari = func() map[string]interface{} {
+	readLater := leaf("R1", "Article", "https://example.com/article")
+	readLater["ReadingList"] = map[string]interface{}{"DateAdded": added, "PreviewText": "Preview"}
+
+	root := list("ROOT", "",
+		map[string]interface{}{
+			"WebBookmarkType":       ProxyType,
+			"WebBookmarkUUID":       "H",
+			"Title":                 "History",
+			"WebBookmarkIdentifier": "History",
+		},
+		list("BAR", BookmarksBarTitle,
+			leaf("B1", "Example", "https://example.com/"),
+			list("F1", "Work",
+				leaf("B2", "Docs", "https://docs.example.com/"),
+			),
+		),
+		list("MENU", BookmarksMenuTitle),
+		list("RL", ReadingListTitle, readLater),
+	)
+	root["WebBookmarkFileVersion"] = int64(1)
+	return root
+}()
+
+func TestDecode(t *testing.T) {
+	data, err := binary.Marshal(safari)
+	assert.NoError(t, err)
+
+	root, err := Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, &Folder{
+		UUID: "ROOT",
+		Children: []Node{
+			&Folder{Title: BookmarksBarTitle, UUID: "BAR", Children: []Node{
+				&Bookmark{Title: "Example", URL: "https://example.com/", UUID: "B1"},
+				&Folder{Title: "Work", UUID: "F1", Children: []Node{
+					&Bookmark{Title: "Docs", URL: "https://docs.example.com/", UUID: "B2"},
+				}},
+			}},
+			&Folder{Title: BookmarksMenuTitle, UUID: "MENU"},
+			&Folder{Title: ReadingListTitle, UUID: "RL", Children: []Node{
+				&Bookmark{
+					Title:       "Article",
+					URL:         "https://example.com/article",
+					UUID:        "R1",
+					ReadingList: &ReadingListInfo{DateAdded: added, PreviewText: "Preview"},
+				},
+			}},
+		},
+	}, root)
+
+	assert.Equal(t, "MENU", root.Folder(BookmarksMenuTitle).UUID)
+	assert.Nil(t, root.Folder("Missing"))
+}
+
+func TestDecodeXML(t *testing.T) {
+	root, err := Decode(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>WebBookmarkType</key><string>WebBookmarkTypeList</string>
+	<key>Children</key>
+	<array>
+		<dict>
+			<key>WebBookmarkType</key><string>WebBookmarkTypeLeaf</string>
+			<key>URLString</key><string>https://example.com/</string>
+			<key>URIDictionary</key><dict><key>title</key><string>Example</string></dict>
+		</dict>
+	</array>
+</dict>
+</plist>`))
+	assert.NoError(t, err)
+	assert.Equal(t, &Folder{Children: []Node{&Bookmark{Title: "Example", URL: "https://example.com/"}}}, root)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, test := range []struct {
+		value    map[string]interface{}
+		expected string
+	}{
+		{leaf("B", "Example", "https://example.com/"), "bookmarks: root is a WebBookmarkTypeLeaf, not a WebBookmarkTypeList"},
+		{list("ROOT", "", map[string]interface{}{"WebBookmarkType": "Other"}), `bookmarks: unknown bookmark type "Other" in folder ""`},
+		{list("ROOT", "", "string"), "bookmarks: cannot unmarshal string into Go value of type bookmarks.entry at Children[0]"},
+	} {
+		data, err := binary.Marshal(test.value)
+		assert.NoError(t, err)
+		_, err = Decode(bytes.NewReader(data))
+		assert.EqualError(t, err, test.expected)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	data, _ := binary.Marshal(safari)
+	root, err := Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	var paths, urls []string
+	err = root.Walk(func(path []string, b *Bookmark) error {
+		paths = append(paths, strings.Join(path, "/"))
+		urls = append(urls, b.URL)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{BookmarksBarTitle, BookmarksBarTitle + "/Work", ReadingListTitle}, paths)
+	assert.Equal(t, []string{"https://example.com/", "https://docs.example.com/", "https://example.com/article"}, urls)
+
+	stop := errors.New("stop")
+	var count int
+	err = root.Walk(func([]string, *Bookmark) error {
+		count++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+}
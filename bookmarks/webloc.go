@@ -0,0 +1,44 @@
+package bookmarks
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	plist "github.com/zach-klippenstein/goplist"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+/*
+EncodeWebloc writes a .webloc file that opens url. The Finder saves web links
+in .webloc files and other links, like ftp: ones, in .inetloc files, but both
+are a dict with the URL under the URL key, so this writes either.
+*/
+func EncodeWebloc(w io.Writer, url string) error {
+	return xml.EncodeDictPlist(w, func(e *xml.DictEncoder) error {
+		return e.WriteString("URL", url)
+	})
+}
+
+// DecodeWebloc reads a .webloc or .inetloc file, in XML or binary format, and
+// returns its URL.
+func DecodeWebloc(r io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	value, _, err := plist.Unmarshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("bookmarks: webloc is not a dict")
+	}
+	url, ok := dict["URL"].(string)
+	if !ok {
+		return "", fmt.Errorf("bookmarks: webloc has no URL")
+	}
+	return url, nil
+}
@@ -0,0 +1,44 @@
+package bookmarks
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/binary"
+)
+
+func TestEncodeWebloc(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, EncodeWebloc(&b, "https://example.com/?a=1&b=2"))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+	<dict>
+		<key>URL</key>
+		<string>https://example.com/?a=1&amp;b=2</string>
+	</dict>
+</plist>`, b.String())
+
+	url, err := DecodeWebloc(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/?a=1&b=2", url)
+}
+
+func TestDecodeWeblocBinary(t *testing.T) {
+	data, err := binary.Marshal(map[string]interface{}{"URL": "ftp://example.com/"})
+	assert.NoError(t, err)
+
+	url, err := DecodeWebloc(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "ftp://example.com/", url)
+}
+
+func TestDecodeWeblocErrors(t *testing.T) {
+	_, err := DecodeWebloc(strings.NewReader(`<plist><array/></plist>`))
+	assert.EqualError(t, err, "bookmarks: webloc is not a dict")
+
+	_, err = DecodeWebloc(strings.NewReader(`<plist><dict><key>URL</key><integer>1</integer></dict></plist>`))
+	assert.EqualError(t, err, "bookmarks: webloc has no URL")
+}
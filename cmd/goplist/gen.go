@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	plist "github.com/zach-klippenstein/goplist"
+	"github.com/zach-klippenstein/goplist/gen"
+)
+
+func runGen(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	var options gen.Options
+	flags.StringVar(&options.Package, "package", "main", "package `name` of the generated file")
+	flags.StringVar(&options.TypeName, "type", "Plist", "`name` of the generated type")
+	output := flags.String("o", "", "write the source to `file` instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no sample plists given")
+	}
+
+	var samples []interface{}
+	for _, name := range flags.Args() {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		sample, _, err := plist.Unmarshal(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		samples = append(samples, sample)
+	}
+
+	src, err := gen.Generate(samples, options)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, stdout, src)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/binary"
+)
+
+func TestRunGen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goplist")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	xmlSample := filepath.Join(dir, "a.plist")
+	assert.NoError(t, ioutil.WriteFile(xmlSample, []byte(`<plist><dict><key>Name</key><string>a</string></dict></plist>`), 0644))
+	binarySample := filepath.Join(dir, "b.plist")
+	data, _ := binary.Marshal(map[string]interface{}{"Name": "b", "Count": int64(1)})
+	assert.NoError(t, ioutil.WriteFile(binarySample, data, 0644))
+
+	var stdout bytes.Buffer
+	assert.NoError(t, runGen([]string{"-package", "models", "-type", "Item", xmlSample, binarySample}, &stdout))
+	assert.Equal(t, "// Code generated by goplist gen; DO NOT EDIT.\n\n"+
+		"package models\n\n"+
+		"type Item struct {\n"+
+		"\tCount *int   `plist:\"Count\"`\n"+
+		"\tName  string `plist:\"Name\"`\n"+
+		"}\n", stdout.String())
+
+	output := filepath.Join(dir, "item.go")
+	assert.NoError(t, runGen([]string{"-o", output, xmlSample}, &stdout))
+	src, err := ioutil.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "package main\n\ntype Plist struct {\n")
+}
+
+func TestRunGenErrors(t *testing.T) {
+	var stdout bytes.Buffer
+	assert.EqualError(t, runGen(nil, &stdout), "no sample plists given")
+
+	f, err := ioutil.TempFile("", "goplist")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString("not a plist")
+	f.Close()
+	err = runGen([]string{f.Name()}, &stdout)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), f.Name()+": ")
+	}
+}
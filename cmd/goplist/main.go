@@ -0,0 +1,72 @@
+/*
+Command goplist works with plist files.
+
+Usage:
+
+	goplist gen [-package name] [-type name] [-o file] sample.plist...
+	goplist validate -schema schema.plist file.plist...
+
+gen writes Go source for a type that the sample plists, in XML or binary
+format, can be unmarshaled into. See the gen package for how types are
+inferred. The source is written to standard output, or to the file given with -o.
+
+validate checks plists against a schema, as described in the schema package,
+and prints every violation. It exits with status 1 if any plist is invalid.
+*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: goplist <command> [arguments]
+
+commands:
+	gen       generate Go types from sample plists
+	validate  check plists against a schema
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "gen":
+		err = runGen(os.Args[2:], os.Stdout)
+	case "validate":
+		err = runValidate(os.Args[2:], os.Stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "goplist: unknown command %q\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goplist %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// writeOutput writes data to the file named output, or to stdout if output is empty.
+func writeOutput(output string, stdout io.Writer, data []byte) error {
+	if output == "" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
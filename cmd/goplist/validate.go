@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	plist "github.com/zach-klippenstein/goplist"
+	"github.com/zach-klippenstein/goplist/schema"
+)
+
+func runValidate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	schemaFile := flags.String("schema", "", "validate against the schema in `file`")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *schemaFile == "" {
+		return fmt.Errorf("-schema is required")
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no plists given")
+	}
+
+	data, err := ioutil.ReadFile(*schemaFile)
+	if err != nil {
+		return err
+	}
+	s, err := schema.Parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", *schemaFile, err)
+	}
+
+	invalid := 0
+	for _, name := range flags.Args() {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		value, _, err := plist.Unmarshal(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		errs := s.Validate(value)
+		if len(errs) > 0 {
+			invalid++
+		}
+		for _, err := range errs {
+			err := err.(*schema.ValidationError)
+			if err.Key == "" {
+				fmt.Fprintf(stdout, "%s: %s\n", name, err.Msg)
+			} else {
+				fmt.Fprintf(stdout, "%s: %s: %s\n", name, err.Key, err.Msg)
+			}
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d plists are invalid", invalid, flags.NArg())
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goplist")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"schema.plist": `<plist><dict>
+			<key>type</key><string>dict</string>
+			<key>required</key><array><string>Name</string></array>
+			<key>properties</key><dict><key>Port</key><dict><key>type</key><string>integer</string></dict></dict>
+		</dict></plist>`,
+		"valid.plist":   `<plist><dict><key>Name</key><string>a</string><key>Port</key><integer>80</integer></dict></plist>`,
+		"invalid.plist": `<plist><dict><key>Port</key><string>80</string></dict></plist>`,
+		"array.plist":   `<plist><array/></plist>`,
+	}
+	for name, contents := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	var stdout bytes.Buffer
+	assert.NoError(t, runValidate([]string{"-schema", path("schema.plist"), path("valid.plist")}, &stdout))
+	assert.Empty(t, stdout.String())
+
+	err = runValidate([]string{"-schema", path("schema.plist"), path("valid.plist"), path("invalid.plist"), path("array.plist")}, &stdout)
+	assert.EqualError(t, err, "2 of 3 plists are invalid")
+	assert.Equal(t, path("invalid.plist")+": Name: is required\n"+
+		path("invalid.plist")+": Port: must be of type integer, found string\n"+
+		path("array.plist")+": must be of type dict, found array\n", stdout.String())
+}
+
+func TestRunValidateErrors(t *testing.T) {
+	var stdout bytes.Buffer
+	assert.EqualError(t, runValidate([]string{"a.plist"}, &stdout), "-schema is required")
+	assert.EqualError(t, runValidate([]string{"-schema", "schema.plist"}, &stdout), "no plists given")
+
+	f, err := ioutil.TempFile("", "goplist")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString(`<plist><dict><key>type</key><string>float</string></dict></plist>`)
+	f.Close()
+	assert.EqualError(t, runValidate([]string{"-schema", f.Name(), "a.plist"}, &stdout), f.Name()+`: schema: unknown type "float"`)
+}
@@ -0,0 +1,269 @@
+/*
+Package defaults emulates the macOS defaults command against a directory of
+preferences plists, like ~/Library/Preferences, so tools that use it can be
+tested on other systems.
+
+Each domain is stored in <domain>.plist in the directory, and NSGlobalDomain in
+.GlobalPreferences.plist. A domain can also be given as the absolute path of a
+plist, with or without its .plist extension, as defaults allows. Files may be
+XML or binary plists, and are written back in the format they were read in.
+New files are binary, as cfprefsd writes them.
+*/
+package defaults
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	plist "github.com/zach-klippenstein/goplist"
+)
+
+// GlobalDomain is the domain whose settings apply to every application.
+const GlobalDomain = "NSGlobalDomain"
+
+const globalFile = ".GlobalPreferences"
+
+// Defaults reads and writes the domains in a preferences directory.
+type Defaults struct {
+	Dir string
+}
+
+// New returns a Defaults for the preferences directory dir.
+func New(dir string) *Defaults {
+	return &Defaults{Dir: dir}
+}
+
+// NotExistError is returned when reading or deleting a domain or key that
+// doesn't exist.
+type NotExistError struct {
+	Domain string
+	// Key is empty if the domain doesn't exist.
+	Key string
+}
+
+func (e *NotExistError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("defaults: domain %s does not exist", e.Domain)
+	}
+	return fmt.Sprintf("defaults: the domain/default pair of (%s, %s) does not exist", e.Domain, e.Key)
+}
+
+// Domains returns the names of the domains in the directory, sorted, not
+// including NSGlobalDomain.
+func (d *Defaults) Domains() ([]string, error) {
+	infos, err := ioutil.ReadDir(d.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var domains []string
+	for _, info := range infos {
+		name := info.Name()
+		if info.Mode().IsRegular() && strings.HasSuffix(name, ".plist") && name != globalFile+".plist" {
+			domains = append(domains, strings.TrimSuffix(name, ".plist"))
+		}
+	}
+	sort.Strings(domains)
+	return domains, nil
+}
+
+// Read returns all of a domain's settings.
+func (d *Defaults) Read(domain string) (map[string]interface{}, error) {
+	values, _, err := d.load(domain)
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		return nil, &NotExistError{Domain: domain}
+	}
+	return values, nil
+}
+
+// ReadKey returns a setting, with one of the types xml.PlistDecoder.DecodeValue returns.
+func (d *Defaults) ReadKey(domain, key string) (interface{}, error) {
+	values, _, err := d.load(domain)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := values[key]
+	if !ok {
+		return nil, &NotExistError{Domain: domain, Key: key}
+	}
+	return value, nil
+}
+
+/*
+Write sets a setting from command line arguments, as defaults write does:
+
+	d.Write("com.example.app", "Name", "-string", "Example")
+	d.Write("com.example.app", "Count", "-int", "3")
+	d.Write("com.example.app", "Enabled", "-bool", "YES")
+	d.Write("com.example.app", "Items", "-array", "a", "-int", "2")
+	d.Write("com.example.app", "Sizes", "-dict-add", "small", "-float", "0.5")
+
+A value without a type flag is a string. See ParseValue for the flags.
+-array-add and -dict-add add to an existing array or dict instead of
+replacing it.
+*/
+func (d *Defaults) Write(domain, key string, args ...string) error {
+	if len(args) > 0 && (args[0] == "-array-add" || args[0] == "-dict-add") {
+		return d.update(domain, func(values map[string]interface{}) error {
+			return add(values, key, args)
+		})
+	}
+
+	value, err := ParseValue(args)
+	if err != nil {
+		return err
+	}
+	return d.WriteValue(domain, key, value)
+}
+
+func add(values map[string]interface{}, key string, args []string) error {
+	value, err := ParseValue(append([]string{strings.TrimSuffix(args[0], "-add")}, args[1:]...))
+	if err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case []interface{}:
+		existing, ok := values[key].([]interface{})
+		if _, set := values[key]; set && !ok {
+			return fmt.Errorf("defaults: value for key %s is not an array", key)
+		}
+		values[key] = append(existing, value...)
+	case map[string]interface{}:
+		existing, ok := values[key].(map[string]interface{})
+		if _, set := values[key]; set && !ok {
+			return fmt.Errorf("defaults: value for key %s is not a dictionary", key)
+		}
+		if existing == nil {
+			existing = map[string]interface{}{}
+		}
+		for k, v := range value {
+			existing[k] = v
+		}
+		values[key] = existing
+	}
+	return nil
+}
+
+// WriteValue sets a setting to a value of any type plist.Marshal accepts.
+func (d *Defaults) WriteValue(domain, key string, value interface{}) error {
+	return d.update(domain, func(values map[string]interface{}) error {
+		values[key] = value
+		return nil
+	})
+}
+
+// Delete removes a domain.
+func (d *Defaults) Delete(domain string) error {
+	path, err := d.path(domain)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return &NotExistError{Domain: domain}
+	}
+	return err
+}
+
+// DeleteKey removes a setting from a domain.
+func (d *Defaults) DeleteKey(domain, key string) error {
+	return d.update(domain, func(values map[string]interface{}) error {
+		if _, ok := values[key]; !ok {
+			return &NotExistError{Domain: domain, Key: key}
+		}
+		delete(values, key)
+		return nil
+	})
+}
+
+// path returns the file a domain is stored in.
+func (d *Defaults) path(domain string) (string, error) {
+	switch {
+	case domain == GlobalDomain || domain == "-g" || domain == "-globalDomain":
+		return filepath.Join(d.Dir, globalFile+".plist"), nil
+	case filepath.IsAbs(domain):
+		if !strings.HasSuffix(domain, ".plist") {
+			domain += ".plist"
+		}
+		return domain, nil
+	case domain == "" || strings.ContainsRune(domain, filepath.Separator) || strings.HasPrefix(domain, "."):
+		return "", fmt.Errorf("defaults: invalid domain %q", domain)
+	}
+	return filepath.Join(d.Dir, domain+".plist"), nil
+}
+
+// load returns a domain's settings and the format of its file, or nil if the
+// file doesn't exist.
+func (d *Defaults) load(domain string) (map[string]interface{}, plist.Format, error) {
+	path, err := d.path(domain)
+	if err != nil {
+		return nil, plist.BinaryFormat, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, plist.BinaryFormat, nil
+	} else if err != nil {
+		return nil, plist.BinaryFormat, err
+	}
+	if len(data) == 0 {
+		return map[string]interface{}{}, plist.BinaryFormat, nil
+	}
+
+	value, format, err := plist.Unmarshal(data)
+	if err != nil {
+		return nil, format, fmt.Errorf("defaults: %s: %v", path, err)
+	}
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, format, fmt.Errorf("defaults: %s: root is not a dictionary", path)
+	}
+	return values, format, nil
+}
+
+// update changes a domain's settings and writes them back, replacing the file
+// atomically.
+func (d *Defaults) update(domain string, change func(map[string]interface{}) error) error {
+	values, format, err := d.load(domain)
+	if err != nil {
+		return err
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	if err := change(values); err != nil {
+		return err
+	}
+
+	data, err := plist.Marshal(values, format)
+	if err != nil {
+		return err
+	}
+	path, _ := d.path(domain)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,143 @@
+package defaults
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	plist "github.com/zach-klippenstein/goplist"
+)
+
+const xmlPrefs = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Name</key>
+	<string>Example</string>
+	<key>Count</key>
+	<integer>3</integer>
+</dict>
+</plist>`
+
+func tempDefaults(t *testing.T) (*Defaults, func()) {
+	dir, err := ioutil.TempDir("", "defaults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(dir), func() { os.RemoveAll(dir) }
+}
+
+func format(t *testing.T, path string) plist.Format {
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	_, format, err := plist.Unmarshal(data)
+	assert.NoError(t, err)
+	return format
+}
+
+func TestReadWriteXML(t *testing.T) {
+	d, cleanup := tempDefaults(t)
+	defer cleanup()
+	path := filepath.Join(d.Dir, "com.example.app.plist")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(xmlPrefs), 0600))
+
+	values, err := d.Read("com.example.app")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "Example", "Count": int64(3)}, values)
+
+	assert.NoError(t, d.Write("com.example.app", "Enabled", "-bool", "YES"))
+	value, err := d.ReadKey("com.example.app", "Enabled")
+	assert.NoError(t, err)
+	assert.Equal(t, true, value)
+	assert.Equal(t, plist.XMLFormat, format(t, path))
+}
+
+func TestWriteNewDomain(t *testing.T) {
+	d, cleanup := tempDefaults(t)
+	defer cleanup()
+
+	assert.NoError(t, d.Write("com.example.app", "Name", "Example"))
+	assert.NoError(t, d.Write("com.example.app", "Items", "-array", "a", "-int", "2"))
+	assert.NoError(t, d.WriteValue(GlobalDomain, "AppleLocale", "en_US"))
+
+	values, err := d.Read("com.example.app")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name":  "Example",
+		"Items": []interface{}{"a", int64(2)},
+	}, values)
+	assert.Equal(t, plist.BinaryFormat, format(t, filepath.Join(d.Dir, "com.example.app.plist")))
+
+	value, err := d.ReadKey("-g", "AppleLocale")
+	assert.NoError(t, err)
+	assert.Equal(t, "en_US", value)
+	assert.Equal(t, plist.BinaryFormat, format(t, filepath.Join(d.Dir, ".GlobalPreferences.plist")))
+
+	domains, err := d.Domains()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"com.example.app"}, domains)
+}
+
+func TestWriteAdd(t *testing.T) {
+	d, cleanup := tempDefaults(t)
+	defer cleanup()
+
+	assert.NoError(t, d.Write("app", "Items", "-array-add", "a"))
+	assert.NoError(t, d.Write("app", "Items", "-array-add", "b", "-int", "1"))
+	assert.NoError(t, d.Write("app", "Sizes", "-dict-add", "small", "-float", "0.5"))
+	assert.NoError(t, d.Write("app", "Sizes", "-dict-add", "large", "-float", "2"))
+
+	values, err := d.Read("app")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Items": []interface{}{"a", "b", int64(1)},
+		"Sizes": map[string]interface{}{"small": 0.5, "large": 2.0},
+	}, values)
+
+	assert.EqualError(t, d.Write("app", "Sizes", "-array-add", "a"), "defaults: value for key Sizes is not an array")
+	assert.EqualError(t, d.Write("app", "Items", "-dict-add", "a", "b"), "defaults: value for key Items is not a dictionary")
+}
+
+func TestAbsolutePath(t *testing.T) {
+	d, cleanup := tempDefaults(t)
+	defer cleanup()
+	path := filepath.Join(d.Dir, "Info")
+
+	assert.NoError(t, d.Write(path, "CFBundleVersion", "1.0"))
+	value, err := d.ReadKey(path+".plist", "CFBundleVersion")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0", value)
+}
+
+func TestDelete(t *testing.T) {
+	d, cleanup := tempDefaults(t)
+	defer cleanup()
+	assert.NoError(t, d.Write("app", "a", "1"))
+	assert.NoError(t, d.Write("app", "b", "2"))
+
+	assert.NoError(t, d.DeleteKey("app", "a"))
+	values, err := d.Read("app")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"b": "2"}, values)
+
+	err = d.DeleteKey("app", "a")
+	assert.Equal(t, &NotExistError{Domain: "app", Key: "a"}, err)
+	assert.EqualError(t, err, "defaults: the domain/default pair of (app, a) does not exist")
+
+	assert.NoError(t, d.Delete("app"))
+	_, err = d.Read("app")
+	assert.EqualError(t, err, "defaults: domain app does not exist")
+	assert.EqualError(t, d.Delete("app"), "defaults: domain app does not exist")
+}
+
+func TestInvalidDomain(t *testing.T) {
+	d, cleanup := tempDefaults(t)
+	defer cleanup()
+
+	for _, domain := range []string{"", "a/b", ".hidden"} {
+		_, err := d.Read(domain)
+		assert.EqualError(t, err, `defaults: invalid domain "`+domain+`"`)
+	}
+}
@@ -0,0 +1,124 @@
+package defaults
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateFormats are the formats -date accepts: the one defaults read prints, and ISO 8601.
+var dateFormats = []string{"2006-01-02 15:04:05 -0700", time.RFC3339}
+
+/*
+ParseValue converts defaults write arguments to a value:
+
+	value                    a string
+	-string value            a string
+	-data hex                []byte, from hex digits
+	-int, -integer value     int64
+	-float value             float64
+	-bool, -boolean value    bool: YES, NO, TRUE, FALSE, 1 or 0, in any case
+	-date value              time.Time, e.g. "2024-01-02 15:04:05 +0000"
+	-array items...          []interface{}
+	-dict key value...       map[string]interface{}
+
+Array items and dict values are strings, or typed by a flag like a single
+value, e.g. -array a -int 2. Dict keys are strings.
+*/
+func ParseValue(args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("defaults: no value given")
+	}
+
+	switch args[0] {
+	case "-array":
+		array := []interface{}{}
+		for rest := args[1:]; len(rest) > 0; {
+			var item interface{}
+			var err error
+			if item, rest, err = parseItem(rest); err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+		return array, nil
+	case "-dict":
+		dict := map[string]interface{}{}
+		for rest := args[1:]; len(rest) > 0; {
+			key := rest[0]
+			if len(rest) == 1 {
+				return nil, fmt.Errorf("defaults: no value for key %s in -dict", key)
+			}
+			var value interface{}
+			var err error
+			if value, rest, err = parseItem(rest[1:]); err != nil {
+				return nil, err
+			}
+			dict[key] = value
+		}
+		return dict, nil
+	}
+
+	value, rest, err := parseItem(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("defaults: unexpected argument %q", rest[0])
+	}
+	return value, nil
+}
+
+// parseItem parses a single value, with or without a type flag, and returns
+// the remaining arguments.
+func parseItem(args []string) (interface{}, []string, error) {
+	flag := args[0]
+	switch flag {
+	case "-string", "-data", "-int", "-integer", "-float", "-bool", "-boolean", "-date":
+	case "-array", "-dict", "-array-add", "-dict-add":
+		return nil, nil, fmt.Errorf("defaults: %s cannot be nested", flag)
+	default:
+		return flag, args[1:], nil
+	}
+
+	if len(args) < 2 {
+		return nil, nil, fmt.Errorf("defaults: no value given for %s", flag)
+	}
+	raw, rest := args[1], args[2:]
+	value, err := parseTyped(flag, raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("defaults: invalid value %q for %s", raw, flag)
+	}
+	return value, rest, nil
+}
+
+func parseTyped(flag, raw string) (interface{}, error) {
+	switch flag {
+	case "-data":
+		return hex.DecodeString(raw)
+	case "-int", "-integer":
+		return strconv.ParseInt(raw, 10, 64)
+	case "-float":
+		return strconv.ParseFloat(raw, 64)
+	case "-bool", "-boolean":
+		switch strings.ToUpper(raw) {
+		case "YES", "TRUE", "1":
+			return true, nil
+		case "NO", "FALSE", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("not a boolean")
+	case "-date":
+		var err error
+		for _, format := range dateFormats {
+			var date time.Time
+			if date, err = time.Parse(format, raw); err == nil {
+				return date.UTC(), nil
+			}
+		}
+		return nil, err
+	}
+	return raw, nil
+}
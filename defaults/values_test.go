@@ -0,0 +1,54 @@
+package defaults
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseValue(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		expected interface{}
+	}{
+		{[]string{"hello"}, "hello"},
+		{[]string{"-string", "-int"}, "-int"},
+		{[]string{"-data", "00ff"}, []byte{0, 0xFF}},
+		{[]string{"-int", "-3"}, int64(-3)},
+		{[]string{"-integer", "42"}, int64(42)},
+		{[]string{"-float", "0.5"}, 0.5},
+		{[]string{"-bool", "YES"}, true},
+		{[]string{"-boolean", "false"}, false},
+		{[]string{"-bool", "0"}, false},
+		{[]string{"-date", "2024-01-02 03:04:05 +0100"}, time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC)},
+		{[]string{"-date", "2024-01-02T03:04:05Z"}, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{[]string{"-array"}, []interface{}{}},
+		{[]string{"-array", "a", "-int", "2", "-bool", "no"}, []interface{}{"a", int64(2), false}},
+		{[]string{"-dict", "a", "b", "c", "-float", "1"}, map[string]interface{}{"a": "b", "c": 1.0}},
+	} {
+		value, err := ParseValue(test.args)
+		assert.NoError(t, err, "%q", test.args)
+		assert.Equal(t, test.expected, value, "%q", test.args)
+	}
+}
+
+func TestParseValueErrors(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{nil, "defaults: no value given"},
+		{[]string{"-int"}, "defaults: no value given for -int"},
+		{[]string{"-int", "x"}, `defaults: invalid value "x" for -int`},
+		{[]string{"-bool", "maybe"}, `defaults: invalid value "maybe" for -bool`},
+		{[]string{"-data", "abc"}, `defaults: invalid value "abc" for -data`},
+		{[]string{"-date", "yesterday"}, `defaults: invalid value "yesterday" for -date`},
+		{[]string{"a", "b"}, `defaults: unexpected argument "b"`},
+		{[]string{"-dict", "a"}, "defaults: no value for key a in -dict"},
+		{[]string{"-array", "-array"}, "defaults: -array cannot be nested"},
+	} {
+		_, err := ParseValue(test.args)
+		assert.EqualError(t, err, test.expected, "%q", test.args)
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Package entitlements reads code signing entitlements plists, and checks them
+against the entitlements granted by a provisioning profile.
+
+Provisioning profiles grant entitlements with wildcards, e.g. an
+application-identifier of "ABCDE12345.*" grants every app ID in team ABCDE12345,
+and an associated-domains value of "*" grants any list of domains. Satisfies
+implements the same matching codesign does.
+*/
+package entitlements
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Well-known entitlement keys.
+const (
+	ApplicationIdentifierKey = "application-identifier"
+	TeamIdentifierKey        = "com.apple.developer.team-identifier"
+	KeychainAccessGroupsKey  = "keychain-access-groups"
+	APSEnvironmentKey        = "aps-environment"
+	AssociatedDomainsKey     = "com.apple.developer.associated-domains"
+	ApplicationGroupsKey     = "com.apple.security.application-groups"
+	GetTaskAllowKey          = "get-task-allow"
+)
+
+// Entitlements is the contents of an entitlements plist, or the Entitlements
+// dict of a provisioning profile.
+type Entitlements struct {
+	ApplicationIdentifier string     `plist:"application-identifier"`
+	TeamIdentifier        string     `plist:"com.apple.developer.team-identifier"`
+	KeychainAccessGroups  []string   `plist:"keychain-access-groups"`
+	APSEnvironment        string     `plist:"aps-environment"`
+	AssociatedDomains     StringList `plist:"com.apple.developer.associated-domains"`
+	ApplicationGroups     []string   `plist:"com.apple.security.application-groups"`
+	GetTaskAllow          bool       `plist:"get-task-allow"`
+
+	// All holds every entitlement, including the ones with fields above. If
+	// it's nil, Mismatches compares the fields above instead.
+	All map[string]interface{} `plist:"-"`
+}
+
+/*
+StringList is a list of strings that may be written in a plist as either an
+array, or a single string. Provisioning profiles use "*" instead of an array
+to grant any value.
+*/
+type StringList []string
+
+func (l *StringList) UnmarshalPlist(value interface{}) error {
+	if value, ok := value.(string); ok {
+		*l = StringList{value}
+		return nil
+	}
+	return xml.UnmarshalValue(value, (*[]string)(l))
+}
+
+// Decode reads an entitlements plist from r.
+func Decode(r io.Reader) (*Entitlements, error) {
+	value, err := xml.NewDecoder(r).DecodeValue()
+	if err != nil {
+		return nil, err
+	}
+	return FromValue(value)
+}
+
+// FromValue converts a dict decoded by xml.PlistDecoder.DecodeValue to Entitlements.
+func FromValue(value interface{}) (*Entitlements, error) {
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("entitlements must be a dict, found %T", value)
+	}
+
+	e := Entitlements{All: dict}
+	if err := xml.UnmarshalValue(dict, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// TeamID returns the team ID prefix of the application identifier.
+func (e *Entitlements) TeamID() string {
+	if i := strings.Index(e.ApplicationIdentifier, "."); i >= 0 {
+		return e.ApplicationIdentifier[:i]
+	}
+	return ""
+}
+
+// BundleID returns the application identifier without its team ID prefix.
+func (e *Entitlements) BundleID() string {
+	if i := strings.Index(e.ApplicationIdentifier, "."); i >= 0 {
+		return e.ApplicationIdentifier[i+1:]
+	}
+	return e.ApplicationIdentifier
+}
+
+// Mismatch describes an entitlement that isn't granted.
+type Mismatch struct {
+	Key   string
+	Value interface{}
+	// Granted is the granted value, or nil if the key isn't granted at all.
+	Granted interface{}
+}
+
+func (m Mismatch) String() string {
+	if m.Granted == nil {
+		return fmt.Sprintf("%s: %v is not granted", m.Key, m.Value)
+	}
+	return fmt.Sprintf("%s: %v does not match granted %v", m.Key, m.Value, m.Granted)
+}
+
+// Satisfies returns true if every entitlement in e is granted by granted.
+func (e *Entitlements) Satisfies(granted *Entitlements) bool {
+	return len(e.Mismatches(granted)) == 0
+}
+
+/*
+Mismatches returns the entitlements in e that aren't granted by granted,
+sorted by key.
+
+A granted string ending in "*" matches any string with the same prefix, and
+"*" on its own matches any value, including arrays. Every string in an array
+must match the granted string, or one of the strings in the granted array.
+A false boolean never needs to be granted.
+*/
+func (e *Entitlements) Mismatches(granted *Entitlements) []Mismatch {
+	all, grantedAll := e.values(), granted.values()
+	var mismatches []Mismatch
+	for key, value := range all {
+		grantedValue, ok := grantedAll[key]
+		if !ok {
+			if value == false {
+				continue
+			}
+			mismatches = append(mismatches, Mismatch{key, value, nil})
+		} else if !matches(value, grantedValue) {
+			mismatches = append(mismatches, Mismatch{key, value, grantedValue})
+		}
+	}
+
+	sort.Sort(byKey(mismatches))
+	return mismatches
+}
+
+// values returns All, or if it's nil, the fields that are set, as they'd be
+// decoded into All.
+func (e *Entitlements) values() map[string]interface{} {
+	if e.All != nil {
+		return e.All
+	}
+
+	values := make(map[string]interface{})
+	setString := func(key, value string) {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	setStrings := func(key string, list []string) {
+		if len(list) > 0 {
+			items := make([]interface{}, len(list))
+			for i, item := range list {
+				items[i] = item
+			}
+			values[key] = items
+		}
+	}
+	setString(ApplicationIdentifierKey, e.ApplicationIdentifier)
+	setString(TeamIdentifierKey, e.TeamIdentifier)
+	setStrings(KeychainAccessGroupsKey, e.KeychainAccessGroups)
+	setString(APSEnvironmentKey, e.APSEnvironment)
+	setStrings(AssociatedDomainsKey, e.AssociatedDomains)
+	setStrings(ApplicationGroupsKey, e.ApplicationGroups)
+	if e.GetTaskAllow {
+		values[GetTaskAllowKey] = true
+	}
+	return values
+}
+
+// matches returns true if value is granted by granted.
+func matches(value, granted interface{}) bool {
+	if granted == "*" {
+		return true
+	}
+
+	switch value := value.(type) {
+	case string:
+		switch granted := granted.(type) {
+		case string:
+			return matchString(value, granted)
+		case []interface{}:
+			for _, grantedItem := range granted {
+				if grantedItem, ok := grantedItem.(string); ok && matchString(value, grantedItem) {
+					return true
+				}
+			}
+		}
+		return false
+	case []interface{}:
+		for _, item := range value {
+			if !matches(item, granted) {
+				return false
+			}
+		}
+		return true
+	case bool:
+		return !value || granted == true
+	}
+	return reflect.DeepEqual(value, granted)
+}
+
+// matchString returns true if value is equal to pattern, or starts with
+// pattern's prefix if pattern ends with a wildcard.
+func matchString(value, pattern string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(value, pattern[:len(pattern)-1])
+	}
+	return value == pattern
+}
+
+type byKey []Mismatch
+
+func (m byKey) Len() int           { return len(m) }
+func (m byKey) Less(i, j int) bool { return m[i].Key < m[j].Key }
+func (m byKey) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
@@ -0,0 +1,145 @@
+package entitlements
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const buildEntitlements = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>application-identifier</key>
+	<string>ABCDE12345.com.example.app</string>
+	<key>com.apple.developer.team-identifier</key>
+	<string>ABCDE12345</string>
+	<key>keychain-access-groups</key>
+	<array>
+		<string>ABCDE12345.com.example.app</string>
+		<string>ABCDE12345.shared</string>
+	</array>
+	<key>aps-environment</key>
+	<string>production</string>
+	<key>com.apple.developer.associated-domains</key>
+	<array>
+		<string>applinks:example.com</string>
+	</array>
+	<key>get-task-allow</key>
+	<false/>
+</dict>
+</plist>`
+
+const profileEntitlements = `<plist version="1.0">
+<dict>
+	<key>application-identifier</key>
+	<string>ABCDE12345.*</string>
+	<key>com.apple.developer.team-identifier</key>
+	<string>ABCDE12345</string>
+	<key>keychain-access-groups</key>
+	<array>
+		<string>ABCDE12345.*</string>
+	</array>
+	<key>aps-environment</key>
+	<string>production</string>
+	<key>com.apple.developer.associated-domains</key>
+	<string>*</string>
+</dict>
+</plist>`
+
+func TestDecode(t *testing.T) {
+	e, err := Decode(strings.NewReader(buildEntitlements))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "ABCDE12345.com.example.app", e.ApplicationIdentifier)
+	assert.Equal(t, "ABCDE12345", e.TeamID())
+	assert.Equal(t, "com.example.app", e.BundleID())
+	assert.Equal(t, "ABCDE12345", e.TeamIdentifier)
+	assert.Equal(t, []string{"ABCDE12345.com.example.app", "ABCDE12345.shared"}, e.KeychainAccessGroups)
+	assert.Equal(t, "production", e.APSEnvironment)
+	assert.Equal(t, StringList{"applinks:example.com"}, e.AssociatedDomains)
+	assert.False(t, e.GetTaskAllow)
+	assert.Len(t, e.All, 6)
+
+	profile, err := Decode(strings.NewReader(profileEntitlements))
+	assert.NoError(t, err)
+	assert.Equal(t, StringList{"*"}, profile.AssociatedDomains)
+}
+
+func TestSatisfies(t *testing.T) {
+	build, err := Decode(strings.NewReader(buildEntitlements))
+	assert.NoError(t, err)
+	profile, err := Decode(strings.NewReader(profileEntitlements))
+	assert.NoError(t, err)
+
+	assert.True(t, build.Satisfies(profile))
+	assert.Empty(t, build.Mismatches(profile))
+}
+
+func TestMismatches(t *testing.T) {
+	build, err := Decode(strings.NewReader(buildEntitlements))
+	assert.NoError(t, err)
+	build.All["aps-environment"] = "development"
+	build.All["keychain-access-groups"] = []interface{}{"ABCDE12345.shared", "OTHERTEAM.shared"}
+	build.All["com.apple.developer.icloud-services"] = []interface{}{"CloudKit"}
+	build.All["get-task-allow"] = true
+
+	profile, err := Decode(strings.NewReader(profileEntitlements))
+	assert.NoError(t, err)
+
+	assert.False(t, build.Satisfies(profile))
+	assert.Equal(t, []Mismatch{
+		{"aps-environment", "development", "production"},
+		{"com.apple.developer.icloud-services", []interface{}{"CloudKit"}, nil},
+		{"get-task-allow", true, nil},
+		{"keychain-access-groups", []interface{}{"ABCDE12345.shared", "OTHERTEAM.shared"}, []interface{}{"ABCDE12345.*"}},
+	}, build.Mismatches(profile))
+}
+
+func TestMismatchesWithoutAll(t *testing.T) {
+	profile, err := Decode(strings.NewReader(profileEntitlements))
+	assert.NoError(t, err)
+
+	build := &Entitlements{
+		ApplicationIdentifier: "ABCDE12345.com.example.app",
+		KeychainAccessGroups:  []string{"OTHERTEAM.shared"},
+		GetTaskAllow:          true,
+	}
+	assert.Equal(t, []Mismatch{
+		{"get-task-allow", true, nil},
+		{"keychain-access-groups", []interface{}{"OTHERTEAM.shared"}, []interface{}{"ABCDE12345.*"}},
+	}, build.Mismatches(profile))
+
+	build = &Entitlements{ApplicationIdentifier: "ABCDE12345.com.example.app"}
+	assert.True(t, build.Satisfies(profile))
+	assert.False(t, build.Satisfies(&Entitlements{ApplicationIdentifier: "OTHERTEAM.*"}))
+	assert.True(t, build.Satisfies(&Entitlements{ApplicationIdentifier: "ABCDE12345.*"}))
+}
+
+func TestMatches(t *testing.T) {
+	for _, test := range []struct {
+		value, granted interface{}
+		matches        bool
+	}{
+		{"ABCDE12345.com.example.app", "ABCDE12345.com.example.*", true},
+		{"ABCDE12345.com.other.app", "ABCDE12345.com.example.*", false},
+		{"ABCDE12345.com.example.app", "ABCDE12345.com.example.app", true},
+		{"anything", "*", true},
+		{[]interface{}{"a", "b"}, "*", true},
+		{"group.a", []interface{}{"group.b", "group.a"}, true},
+		{"group.c", []interface{}{"group.b", "group.a"}, false},
+		{true, true, true},
+		{true, false, false},
+		{false, true, true},
+		{int64(1), int64(1), true},
+		{int64(1), int64(2), false},
+	} {
+		assert.Equal(t, test.matches, matches(test.value, test.granted), "%v granted by %v", test.value, test.granted)
+	}
+}
+
+func TestMismatchString(t *testing.T) {
+	assert.Equal(t, "a: b is not granted", Mismatch{"a", "b", nil}.String())
+	assert.Equal(t, "a: b does not match granted c", Mismatch{"a", "b", "c"}.String())
+}
@@ -0,0 +1,387 @@
+/*
+Package gen generates Go types for plists from samples of them, for use with
+xml.UnmarshalValue.
+
+Every sample is merged into one type. Dicts become structs, with a field for
+every key seen in any sample. Fields for keys that are missing from some
+samples are optional: pointers, unless their type can already be nil. Array
+elements are merged the same way, so an array of dicts becomes a slice of one
+struct. Integers merge to int or uint64 if one of them holds every sample,
+and with reals to float64. Other differing types, including integers that are
+both negative and above math.MaxInt64, merge to interface{}.
+
+Dicts whose keys all start with a digit, like the track IDs that key the
+Tracks dict of an iTunes library, are assumed to be keyed by data, and become
+maps of their merged values.
+*/
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Options configures the generated source.
+type Options struct {
+	// Package is the name of the generated file's package, or "main" if empty.
+	Package string
+	// TypeName is the name of the type for the root value, or "Plist" if empty.
+	TypeName string
+}
+
+type kind int
+
+const (
+	// unknownKind is the kind of an empty array's elements.
+	unknownKind kind = iota
+	stringKind
+	boolKind
+	intKind
+	uintKind
+	floatKind
+	dateKind
+	dataKind
+	arrayKind
+	dictKind
+	// mixedKind is a value seen with types that don't merge, or that don't
+	// have a Go type of their own, like 128-bit integers.
+	mixedKind
+)
+
+// shape is what the samples of a value had in common.
+type shape struct {
+	kind kind
+	// elem is the shape of an array's elements, or a data-keyed dict's values.
+	elem *shape
+	// fields maps a dict's keys to the shapes of their values.
+	fields map[string]*field
+	// dicts is the number of dicts merged into the shape.
+	dicts int
+	// dataKeyed is true if a dict's keys are data rather than names.
+	dataKeyed bool
+	// negative is true if an integer was below zero, and huge if one was
+	// above math.MaxInt64. They decide what a mix of integers merges into.
+	negative, huge bool
+}
+
+type field struct {
+	shape *shape
+	// count is the number of dicts the key was in.
+	count int
+}
+
+/*
+Generate returns Go source declaring a type that every sample can be
+unmarshaled into. Samples are values as returned by xml.PlistDecoder.DecodeValue.
+The source is formatted, and imports the packages it needs.
+*/
+func Generate(samples []interface{}, options Options) ([]byte, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("gen: no samples")
+	}
+	if options.Package == "" {
+		options.Package = "main"
+	}
+	if options.TypeName == "" {
+		options.TypeName = "Plist"
+	}
+
+	root := new(shape)
+	for _, sample := range samples {
+		if err := root.merge(sample); err != nil {
+			return nil, err
+		}
+	}
+
+	var body bytes.Buffer
+	w := &writer{b: &body}
+	fmt.Fprintf(&body, "type %s ", options.TypeName)
+	w.writeType(root, false)
+	body.WriteString("\n")
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by goplist gen; DO NOT EDIT.\n\npackage %s\n\n", options.Package)
+	if w.usesTime {
+		src.WriteString("import \"time\"\n\n")
+	}
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+func (s *shape) merge(value interface{}) error {
+	var k kind
+	switch value := value.(type) {
+	case string:
+		k = stringKind
+	case bool:
+		k = boolKind
+	case int64:
+		k = intKind
+		s.negative = s.negative || value < 0
+	case uint64:
+		k = uintKind
+		s.huge = s.huge || value > math.MaxInt64
+	case float64, big.Float:
+		k = floatKind
+	case xml.Int128, big.Int:
+		k = mixedKind
+	case time.Time:
+		k = dateKind
+	case []byte:
+		k = dataKind
+	case []interface{}:
+		if s.setKind(arrayKind) {
+			if s.elem == nil {
+				s.elem = new(shape)
+			}
+			for _, elem := range value {
+				if err := s.elem.merge(elem); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		if s.setKind(dictKind) {
+			return s.mergeDict(value)
+		}
+		return nil
+	default:
+		return fmt.Errorf("gen: unsupported value of type %T", value)
+	}
+	s.setKind(k)
+	return nil
+}
+
+// setKind merges k into the shape's kind, and returns whether the shape is still of kind k.
+func (s *shape) setKind(k kind) bool {
+	switch {
+	case s.kind == unknownKind || s.kind == k:
+		s.kind = k
+	case isNumber(s.kind) && isNumber(k) && !(s.negative && s.huge):
+		// Integers only merge into a type that holds them all.
+		switch {
+		case s.kind == floatKind || k == floatKind:
+			s.kind = floatKind
+		case s.negative:
+			s.kind = intKind
+		default:
+			s.kind = uintKind
+		}
+	default:
+		s.kind = mixedKind
+		s.elem, s.fields = nil, nil
+	}
+	return s.kind == k
+}
+
+func isNumber(k kind) bool {
+	return k == intKind || k == uintKind || k == floatKind
+}
+
+func (s *shape) mergeDict(dict map[string]interface{}) error {
+	if s.fields == nil {
+		s.fields = map[string]*field{}
+		s.dataKeyed = true
+	}
+	s.dicts++
+	for key, value := range dict {
+		if s.dataKeyed && !isDataKey(key) {
+			s.dataKeyed = false
+		}
+		f := s.fields[key]
+		if f == nil {
+			f = &field{shape: new(shape)}
+			s.fields[key] = f
+		}
+		f.count++
+		if err := f.shape.merge(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isDataKey(key string) bool {
+	for _, r := range key {
+		return unicode.IsDigit(r)
+	}
+	return false
+}
+
+type writer struct {
+	b        *bytes.Buffer
+	usesTime bool
+}
+
+// writeType writes the Go type for s. optional types that can't be nil are pointers.
+func (w *writer) writeType(s *shape, optional bool) {
+	if optional {
+		switch s.kind {
+		case stringKind, boolKind, intKind, uintKind, floatKind, dateKind:
+			w.b.WriteString("*")
+		case dictKind:
+			if len(s.fields) > 0 && !s.dataKeyed {
+				w.b.WriteString("*")
+			}
+		}
+	}
+
+	switch s.kind {
+	case stringKind:
+		w.b.WriteString("string")
+	case boolKind:
+		w.b.WriteString("bool")
+	case intKind:
+		w.b.WriteString("int")
+	case uintKind:
+		w.b.WriteString("uint64")
+	case floatKind:
+		w.b.WriteString("float64")
+	case dateKind:
+		w.usesTime = true
+		w.b.WriteString("time.Time")
+	case dataKind:
+		w.b.WriteString("[]byte")
+	case arrayKind:
+		w.b.WriteString("[]")
+		w.writeType(s.elem, false)
+	case dictKind:
+		w.writeDict(s)
+	default:
+		w.b.WriteString("interface{}")
+	}
+}
+
+func (w *writer) writeDict(s *shape) {
+	if len(s.fields) == 0 {
+		w.b.WriteString("map[string]interface{}")
+		return
+	}
+
+	keys := make([]string, 0, len(s.fields))
+	for key := range s.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if s.dataKeyed {
+		values := new(shape)
+		for _, key := range keys {
+			values.mergeShape(s.fields[key].shape)
+		}
+		w.b.WriteString("map[string]")
+		w.writeType(values, false)
+		return
+	}
+
+	w.b.WriteString("struct {\n")
+	names := map[string]bool{}
+	for _, key := range keys {
+		f := s.fields[key]
+		name := uniqueName(fieldName(key), names)
+		fmt.Fprintf(w.b, "%s ", name)
+		w.writeType(f.shape, f.count < s.dicts)
+		w.b.WriteString(" " + structTag(key) + "\n")
+	}
+	w.b.WriteString("}")
+}
+
+// structTag returns the tag for a field, as a raw string unless key contains a backquote.
+func structTag(key string) string {
+	tag := fmt.Sprintf("plist:%q", key)
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// mergeShape merges other into s, as if the values merged into other had
+// been merged into s.
+func (s *shape) mergeShape(other *shape) {
+	if other.kind == unknownKind {
+		return
+	}
+	s.negative = s.negative || other.negative
+	s.huge = s.huge || other.huge
+	if !s.setKind(other.kind) {
+		return
+	}
+	switch other.kind {
+	case arrayKind:
+		if s.elem == nil {
+			s.elem = new(shape)
+		}
+		s.elem.mergeShape(other.elem)
+	case dictKind:
+		if s.fields == nil {
+			s.fields = map[string]*field{}
+			s.dataKeyed = true
+		}
+		s.dicts += other.dicts
+		s.dataKeyed = s.dataKeyed && other.dataKeyed
+		for key, otherField := range other.fields {
+			f := s.fields[key]
+			if f == nil {
+				f = &field{shape: new(shape)}
+				s.fields[key] = f
+			}
+			f.count += otherField.count
+			f.shape.mergeShape(otherField.shape)
+		}
+	}
+}
+
+// initialisms are written in upper case in field names, as golint suggests.
+var initialisms = map[string]bool{
+	"API": true, "HTTP": true, "HTTPS": true, "ID": true, "JSON": true, "UDID": true,
+	"UID": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// fieldName converts a dict key to an exported Go identifier, e.g. "Track ID"
+// to TrackID and "get-task-allow" to GetTaskAllow.
+func fieldName(key string) string {
+	parts := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var name bytes.Buffer
+	for _, part := range parts {
+		if upper := strings.ToUpper(part); initialisms[upper] {
+			name.WriteString(upper)
+			continue
+		}
+		runes := []rune(part)
+		name.WriteRune(unicode.ToUpper(runes[0]))
+		name.WriteString(string(runes[1:]))
+	}
+
+	if name.Len() == 0 {
+		return "Field"
+	}
+	if first := []rune(name.String())[0]; !unicode.IsLetter(first) || !unicode.IsUpper(first) {
+		return "X" + name.String()
+	}
+	return name.String()
+}
+
+// uniqueName returns name, with a number appended if it's already in names,
+// and adds it to names.
+func uniqueName(name string, names map[string]bool) string {
+	unique := name
+	for i := 2; names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	names[unique] = true
+	return unique
+}
@@ -0,0 +1,160 @@
+package gen
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+func decode(t *testing.T, plist string) interface{} {
+	value, err := xml.NewDecoder(strings.NewReader(plist)).DecodeValue()
+	assert.NoError(t, err)
+	return value
+}
+
+func TestGenerate(t *testing.T) {
+	samples := []interface{}{
+		decode(t, `<plist><dict>
+			<key>CFBundleIdentifier</key><string>com.example.app</string>
+			<key>Build Date</key><date>2024-01-02T03:04:05Z</date>
+			<key>Version</key><integer>1</integer>
+			<key>get-task-allow</key><true/>
+			<key>URL Types</key>
+			<array>
+				<dict><key>Name</key><string>a</string><key>Schemes</key><array><string>x</string></array></dict>
+				<dict><key>Name</key><string>b</string><key>Role</key><string>Editor</string></dict>
+			</array>
+			<key>Tracks</key>
+			<dict>
+				<key>101</key><dict><key>Track ID</key><integer>101</integer></dict>
+				<key>102</key><dict><key>Track ID</key><integer>102</integer><key>Rating</key><integer>80</integer></dict>
+			</dict>
+			<key>Icon</key><data>AQI=</data>
+			<key>Empty</key><array/>
+			<key>Options</key><dict/>
+		</dict></plist>`),
+		decode(t, `<plist><dict>
+			<key>CFBundleIdentifier</key><string>com.example.other</string>
+			<key>Build Date</key><date>2024-01-02T03:04:05Z</date>
+			<key>Version</key><real>1.5</real>
+			<key>Mixed</key><string>a</string>
+			<key>Nested</key><dict><key>$key</key><string>v</string></dict>
+		</dict></plist>`),
+		map[string]interface{}{"Mixed": int64(1)},
+	}
+
+	src, err := Generate(samples, Options{Package: "models", TypeName: "App"})
+	assert.NoError(t, err)
+	assert.Equal(t, "// Code generated by goplist gen; DO NOT EDIT.\n\n"+
+		"package models\n\n"+
+		"import \"time\"\n\n"+
+		"type App struct {\n"+
+		"\tBuildDate          *time.Time    `plist:\"Build Date\"`\n"+
+		"\tCFBundleIdentifier *string       `plist:\"CFBundleIdentifier\"`\n"+
+		"\tEmpty              []interface{} `plist:\"Empty\"`\n"+
+		"\tIcon               []byte        `plist:\"Icon\"`\n"+
+		"\tMixed              interface{}   `plist:\"Mixed\"`\n"+
+		"\tNested             *struct {\n"+
+		"\t\tKey string `plist:\"$key\"`\n"+
+		"\t} `plist:\"Nested\"`\n"+
+		"\tOptions map[string]interface{} `plist:\"Options\"`\n"+
+		"\tTracks  map[string]struct {\n"+
+		"\t\tRating  *int `plist:\"Rating\"`\n"+
+		"\t\tTrackID int  `plist:\"Track ID\"`\n"+
+		"\t} `plist:\"Tracks\"`\n"+
+		"\tURLTypes []struct {\n"+
+		"\t\tName    string   `plist:\"Name\"`\n"+
+		"\t\tRole    *string  `plist:\"Role\"`\n"+
+		"\t\tSchemes []string `plist:\"Schemes\"`\n"+
+		"\t} `plist:\"URL Types\"`\n"+
+		"\tVersion      *float64 `plist:\"Version\"`\n"+
+		"\tGetTaskAllow *bool    `plist:\"get-task-allow\"`\n"+
+		"}\n", string(src))
+}
+
+func TestGenerateArray(t *testing.T) {
+	src, err := Generate([]interface{}{
+		[]interface{}{uint64(1 << 63), "b"},
+		[]interface{}{[]byte{1}},
+	}, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, "// Code generated by goplist gen; DO NOT EDIT.\n\npackage main\n\ntype Plist []interface{}\n", string(src))
+
+	for _, test := range []struct {
+		sample interface{}
+		want   string
+	}{
+		{[]interface{}{uint64(1 << 63), int64(1)}, "type Plist []uint64\n"},
+		{[]interface{}{int64(-1), uint64(1 << 62)}, "type Plist []int\n"},
+		{[]interface{}{uint64(1 << 63), int64(-1)}, "type Plist []interface{}\n"},
+		{[]interface{}{int64(-1), uint64(1 << 63)}, "type Plist []interface{}\n"},
+		{[]interface{}{int64(1), 1.5}, "type Plist []float64\n"},
+		{[]interface{}{1.5, int64(-1), uint64(1 << 63)}, "type Plist []interface{}\n"},
+		{
+			map[string]interface{}{"Tracks": map[string]interface{}{"1": int64(-5), "2": uint64(1<<64 - 1)}},
+			"Tracks map[string]interface{} `plist:\"Tracks\"`\n",
+		},
+	} {
+		src, err = Generate([]interface{}{test.sample}, Options{})
+		assert.NoError(t, err)
+		assert.Contains(t, string(src), test.want, "%v", test.sample)
+	}
+}
+
+func TestGenerateTypes(t *testing.T) {
+	src, err := Generate([]interface{}{map[string]interface{}{
+		"big":    *big.NewInt(1),
+		"int128": xml.Int128{Hi: 1},
+		"uint":   uint64(1 << 63),
+		"when":   time.Time{},
+		"a`b":    "",
+		"A B":    "",
+		"A-B":    "",
+		"1st":    "",
+		"!":      "",
+	}}, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, "// Code generated by goplist gen; DO NOT EDIT.\n\n"+
+		"package main\n\n"+
+		"import \"time\"\n\n"+
+		"type Plist struct {\n"+
+		"\tField  string      `plist:\"!\"`\n"+
+		"\tX1st   string      `plist:\"1st\"`\n"+
+		"\tAB     string      `plist:\"A B\"`\n"+
+		"\tAB2    string      `plist:\"A-B\"`\n"+
+		"\tAB3    string      \"plist:\\\"a`b\\\"\"\n"+
+		"\tBig    interface{} `plist:\"big\"`\n"+
+		"\tInt128 interface{} `plist:\"int128\"`\n"+
+		"\tUint   uint64      `plist:\"uint\"`\n"+
+		"\tWhen   time.Time   `plist:\"when\"`\n"+
+		"}\n", string(src))
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := Generate(nil, Options{})
+	assert.EqualError(t, err, "gen: no samples")
+
+	_, err = Generate([]interface{}{map[string]interface{}{"a": struct{}{}}}, Options{})
+	assert.EqualError(t, err, "gen: unsupported value of type struct {}")
+}
+
+func TestFieldName(t *testing.T) {
+	for key, expected := range map[string]string{
+		"CFBundleIdentifier":                  "CFBundleIdentifier",
+		"Track ID":                            "TrackID",
+		"get-task-allow":                      "GetTaskAllow",
+		"com.apple.developer.team-identifier": "ComAppleDeveloperTeamIdentifier",
+		"$objects":                            "Objects",
+		"url":                                 "URL",
+		"uuid_string":                         "UUIDString",
+		"2x":                                  "X2x",
+		"日本":                                  "X日本",
+		"":                                    "Field",
+	} {
+		assert.Equal(t, expected, fieldName(key), key)
+	}
+}
@@ -0,0 +1,348 @@
+//go:build go1.18
+
+package plist
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Option changes how As and Get convert values.
+type Option int
+
+const (
+	// FloatFromInteger lets integers be converted to floats, which loses
+	// precision for integers too large to be represented exactly.
+	FloatFromInteger Option = iota + 1
+)
+
+/*
+Path is the location of a value in a plist: a sequence of dict keys, as
+strings, and array indexes, as ints. Keys aren't escaped, so they can contain
+any characters, including dots.
+*/
+type Path []interface{}
+
+// String returns the path in the form used in error messages, e.g. "a.b[2]".
+func (p Path) String() string {
+	var b strings.Builder
+	for _, elem := range p {
+		if index, ok := elem.(int); ok {
+			fmt.Fprintf(&b, "[%d]", index)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		fmt.Fprint(&b, elem)
+	}
+	return b.String()
+}
+
+// append returns a copy of p with elem appended, which doesn't share p's array.
+func (p Path) append(elem interface{}) Path {
+	return append(p[:len(p):len(p)], elem)
+}
+
+// PathError is returned by Get when a path doesn't lead to a value.
+type PathError struct {
+	// Path is the part of the path that was followed, up to and including the
+	// element that couldn't be.
+	Path Path
+	Msg  string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("plist: %s: %s", e.Path, e.Msg)
+}
+
+// ConversionError is returned by As and Get when a value can't be converted
+// to the requested type.
+type ConversionError struct {
+	// Value describes the value, e.g. "string" or "integer 300".
+	Value string
+	Type  reflect.Type
+	// Path is the location of the value in the one passed to As or Get.
+	Path Path
+	// Reason explains why a value of the right kind couldn't be converted, or
+	// is empty.
+	Reason string
+}
+
+func (e *ConversionError) Error() string {
+	msg := fmt.Sprintf("plist: cannot convert %s to %s", e.Value, e.Type)
+	if len(e.Path) > 0 {
+		msg += " at " + e.Path.String()
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+/*
+As converts v, a value as returned by Unmarshal, to T.
+
+v is returned as is if it's already a T, or T is an interface it implements.
+Otherwise:
+
+	Integers, whether int64, uint64, xml.Int128 or big.Int, convert to any
+	integer type they're in range of, and to *big.Int.
+	Reals, whether float64 or big.Float, convert to any float type they're in
+	range of, and to *big.Float. Integers do too, with the FloatFromInteger option.
+	Strings and booleans convert to types with those underlying types.
+	Arrays convert to slices, and dicts to maps with string keys, by converting
+	each of their values.
+	Any of these convert to pointers to the types they convert to.
+
+Reals never convert to integers, and nothing converts to a string. Values that
+don't convert return a *ConversionError.
+*/
+func As[T any](v interface{}, options ...Option) (T, error) {
+	var result T
+	c := converter{}
+	for _, option := range options {
+		if option == FloatFromInteger {
+			c.floatFromInteger = true
+		}
+	}
+	err := c.convert(v, reflect.ValueOf(&result).Elem(), nil)
+	return result, err
+}
+
+/*
+Get returns the value at path in v, converted to T as As does. For example,
+this returns the first URL scheme in an Info.plist:
+
+	scheme, err := plist.Get[string](info, plist.Path{"CFBundleURLTypes", 0, "CFBundleURLSchemes", 0})
+
+If a key is missing, an index is out of range, or a value isn't a dict or
+array where the path goes into one, Get returns a *PathError.
+*/
+func Get[T any](v interface{}, path Path, options ...Option) (T, error) {
+	for i, elem := range path {
+		followed := path[:i+1]
+		switch elem := elem.(type) {
+		case string:
+			dict, ok := v.(map[string]interface{})
+			if !ok {
+				var zero T
+				return zero, &PathError{followed, fmt.Sprintf("cannot get key from %s", describe(v))}
+			}
+			if v, ok = dict[elem]; !ok {
+				var zero T
+				return zero, &PathError{followed, "key not found"}
+			}
+		case int:
+			array, ok := v.([]interface{})
+			if !ok {
+				var zero T
+				return zero, &PathError{followed, fmt.Sprintf("cannot index %s", describe(v))}
+			}
+			if elem < 0 || elem >= len(array) {
+				var zero T
+				return zero, &PathError{followed, fmt.Sprintf("index out of range for array of length %d", len(array))}
+			}
+			v = array[elem]
+		default:
+			var zero T
+			return zero, &PathError{followed, fmt.Sprintf("invalid path element of type %T", elem)}
+		}
+	}
+
+	result, err := As[T](v, options...)
+	if err, ok := err.(*ConversionError); ok {
+		err.Path = append(path[:len(path):len(path)], err.Path...)
+	}
+	return result, err
+}
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+type converter struct {
+	floatFromInteger bool
+}
+
+func (c converter) convert(value interface{}, v reflect.Value, path Path) error {
+	if value != nil && reflect.TypeOf(value).AssignableTo(v.Type()) {
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	fail := func(reason string) error {
+		return &ConversionError{Value: describe(value), Type: v.Type(), Path: path, Reason: reason}
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := bigInt(value); ok {
+			if !i.IsInt64() || v.OverflowInt(i.Int64()) {
+				return fail("out of range")
+			}
+			v.SetInt(i.Int64())
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := bigInt(value); ok {
+			if !i.IsUint64() || v.OverflowUint(i.Uint64()) {
+				return fail("out of range")
+			}
+			v.SetUint(i.Uint64())
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := value.(float64); ok {
+			if v.OverflowFloat(f) {
+				return fail("out of range")
+			}
+			v.SetFloat(f)
+			return nil
+		}
+		f, ok, err := c.bigFloat(value)
+		if err != nil {
+			return fail(err.Error())
+		}
+		if ok {
+			f64, _ := f.Float64()
+			// Float64 rounds values too large for a float64 to infinity.
+			if (math.IsInf(f64, 0) && !f.IsInf()) || v.OverflowFloat(f64) {
+				return fail("out of range")
+			}
+			v.SetFloat(f64)
+			return nil
+		}
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			v.SetString(s)
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			v.SetBool(b)
+			return nil
+		}
+	case reflect.Ptr:
+		switch v.Type().Elem() {
+		case bigIntType:
+			if i, ok := bigInt(value); ok {
+				v.Set(reflect.ValueOf(i))
+				return nil
+			}
+			return fail("")
+		case bigFloatType:
+			f, ok, err := c.bigFloat(value)
+			if err != nil {
+				return fail(err.Error())
+			}
+			if ok {
+				v.Set(reflect.ValueOf(f))
+				return nil
+			}
+			return fail("")
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := c.convert(value, elem.Elem(), path); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Slice:
+		if array, ok := value.([]interface{}); ok {
+			slice := reflect.MakeSlice(v.Type(), len(array), len(array))
+			for i, elem := range array {
+				if err := c.convert(elem, slice.Index(i), path.append(i)); err != nil {
+					return err
+				}
+			}
+			v.Set(slice)
+			return nil
+		}
+	case reflect.Map:
+		if dict, ok := value.(map[string]interface{}); ok && v.Type().Key().Kind() == reflect.String {
+			m := reflect.MakeMapWithSize(v.Type(), len(dict))
+			for key, entryValue := range dict {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := c.convert(entryValue, elem, path.append(key)); err != nil {
+					return err
+				}
+				m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+			}
+			v.Set(m)
+			return nil
+		}
+	}
+	return fail("")
+}
+
+// bigFloat returns a real value, or an integer value if floatFromInteger is
+// set, as a big.Float.
+func (c converter) bigFloat(value interface{}) (*big.Float, bool, error) {
+	switch value := value.(type) {
+	case float64:
+		if math.IsNaN(value) {
+			return nil, false, fmt.Errorf("NaN cannot be represented")
+		}
+		return big.NewFloat(value), true, nil
+	case big.Float:
+		return new(big.Float).Copy(&value), true, nil
+	}
+	if i, ok := bigInt(value); ok {
+		if !c.floatFromInteger {
+			return nil, false, fmt.Errorf("integers convert to floats only with FloatFromInteger")
+		}
+		return new(big.Float).SetInt(i), true, nil
+	}
+	return nil, false, nil
+}
+
+func bigInt(value interface{}) (*big.Int, bool) {
+	switch value := value.(type) {
+	case int64:
+		return big.NewInt(value), true
+	case uint64:
+		return new(big.Int).SetUint64(value), true
+	case xml.Int128:
+		return value.BigInt(), true
+	case big.Int:
+		return new(big.Int).Set(&value), true
+	}
+	return nil, false
+}
+
+// describe returns the plist type name of a decoded value, and the value
+// itself if it's a number.
+func describe(value interface{}) string {
+	if i, ok := bigInt(value); ok {
+		return "integer " + i.String()
+	}
+	switch value := value.(type) {
+	case float64:
+		return fmt.Sprintf("real %v", value)
+	case big.Float:
+		return "real " + value.Text('g', -1)
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case time.Time:
+		return "date"
+	case []byte:
+		return "data"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "dict"
+	case nil:
+		return "nil"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,158 @@
+//go:build go1.18
+
+package plist
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+type port uint16
+
+func TestAs(t *testing.T) {
+	i, err := As[int64](uint64(1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), i)
+
+	u, err := As[uint64](xml.Int128{Lo: 1 << 63})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1<<63), u)
+
+	p, err := As[port](*big.NewInt(443))
+	assert.NoError(t, err)
+	assert.Equal(t, port(443), p)
+
+	f, err := As[float32](1.5)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(1.5), f)
+
+	f64, err := As[float64](*big.NewFloat(2.5))
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, f64)
+
+	f64, err = As[float64](int64(3), FloatFromInteger)
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, f64)
+
+	bi, err := As[*big.Int](uint64(math.MaxUint64))
+	assert.NoError(t, err)
+	assert.Equal(t, "18446744073709551615", bi.String())
+
+	bf, err := As[*big.Float](1.5)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.5", bf.String())
+
+	s, err := As[*string]("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", *s)
+
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	d, err := As[time.Time](date)
+	assert.NoError(t, err)
+	assert.Equal(t, date, d)
+
+	data, err := As[[]byte]([]byte{1})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, data)
+
+	ports, err := As[[]port]([]interface{}{int64(80), uint64(443)})
+	assert.NoError(t, err)
+	assert.Equal(t, []port{80, 443}, ports)
+
+	flags, err := As[map[string]bool](map[string]interface{}{"a": true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": true}, flags)
+
+	dict, err := As[map[string]interface{}](map[string]interface{}{"a": true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": true}, dict)
+
+	any, err := As[interface{}](int64(1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), any)
+}
+
+func hugeFloat() *big.Float {
+	f, _, err := big.ParseFloat("1e400", 10, 64, big.ToNearestEven)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestAsErrors(t *testing.T) {
+	for _, test := range []struct {
+		convert  func() error
+		expected string
+	}{
+		{func() error { _, err := As[int8](int64(300)); return err }, "plist: cannot convert integer 300 to int8: out of range"},
+		{func() error { _, err := As[uint](int64(-1)); return err }, "plist: cannot convert integer -1 to uint: out of range"},
+		{func() error { _, err := As[int64](uint64(1 << 63)); return err }, "plist: cannot convert integer 9223372036854775808 to int64: out of range"},
+		{func() error { _, err := As[int](1.5); return err }, "plist: cannot convert real 1.5 to int"},
+		{func() error { _, err := As[float64](int64(1)); return err }, "plist: cannot convert integer 1 to float64: integers convert to floats only with FloatFromInteger"},
+		{func() error { _, err := As[float32](math.MaxFloat64); return err }, "plist: cannot convert real 1.7976931348623157e+308 to float32: out of range"},
+		{func() error { _, err := As[float64](*hugeFloat()); return err }, "plist: cannot convert real 1e+400 to float64: out of range"},
+		{func() error {
+			_, err := As[float64](*new(big.Int).Exp(big.NewInt(10), big.NewInt(400), nil), FloatFromInteger)
+			return err
+		}, "plist: cannot convert integer 1" + strings.Repeat("0", 400) + " to float64: out of range"},
+		{func() error { _, err := As[*big.Float](math.NaN()); return err }, "plist: cannot convert real NaN to *big.Float: NaN cannot be represented"},
+		{func() error { _, err := As[string](int64(1)); return err }, "plist: cannot convert integer 1 to string"},
+		{func() error { _, err := As[bool]("true"); return err }, "plist: cannot convert string to bool"},
+		{func() error { _, err := As[[]string]([]interface{}{"a", true}); return err }, "plist: cannot convert bool to string at [1]"},
+		{func() error { _, err := As[map[string]int](map[string]interface{}{"a": "b"}); return err }, "plist: cannot convert string to int at a"},
+	} {
+		assert.EqualError(t, test.convert(), test.expected)
+	}
+
+	_, err := As[int8](int64(300))
+	assert.Equal(t, &ConversionError{Value: "integer 300", Type: reflect.TypeOf(int8(0)), Reason: "out of range"}, err)
+}
+
+func TestGet(t *testing.T) {
+	info := map[string]interface{}{
+		"CFBundleURLTypes": []interface{}{
+			map[string]interface{}{"CFBundleURLSchemes": []interface{}{"example"}},
+		},
+		"com.apple.security.app-sandbox": true,
+		"Port":                           int64(80),
+	}
+
+	scheme, err := Get[string](info, Path{"CFBundleURLTypes", 0, "CFBundleURLSchemes", 0})
+	assert.NoError(t, err)
+	assert.Equal(t, "example", scheme)
+
+	sandbox, err := Get[bool](info, Path{"com.apple.security.app-sandbox"})
+	assert.NoError(t, err)
+	assert.True(t, sandbox)
+
+	root, err := Get[map[string]interface{}](info, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, info, root)
+
+	_, err = Get[string](info, Path{"Missing"})
+	assert.Equal(t, &PathError{Path{"Missing"}, "key not found"}, err)
+	assert.EqualError(t, err, "plist: Missing: key not found")
+
+	_, err = Get[string](info, Path{"CFBundleURLTypes", 1})
+	assert.EqualError(t, err, "plist: CFBundleURLTypes[1]: index out of range for array of length 1")
+
+	_, err = Get[string](info, Path{"CFBundleURLTypes", "a"})
+	assert.EqualError(t, err, "plist: CFBundleURLTypes.a: cannot get key from array")
+
+	_, err = Get[string](info, Path{"Port", 0})
+	assert.EqualError(t, err, "plist: Port[0]: cannot index integer 80")
+
+	_, err = Get[string](info, Path{1.5})
+	assert.EqualError(t, err, "plist: 1.5: invalid path element of type float64")
+
+	_, err = Get[[]int](info, Path{"CFBundleURLTypes", 0, "CFBundleURLSchemes"})
+	assert.EqualError(t, err, "plist: cannot convert string to int at CFBundleURLTypes[0].CFBundleURLSchemes[0]")
+}
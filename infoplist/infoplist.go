@@ -0,0 +1,218 @@
+/*
+Package infoplist reads the Info.plist files found in application and framework bundles.
+
+Documented keys are decoded into the fields of Info. Everything else is kept in
+Info.Unknown, so no information is lost. The keys are documented at
+https://developer.apple.com/documentation/bundleresources/information_property_list
+*/
+package infoplist
+
+import (
+	"io"
+	"strings"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Info is the contents of an Info.plist file.
+type Info struct {
+	// Core Foundation keys.
+	CFBundleDevelopmentRegion       string                 `plist:"CFBundleDevelopmentRegion"`
+	CFBundleDisplayName             string                 `plist:"CFBundleDisplayName"`
+	CFBundleExecutable              string                 `plist:"CFBundleExecutable"`
+	CFBundleIconFile                string                 `plist:"CFBundleIconFile"`
+	CFBundleIconFiles               []string               `plist:"CFBundleIconFiles"`
+	CFBundleIcons                   map[string]interface{} `plist:"CFBundleIcons"`
+	CFBundleIdentifier              string                 `plist:"CFBundleIdentifier"`
+	CFBundleInfoDictionaryVersion   string                 `plist:"CFBundleInfoDictionaryVersion"`
+	CFBundleName                    string                 `plist:"CFBundleName"`
+	CFBundlePackageType             string                 `plist:"CFBundlePackageType"`
+	CFBundleShortVersionString      string                 `plist:"CFBundleShortVersionString"`
+	CFBundleSignature               string                 `plist:"CFBundleSignature"`
+	CFBundleVersion                 string                 `plist:"CFBundleVersion"`
+	CFBundleSupportedPlatforms      []string               `plist:"CFBundleSupportedPlatforms"`
+	CFBundleLocalizations           []string               `plist:"CFBundleLocalizations"`
+	CFBundleAllowMixedLocalizations bool                   `plist:"CFBundleAllowMixedLocalizations"`
+	CFBundleURLTypes                []URLType              `plist:"CFBundleURLTypes"`
+	CFBundleDocumentTypes           []DocumentType         `plist:"CFBundleDocumentTypes"`
+	MinimumOSVersion                string                 `plist:"MinimumOSVersion"`
+
+	// Launch Services keys.
+	LSApplicationCategoryType         string            `plist:"LSApplicationCategoryType"`
+	LSApplicationQueriesSchemes       []string          `plist:"LSApplicationQueriesSchemes"`
+	LSBackgroundOnly                  bool              `plist:"LSBackgroundOnly"`
+	LSEnvironment                     map[string]string `plist:"LSEnvironment"`
+	LSMinimumSystemVersion            string            `plist:"LSMinimumSystemVersion"`
+	LSMultipleInstancesProhibited     bool              `plist:"LSMultipleInstancesProhibited"`
+	LSRequiresIPhoneOS                bool              `plist:"LSRequiresIPhoneOS"`
+	LSSupportsOpeningDocumentsInPlace bool              `plist:"LSSupportsOpeningDocumentsInPlace"`
+	LSUIElement                       bool              `plist:"LSUIElement"`
+
+	// Cocoa keys.
+	NSAppTransportSecurity   *AppTransportSecurity `plist:"NSAppTransportSecurity"`
+	NSHumanReadableCopyright string                `plist:"NSHumanReadableCopyright"`
+	NSMainNibFile            string                `plist:"NSMainNibFile"`
+	NSPrincipalClass         string                `plist:"NSPrincipalClass"`
+
+	// Usage descriptions, shown when the app asks for permission.
+	NSAppleMusicUsageDescription                 string `plist:"NSAppleMusicUsageDescription"`
+	NSBluetoothAlwaysUsageDescription            string `plist:"NSBluetoothAlwaysUsageDescription"`
+	NSBluetoothPeripheralUsageDescription        string `plist:"NSBluetoothPeripheralUsageDescription"`
+	NSCalendarsUsageDescription                  string `plist:"NSCalendarsUsageDescription"`
+	NSCameraUsageDescription                     string `plist:"NSCameraUsageDescription"`
+	NSContactsUsageDescription                   string `plist:"NSContactsUsageDescription"`
+	NSFaceIDUsageDescription                     string `plist:"NSFaceIDUsageDescription"`
+	NSHealthShareUsageDescription                string `plist:"NSHealthShareUsageDescription"`
+	NSHealthUpdateUsageDescription               string `plist:"NSHealthUpdateUsageDescription"`
+	NSHomeKitUsageDescription                    string `plist:"NSHomeKitUsageDescription"`
+	NSLocalNetworkUsageDescription               string `plist:"NSLocalNetworkUsageDescription"`
+	NSLocationAlwaysAndWhenInUseUsageDescription string `plist:"NSLocationAlwaysAndWhenInUseUsageDescription"`
+	NSLocationAlwaysUsageDescription             string `plist:"NSLocationAlwaysUsageDescription"`
+	NSLocationWhenInUseUsageDescription          string `plist:"NSLocationWhenInUseUsageDescription"`
+	NSMicrophoneUsageDescription                 string `plist:"NSMicrophoneUsageDescription"`
+	NSMotionUsageDescription                     string `plist:"NSMotionUsageDescription"`
+	NSPhotoLibraryAddUsageDescription            string `plist:"NSPhotoLibraryAddUsageDescription"`
+	NSPhotoLibraryUsageDescription               string `plist:"NSPhotoLibraryUsageDescription"`
+	NSRemindersUsageDescription                  string `plist:"NSRemindersUsageDescription"`
+	NSSiriUsageDescription                       string `plist:"NSSiriUsageDescription"`
+	NSSpeechRecognitionUsageDescription          string `plist:"NSSpeechRecognitionUsageDescription"`
+	NSUserTrackingUsageDescription               string `plist:"NSUserTrackingUsageDescription"`
+
+	// UIKit keys.
+	UIAppFonts                               []string               `plist:"UIAppFonts"`
+	UIApplicationSceneManifest               map[string]interface{} `plist:"UIApplicationSceneManifest"`
+	UIBackgroundModes                        []string               `plist:"UIBackgroundModes"`
+	UIDeviceFamily                           []int                  `plist:"UIDeviceFamily"`
+	UIFileSharingEnabled                     bool                   `plist:"UIFileSharingEnabled"`
+	UILaunchScreen                           map[string]interface{} `plist:"UILaunchScreen"`
+	UILaunchStoryboardName                   string                 `plist:"UILaunchStoryboardName"`
+	UIMainStoryboardFile                     string                 `plist:"UIMainStoryboardFile"`
+	UIRequiredDeviceCapabilities             DeviceCapabilities     `plist:"UIRequiredDeviceCapabilities"`
+	UIRequiresFullScreen                     bool                   `plist:"UIRequiresFullScreen"`
+	UIStatusBarStyle                         string                 `plist:"UIStatusBarStyle"`
+	UISupportedInterfaceOrientations         []string               `plist:"UISupportedInterfaceOrientations"`
+	UISupportedInterfaceOrientationsIPad     []string               `plist:"UISupportedInterfaceOrientations~ipad"`
+	UIViewControllerBasedStatusBarAppearance *bool                  `plist:"UIViewControllerBasedStatusBarAppearance"`
+
+	// Uniform Type Identifier keys.
+	UTExportedTypeDeclarations []TypeDeclaration `plist:"UTExportedTypeDeclarations"`
+	UTImportedTypeDeclarations []TypeDeclaration `plist:"UTImportedTypeDeclarations"`
+
+	// Unknown holds all the keys that don't have a field.
+	Unknown map[string]interface{} `plist:",unknown"`
+}
+
+// URLType is an entry in CFBundleURLTypes.
+type URLType struct {
+	CFBundleTypeRole    string   `plist:"CFBundleTypeRole"`
+	CFBundleURLIconFile string   `plist:"CFBundleURLIconFile"`
+	CFBundleURLName     string   `plist:"CFBundleURLName"`
+	CFBundleURLSchemes  []string `plist:"CFBundleURLSchemes"`
+}
+
+// DocumentType is an entry in CFBundleDocumentTypes.
+type DocumentType struct {
+	CFBundleTypeIconFiles []string `plist:"CFBundleTypeIconFiles"`
+	CFBundleTypeName      string   `plist:"CFBundleTypeName"`
+	CFBundleTypeRole      string   `plist:"CFBundleTypeRole"`
+	LSHandlerRank         string   `plist:"LSHandlerRank"`
+	LSItemContentTypes    []string `plist:"LSItemContentTypes"`
+}
+
+// TypeDeclaration is an entry in UTExportedTypeDeclarations or UTImportedTypeDeclarations.
+type TypeDeclaration struct {
+	UTTypeConformsTo   []string `plist:"UTTypeConformsTo"`
+	UTTypeDescription  string   `plist:"UTTypeDescription"`
+	UTTypeIconFile     string   `plist:"UTTypeIconFile"`
+	UTTypeIdentifier   string   `plist:"UTTypeIdentifier"`
+	UTTypeReferenceURL string   `plist:"UTTypeReferenceURL"`
+	// UTTypeTagSpecification maps tag classes, like public.filename-extension,
+	// to a string or array of strings.
+	UTTypeTagSpecification map[string]interface{} `plist:"UTTypeTagSpecification"`
+}
+
+// AppTransportSecurity is the NSAppTransportSecurity dict.
+type AppTransportSecurity struct {
+	NSAllowsArbitraryLoads  bool                              `plist:"NSAllowsArbitraryLoads"`
+	NSAllowsLocalNetworking bool                              `plist:"NSAllowsLocalNetworking"`
+	NSExceptionDomains      map[string]map[string]interface{} `plist:"NSExceptionDomains"`
+}
+
+/*
+DeviceCapabilities is the UIRequiredDeviceCapabilities value.
+
+It can be written as an array of required capabilities, or as a dict mapping
+capabilities to true if they are required or false if they must not be present.
+Both forms are decoded into a map.
+*/
+type DeviceCapabilities map[string]bool
+
+func (c *DeviceCapabilities) UnmarshalPlist(value interface{}) error {
+	switch value.(type) {
+	case []interface{}:
+		var required []string
+		if err := xml.UnmarshalValue(value, &required); err != nil {
+			return err
+		}
+		*c = make(DeviceCapabilities)
+		for _, capability := range required {
+			(*c)[capability] = true
+		}
+		return nil
+	}
+	return xml.UnmarshalValue(value, (*map[string]bool)(c))
+}
+
+// Decode reads an Info.plist from r.
+func Decode(r io.Reader) (*Info, error) {
+	var info Info
+	if err := xml.NewDecoder(r).Decode(&info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// UsageDescriptions returns all the NS*UsageDescription keys in the plist,
+// including ones that don't have a field in Info, mapped to their descriptions.
+func (info *Info) UsageDescriptions() map[string]string {
+	descriptions := map[string]string{}
+	for key, value := range info.Unknown {
+		if description, ok := value.(string); ok && isUsageDescriptionKey(key) {
+			descriptions[key] = description
+		}
+	}
+
+	for key, description := range map[string]string{
+		"NSAppleMusicUsageDescription":                 info.NSAppleMusicUsageDescription,
+		"NSBluetoothAlwaysUsageDescription":            info.NSBluetoothAlwaysUsageDescription,
+		"NSBluetoothPeripheralUsageDescription":        info.NSBluetoothPeripheralUsageDescription,
+		"NSCalendarsUsageDescription":                  info.NSCalendarsUsageDescription,
+		"NSCameraUsageDescription":                     info.NSCameraUsageDescription,
+		"NSContactsUsageDescription":                   info.NSContactsUsageDescription,
+		"NSFaceIDUsageDescription":                     info.NSFaceIDUsageDescription,
+		"NSHealthShareUsageDescription":                info.NSHealthShareUsageDescription,
+		"NSHealthUpdateUsageDescription":               info.NSHealthUpdateUsageDescription,
+		"NSHomeKitUsageDescription":                    info.NSHomeKitUsageDescription,
+		"NSLocalNetworkUsageDescription":               info.NSLocalNetworkUsageDescription,
+		"NSLocationAlwaysAndWhenInUseUsageDescription": info.NSLocationAlwaysAndWhenInUseUsageDescription,
+		"NSLocationAlwaysUsageDescription":             info.NSLocationAlwaysUsageDescription,
+		"NSLocationWhenInUseUsageDescription":          info.NSLocationWhenInUseUsageDescription,
+		"NSMicrophoneUsageDescription":                 info.NSMicrophoneUsageDescription,
+		"NSMotionUsageDescription":                     info.NSMotionUsageDescription,
+		"NSPhotoLibraryAddUsageDescription":            info.NSPhotoLibraryAddUsageDescription,
+		"NSPhotoLibraryUsageDescription":               info.NSPhotoLibraryUsageDescription,
+		"NSRemindersUsageDescription":                  info.NSRemindersUsageDescription,
+		"NSSiriUsageDescription":                       info.NSSiriUsageDescription,
+		"NSSpeechRecognitionUsageDescription":          info.NSSpeechRecognitionUsageDescription,
+		"NSUserTrackingUsageDescription":               info.NSUserTrackingUsageDescription,
+	} {
+		if description != "" {
+			descriptions[key] = description
+		}
+	}
+	return descriptions
+}
+
+func isUsageDescriptionKey(key string) bool {
+	return strings.HasPrefix(key, "NS") && strings.HasSuffix(key, "UsageDescription")
+}
@@ -0,0 +1,124 @@
+package infoplist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const samplePlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>com.example.app</string>
+	<key>CFBundleShortVersionString</key>
+	<string>1.2.3</string>
+	<key>CFBundleVersion</key>
+	<string>42</string>
+	<key>CFBundleURLTypes</key>
+	<array>
+		<dict>
+			<key>CFBundleURLName</key>
+			<string>com.example.app</string>
+			<key>CFBundleURLSchemes</key>
+			<array>
+				<string>example</string>
+			</array>
+		</dict>
+	</array>
+	<key>LSRequiresIPhoneOS</key>
+	<true/>
+	<key>NSCameraUsageDescription</key>
+	<string>To scan codes.</string>
+	<key>NSNewThingUsageDescription</key>
+	<string>For the new thing.</string>
+	<key>UIDeviceFamily</key>
+	<array>
+		<integer>1</integer>
+		<integer>2</integer>
+	</array>
+	<key>UIRequiredDeviceCapabilities</key>
+	<array>
+		<string>arm64</string>
+	</array>
+	<key>UISupportedInterfaceOrientations~ipad</key>
+	<array>
+		<string>UIInterfaceOrientationPortrait</string>
+	</array>
+	<key>UTExportedTypeDeclarations</key>
+	<array>
+		<dict>
+			<key>UTTypeIdentifier</key>
+			<string>com.example.doc</string>
+			<key>UTTypeConformsTo</key>
+			<array>
+				<string>public.data</string>
+			</array>
+		</dict>
+	</array>
+	<key>CustomKey</key>
+	<integer>7</integer>
+</dict>
+</plist>`
+
+func TestDecode(t *testing.T) {
+	info, err := Decode(strings.NewReader(samplePlist))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "com.example.app", info.CFBundleIdentifier)
+	assert.Equal(t, "1.2.3", info.CFBundleShortVersionString)
+	assert.Equal(t, "42", info.CFBundleVersion)
+	assert.Equal(t, []URLType{{
+		CFBundleURLName:    "com.example.app",
+		CFBundleURLSchemes: []string{"example"},
+	}}, info.CFBundleURLTypes)
+	assert.True(t, info.LSRequiresIPhoneOS)
+	assert.Equal(t, []int{1, 2}, info.UIDeviceFamily)
+	assert.Equal(t, DeviceCapabilities{"arm64": true}, info.UIRequiredDeviceCapabilities)
+	assert.Equal(t, []string{"UIInterfaceOrientationPortrait"}, info.UISupportedInterfaceOrientationsIPad)
+	assert.Equal(t, "com.example.doc", info.UTExportedTypeDeclarations[0].UTTypeIdentifier)
+	assert.Equal(t, map[string]interface{}{
+		"NSNewThingUsageDescription": "For the new thing.",
+		"CustomKey":                  int64(7),
+	}, info.Unknown)
+}
+
+func TestUsageDescriptions(t *testing.T) {
+	info, err := Decode(strings.NewReader(samplePlist))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"NSCameraUsageDescription":   "To scan codes.",
+		"NSNewThingUsageDescription": "For the new thing.",
+	}, info.UsageDescriptions())
+}
+
+func TestDeviceCapabilitiesDict(t *testing.T) {
+	plist := `<plist version="1.0">
+<dict>
+	<key>UIRequiredDeviceCapabilities</key>
+	<dict>
+		<key>arm64</key>
+		<true/>
+		<key>telephony</key>
+		<false/>
+	</dict>
+</dict>
+</plist>`
+	info, err := Decode(strings.NewReader(plist))
+	assert.NoError(t, err)
+	assert.Equal(t, DeviceCapabilities{"arm64": true, "telephony": false}, info.UIRequiredDeviceCapabilities)
+}
+
+func TestDecodeWrongType(t *testing.T) {
+	plist := `<plist version="1.0">
+<dict>
+	<key>CFBundleVersion</key>
+	<integer>42</integer>
+</dict>
+</plist>`
+	_, err := Decode(strings.NewReader(plist))
+	assert.EqualError(t, err, "cannot unmarshal integer into Go value of type string at CFBundleVersion")
+}
@@ -0,0 +1,186 @@
+package cms
+
+import (
+	"bytes"
+	"errors"
+)
+
+var errTruncated = errors.New("cms: truncated BER data")
+
+/*
+berToDER converts BER-encoded data to DER, which encoding/asn1 requires.
+
+Apple signs provisioning profiles with indefinite-length encodings, and may
+split octet strings into constructed chunks. Indefinite lengths are replaced
+with definite ones, and constructed octet strings are flattened. Other BER
+freedoms, like non-minimal lengths, are normalized as a side effect.
+*/
+func berToDER(ber []byte) ([]byte, error) {
+	var der bytes.Buffer
+	rest, err := convertElement(&der, ber, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("cms: trailing data after BER element")
+	}
+	return der.Bytes(), nil
+}
+
+// maxDepth is the deepest nesting of elements berToDER accepts. Signed data
+// nests about a dozen deep.
+const maxDepth = 64
+
+// convertElement writes the DER for the first element in ber to der, and
+// returns the bytes after it. depth is the number of elements it's nested in.
+func convertElement(der *bytes.Buffer, ber []byte, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, errors.New("cms: BER data nested too deeply")
+	}
+	tag, rest, err := readTag(ber)
+	if err != nil {
+		return nil, err
+	}
+	constructed := tag[0]&0x20 != 0
+
+	length, indefinite, rest, err := readLength(rest)
+	if err != nil {
+		return nil, err
+	}
+	if !constructed {
+		if indefinite {
+			return nil, errors.New("cms: indefinite length on primitive element")
+		}
+		writeElement(der, tag, rest[:length])
+		return rest[length:], nil
+	}
+
+	// Children are converted as they're read, so each byte is only scanned
+	// once, however deeply it's nested.
+	var children bytes.Buffer
+	if indefinite {
+		for {
+			if len(rest) >= 2 && rest[0] == 0 && rest[1] == 0 {
+				rest = rest[2:]
+				break
+			}
+			if rest, err = convertElement(&children, rest, depth+1); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		contents := rest[:length]
+		rest = rest[length:]
+		for len(contents) > 0 {
+			if contents, err = convertElement(&children, contents, depth+1); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(tag) == 1 && tag[0] == 0x24 {
+		// A constructed octet string is a sequence of primitive octet strings
+		// that have to be joined.
+		joined, err := joinOctetStrings(children.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		writeElement(der, []byte{0x04}, joined)
+		return rest, nil
+	}
+
+	writeElement(der, tag, children.Bytes())
+	return rest, nil
+}
+
+// readTag returns the identifier octets at the start of ber.
+func readTag(ber []byte) (tag, rest []byte, err error) {
+	if len(ber) == 0 {
+		return nil, nil, errTruncated
+	}
+	n := 1
+	if ber[0]&0x1f == 0x1f {
+		// High tag number form.
+		for {
+			if n >= len(ber) {
+				return nil, nil, errTruncated
+			}
+			n++
+			if ber[n-1]&0x80 == 0 {
+				break
+			}
+		}
+	}
+	return ber[:n], ber[n:], nil
+}
+
+// readLength reads the length octets at the start of ber. A definite length
+// is checked to fit in rest.
+func readLength(ber []byte) (length int, indefinite bool, rest []byte, err error) {
+	if len(ber) == 0 {
+		return 0, false, nil, errTruncated
+	}
+
+	lengthByte := ber[0]
+	ber = ber[1:]
+	if lengthByte == 0x80 {
+		return 0, true, ber, nil
+	}
+
+	length = int(lengthByte)
+	if lengthByte&0x80 != 0 {
+		numBytes := int(lengthByte & 0x7f)
+		if numBytes > 4 || numBytes > len(ber) {
+			return 0, false, nil, errTruncated
+		}
+		length = 0
+		for _, b := range ber[:numBytes] {
+			length = length<<8 | int(b)
+		}
+		ber = ber[numBytes:]
+	}
+	if length < 0 || length > len(ber) {
+		return 0, false, nil, errTruncated
+	}
+	return length, false, ber, nil
+}
+
+func joinOctetStrings(der []byte) ([]byte, error) {
+	var joined []byte
+	for len(der) > 0 {
+		tag, rest, err := readTag(der)
+		if err != nil {
+			return nil, err
+		}
+		if len(tag) != 1 || tag[0] != 0x04 {
+			return nil, errors.New("cms: constructed octet string contains non-octet string")
+		}
+		length, _, rest, err := readLength(rest)
+		if err != nil {
+			return nil, err
+		}
+		joined = append(joined, rest[:length]...)
+		der = rest[length:]
+	}
+	return joined, nil
+}
+
+func writeElement(der *bytes.Buffer, tag, contents []byte) {
+	der.Write(tag)
+	writeLength(der, len(contents))
+	der.Write(contents)
+}
+
+func writeLength(der *bytes.Buffer, length int) {
+	if length < 0x80 {
+		der.WriteByte(byte(length))
+		return
+	}
+
+	var lengthBytes []byte
+	for l := length; l > 0; l >>= 8 {
+		lengthBytes = append([]byte{byte(l)}, lengthBytes...)
+	}
+	der.WriteByte(0x80 | byte(len(lengthBytes)))
+	der.Write(lengthBytes)
+}
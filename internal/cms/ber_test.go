@@ -0,0 +1,71 @@
+package cms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBERToDER(t *testing.T) {
+	for _, test := range []struct {
+		ber, der []byte
+	}{
+		// Already DER.
+		{[]byte{0x30, 0x03, 0x02, 0x01, 0x05}, []byte{0x30, 0x03, 0x02, 0x01, 0x05}},
+		// Indefinite length sequence.
+		{[]byte{0x30, 0x80, 0x02, 0x01, 0x05, 0x00, 0x00}, []byte{0x30, 0x03, 0x02, 0x01, 0x05}},
+		// Nested indefinite lengths.
+		{
+			[]byte{0x30, 0x80, 0xa0, 0x80, 0x02, 0x01, 0x05, 0x00, 0x00, 0x00, 0x00},
+			[]byte{0x30, 0x05, 0xa0, 0x03, 0x02, 0x01, 0x05},
+		},
+		// Constructed octet string.
+		{
+			[]byte{0x24, 0x80, 0x04, 0x02, 'a', 'b', 0x04, 0x01, 'c', 0x00, 0x00},
+			[]byte{0x04, 0x03, 'a', 'b', 'c'},
+		},
+		// Non-minimal length.
+		{[]byte{0x04, 0x81, 0x01, 'a'}, []byte{0x04, 0x01, 'a'}},
+	} {
+		der, err := berToDER(test.ber)
+		assert.NoError(t, err)
+		assert.Equal(t, test.der, der)
+	}
+}
+
+func TestBERToDERInvalid(t *testing.T) {
+	for _, ber := range [][]byte{
+		{},
+		{0x30},
+		{0x30, 0x05, 0x02, 0x01},
+		{0x30, 0x80, 0x02, 0x01, 0x05},
+		{0x04, 0x80, 0x00, 0x00},
+		{0x02, 0x01, 0x05, 0x00},
+	} {
+		_, err := berToDER(ber)
+		assert.Error(t, err, "%x", ber)
+	}
+}
+
+// nested returns depth indefinite-length sequences nested around an integer.
+func nested(depth int) []byte {
+	var ber []byte
+	for i := 0; i < depth; i++ {
+		ber = append(ber, 0x30, 0x80)
+	}
+	ber = append(ber, 0x02, 0x01, 0x05)
+	for i := 0; i < depth; i++ {
+		ber = append(ber, 0x00, 0x00)
+	}
+	return ber
+}
+
+func TestBERToDERDeep(t *testing.T) {
+	der, err := berToDER(nested(maxDepth))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x02, 0x01, 0x05}, der[len(der)-3:])
+
+	// Rescanning each level's contents took minutes at this size.
+	_, err = berToDER(nested(100000))
+	assert.EqualError(t, err, "cms: BER data nested too deeply")
+}
@@ -0,0 +1,426 @@
+/*
+Package cms reads and writes the CMS (PKCS #7) SignedData envelopes used by
+provisioning profiles and signed configuration profiles.
+
+Only what those files need is supported: a single embedded data content,
+certificates, and RSA or ECDSA signers with signed attributes.
+*/
+package cms
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+var (
+	oidData       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+	oidAttributeContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttributeSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA1WithRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECDSAWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidECPublicKey     = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	// Content is the [0] EXPLICIT wrapper, whose Bytes are the content's encoding.
+	Content asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// SignedData is a parsed CMS SignedData envelope.
+type SignedData struct {
+	// Content is the signed data.
+	Content []byte
+	// Certificates are all the certificates included in the envelope.
+	Certificates []*x509.Certificate
+
+	signers []signerInfo
+}
+
+// Parse parses a DER or BER encoded ContentInfo containing SignedData.
+func Parse(ber []byte) (*SignedData, error) {
+	der, err := berToDER(ber)
+	if err != nil {
+		return nil, err
+	}
+
+	var info contentInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("cms: trailing data after content info")
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("cms: content type is %s, not signed data", info.ContentType)
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+		return nil, err
+	}
+	if !sd.EncapContentInfo.ContentType.Equal(oidData) {
+		return nil, fmt.Errorf("cms: encapsulated content type is %s, not data", sd.EncapContentInfo.ContentType)
+	}
+
+	var content []byte
+	if len(sd.EncapContentInfo.Content.Bytes) > 0 {
+		if _, err := asn1.Unmarshal(sd.EncapContentInfo.Content.Bytes, &content); err != nil {
+			return nil, err
+		}
+	}
+
+	var certs []*x509.Certificate
+	if len(sd.Certificates.Bytes) > 0 {
+		if certs, err = x509.ParseCertificates(sd.Certificates.Bytes); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SignedData{
+		Content:      content,
+		Certificates: certs,
+		signers:      sd.SignerInfos,
+	}, nil
+}
+
+/*
+Verify checks the signature of every signer, and that each signer's certificate
+chains to one of the roots in options, using the envelope's other certificates
+as intermediates. If options.KeyUsages is empty, any extended key usage is accepted.
+*/
+func (sd *SignedData) Verify(options x509.VerifyOptions) error {
+	if len(sd.signers) == 0 {
+		return errors.New("cms: no signers")
+	}
+
+	if options.Intermediates == nil {
+		options.Intermediates = x509.NewCertPool()
+		for _, cert := range sd.Certificates {
+			options.Intermediates.AddCert(cert)
+		}
+	}
+	if len(options.KeyUsages) == 0 {
+		options.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+
+	for _, signer := range sd.signers {
+		cert, err := sd.findCertificate(signer.SID)
+		if err != nil {
+			return err
+		}
+		if err := verifySignature(signer, cert, sd.Content); err != nil {
+			return err
+		}
+		if _, err := cert.Verify(options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findCertificate returns the certificate identified by sid.
+func (sd *SignedData) findCertificate(sid asn1.RawValue) (*x509.Certificate, error) {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		// SubjectKeyIdentifier.
+		for _, cert := range sd.Certificates {
+			if bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+		return nil, errors.New("cms: no certificate for signer's subject key identifier")
+	}
+
+	var ias issuerAndSerialNumber
+	if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+		return nil, err
+	}
+	for _, cert := range sd.Certificates {
+		if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return cert, nil
+		}
+	}
+	return nil, errors.New("cms: no certificate for signer's issuer and serial number")
+}
+
+func verifySignature(signer signerInfo, cert *x509.Certificate, content []byte) error {
+	hash, err := hashForOID(signer.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	algorithm, err := signatureAlgorithm(signer.SignatureAlgorithm.Algorithm, hash)
+	if err != nil {
+		return err
+	}
+
+	signed := content
+	if len(signer.SignedAttrs.Bytes) > 0 {
+		if err := checkMessageDigest(signer.SignedAttrs, hash, content); err != nil {
+			return err
+		}
+		// The signature covers the attributes with their SET OF tag, not the
+		// implicit [0] tag they're stored with.
+		signed = append([]byte{0x31}, signer.SignedAttrs.FullBytes[1:]...)
+	}
+
+	return cert.CheckSignature(algorithm, signed, signer.Signature)
+}
+
+func checkMessageDigest(signedAttrs asn1.RawValue, hash crypto.Hash, content []byte) error {
+	var attrs []attribute
+	set := append([]byte{0x31}, signedAttrs.FullBytes[1:]...)
+	if _, err := asn1.UnmarshalWithParams(set, &attrs, "set"); err != nil {
+		return err
+	}
+
+	for _, attr := range attrs {
+		if !attr.Type.Equal(oidAttributeMessageDigest) {
+			continue
+		}
+		var digest []byte
+		if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+			return err
+		}
+
+		h := hash.New()
+		h.Write(content)
+		if !bytes.Equal(h.Sum(nil), digest) {
+			return errors.New("cms: message digest does not match content")
+		}
+		return nil
+	}
+	return errors.New("cms: signed attributes have no message digest")
+}
+
+func hashForOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA1):
+		return crypto.SHA1, nil
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("cms: unsupported digest algorithm %s", oid)
+}
+
+// signatureAlgorithm maps a signer's signature algorithm, which may just name
+// the key type, and digest algorithm to an x509.SignatureAlgorithm.
+func signatureAlgorithm(oid asn1.ObjectIdentifier, hash crypto.Hash) (x509.SignatureAlgorithm, error) {
+	isRSA := oid.Equal(oidRSAEncryption) || oid.Equal(oidSHA1WithRSA) || oid.Equal(oidSHA256WithRSA) ||
+		oid.Equal(oidSHA384WithRSA) || oid.Equal(oidSHA512WithRSA)
+	isECDSA := oid.Equal(oidECPublicKey) || oid.Equal(oidECDSAWithSHA1) || oid.Equal(oidECDSAWithSHA256) ||
+		oid.Equal(oidECDSAWithSHA384) || oid.Equal(oidECDSAWithSHA512)
+
+	switch {
+	case isRSA && hash == crypto.SHA1:
+		return x509.SHA1WithRSA, nil
+	case isRSA && hash == crypto.SHA256:
+		return x509.SHA256WithRSA, nil
+	case isRSA && hash == crypto.SHA384:
+		return x509.SHA384WithRSA, nil
+	case isRSA && hash == crypto.SHA512:
+		return x509.SHA512WithRSA, nil
+	case isECDSA && hash == crypto.SHA1:
+		return x509.ECDSAWithSHA1, nil
+	case isECDSA && hash == crypto.SHA256:
+		return x509.ECDSAWithSHA256, nil
+	case isECDSA && hash == crypto.SHA384:
+		return x509.ECDSAWithSHA384, nil
+	case isECDSA && hash == crypto.SHA512:
+		return x509.ECDSAWithSHA512, nil
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("cms: unsupported signature algorithm %s", oid)
+}
+
+/*
+Sign wraps content in a DER-encoded SignedData envelope, signed with SHA-256
+by key, which must be the private key for cert. cert and intermediates are
+included in the envelope, so receivers can verify the chain.
+*/
+func Sign(content []byte, cert *x509.Certificate, key crypto.Signer, intermediates []*x509.Certificate) ([]byte, error) {
+	var signatureOID asn1.ObjectIdentifier
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		signatureOID = oidRSAEncryption
+	case *ecdsa.PublicKey:
+		signatureOID = oidECDSAWithSHA256
+	default:
+		return nil, fmt.Errorf("cms: unsupported key type %T", key.Public())
+	}
+
+	digest := crypto.SHA256.New()
+	digest.Write(content)
+
+	signedAttrs, err := marshalAttributes(
+		newAttribute(oidAttributeContentType, oidData),
+		newAttribute(oidAttributeSigningTime, time.Now().UTC()),
+		newAttribute(oidAttributeMessageDigest, digest.Sum(nil)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	attrsDigest := crypto.SHA256.New()
+	attrsDigest.Write(append([]byte{0x31}, signedAttrs.FullBytes[1:]...))
+	signature, err := key.Sign(rand.Reader, attrsDigest.Sum(nil), crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	sid, err := asn1.Marshal(issuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+		SerialNumber: cert.SerialNumber,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var rawCerts []byte
+	for _, c := range append([]*x509.Certificate{cert}, intermediates...) {
+		rawCerts = append(rawCerts, c.Raw...)
+	}
+
+	encapContent, err := asn1.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	sha256Algorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	sd, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm},
+		EncapContentInfo: contentInfo{
+			ContentType: oidData,
+			Content:     explicitTag0(encapContent),
+		},
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rawCerts},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    sha256Algorithm,
+			SignedAttrs:        signedAttrs,
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: signatureOID},
+			Signature:          signature,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     explicitTag0(sd),
+	})
+}
+
+func explicitTag0(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+type attributeValue struct {
+	oid   asn1.ObjectIdentifier
+	value interface{}
+}
+
+func newAttribute(oid asn1.ObjectIdentifier, value interface{}) attributeValue {
+	return attributeValue{oid, value}
+}
+
+// marshalAttributes encodes attrs as an implicitly tagged [0] SET OF Attribute,
+// sorted as DER requires.
+func marshalAttributes(attrs ...attributeValue) (asn1.RawValue, error) {
+	var encoded [][]byte
+	for _, attr := range attrs {
+		value, err := asn1.Marshal(attr.value)
+		if err != nil {
+			return asn1.RawValue{}, err
+		}
+		der, err := asn1.Marshal(attribute{
+			Type:   attr.oid,
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+		})
+		if err != nil {
+			return asn1.RawValue{}, err
+		}
+		encoded = append(encoded, der)
+	}
+	sort.Sort(byBytes(encoded))
+
+	raw, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        0,
+		IsCompound: true,
+		Bytes:      bytes.Join(encoded, nil),
+	})
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+
+	var value asn1.RawValue
+	_, err = asn1.Unmarshal(raw, &value)
+	return value, err
+}
+
+type byBytes [][]byte
+
+func (b byBytes) Len() int           { return len(b) }
+func (b byBytes) Less(i, j int) bool { return bytes.Compare(b[i], b[j]) < 0 }
+func (b byBytes) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,57 @@
+package cms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/internal/cms/cmstest"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	root := cmstest.NewCertificate(t, "root", rootKey, nil, nil)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	for _, key := range []crypto.Signer{rsaKey, ecKey} {
+		signer := cmstest.NewCertificate(t, "signer", key, root, rootKey)
+
+		signed, err := Sign([]byte("hello"), signer, key, nil)
+		assert.NoError(t, err)
+
+		sd, err := Parse(signed)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("hello"), sd.Content)
+		assert.Len(t, sd.Certificates, 1)
+
+		roots := x509.NewCertPool()
+		roots.AddCert(root)
+		assert.NoError(t, sd.Verify(x509.VerifyOptions{Roots: roots}))
+
+		otherRoot := cmstest.NewCertificate(t, "other", rootKey, nil, nil)
+		otherRoots := x509.NewCertPool()
+		otherRoots.AddCert(otherRoot)
+		assert.Error(t, sd.Verify(x509.VerifyOptions{Roots: otherRoots}))
+
+		sd.Content = []byte("tampered")
+		assert.Error(t, sd.Verify(x509.VerifyOptions{Roots: roots}))
+	}
+}
+
+func TestParseNotSignedData(t *testing.T) {
+	_, err := Parse([]byte{0x30, 0x03, 0x06, 0x01, 0x00})
+	assert.Error(t, err)
+
+	_, err = Parse([]byte("<plist/>"))
+	assert.Error(t, err)
+}
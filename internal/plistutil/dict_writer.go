@@ -0,0 +1,115 @@
+package plistutil
+
+import "github.com/zach-klippenstein/goplist/xml"
+
+// DictWriter writes keys with non-zero values to a DictEncoder, stopping at
+// the first error.
+type DictWriter struct {
+	e   *xml.DictEncoder
+	err error
+}
+
+// NewDictWriter returns a DictWriter that writes to e.
+func NewDictWriter(e *xml.DictEncoder) *DictWriter {
+	return &DictWriter{e: e}
+}
+
+// Err returns the first error encountered while writing.
+func (w *DictWriter) Err() error {
+	return w.err
+}
+
+func (w *DictWriter) String(key, value string) {
+	if w.err == nil && value != "" {
+		w.err = w.e.WriteString(key, value)
+	}
+}
+
+func (w *DictWriter) Bool(key string, value bool) {
+	if value {
+		w.ForceBool(key, value)
+	}
+}
+
+// ForceBool writes value even if it's false.
+func (w *DictWriter) ForceBool(key string, value bool) {
+	if w.err == nil {
+		w.err = w.e.WriteBool(key, value)
+	}
+}
+
+func (w *DictWriter) BoolPtr(key string, value *bool) {
+	if value != nil {
+		w.ForceBool(key, *value)
+	}
+}
+
+func (w *DictWriter) Int(key string, value int) {
+	if w.err == nil && value != 0 {
+		w.err = w.e.WriteInt(key, int64(value))
+	}
+}
+
+func (w *DictWriter) IntPtr(key string, value *int) {
+	if w.err == nil && value != nil {
+		w.err = w.e.WriteInt(key, int64(*value))
+	}
+}
+
+func (w *DictWriter) Data(key string, value []byte) {
+	if w.err == nil && len(value) > 0 {
+		w.err = w.e.WriteData(key, value)
+	}
+}
+
+// Value writes value whatever it is, using DictEncoder.WriteValue.
+func (w *DictWriter) Value(key string, value interface{}) {
+	if w.err == nil {
+		w.err = w.e.WriteValue(key, value)
+	}
+}
+
+func (w *DictWriter) Strings(key string, values []string) {
+	if w.err == nil && len(values) > 0 {
+		w.err = w.e.WriteArray(key, func(e *xml.ArrayEncoder) error {
+			for _, value := range values {
+				if err := e.WriteString(value); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func (w *DictWriter) BoolMap(key string, values map[string]bool) {
+	if len(values) > 0 {
+		w.Dict(key, func(e *xml.DictEncoder) error {
+			for _, name := range SortedKeys(values) {
+				if err := e.WriteBool(name, values[name]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func (w *DictWriter) Dict(key string, encode xml.DictEncodingFunc) {
+	if w.err == nil {
+		w.err = w.e.WriteDict(key, encode)
+	}
+}
+
+func (w *DictWriter) Array(key string, encode xml.ArrayEncodingFunc) {
+	if w.err == nil {
+		w.err = w.e.WriteArray(key, encode)
+	}
+}
+
+// Entries writes every entry of m, sorted by key.
+func (w *DictWriter) Entries(m map[string]interface{}) {
+	for _, key := range SortedKeys(m) {
+		w.Value(key, m[key])
+	}
+}
@@ -0,0 +1,25 @@
+/*
+Package plistutil holds the helpers shared by the packages that write and
+check structured plists, like launchd jobs and configuration profiles.
+*/
+package plistutil
+
+import (
+	"reflect"
+	"sort"
+)
+
+// SortedKeys returns the keys of a map with string keys, in order. It returns
+// nil for anything else.
+func SortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+	keys := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,58 @@
+package plistutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validator collects the problems found while checking a document.
+type Validator struct {
+	newError func(key, msg string) error
+	errs     []error
+}
+
+// NewValidator returns a Validator that reports problems with errors made by
+// newError, so each package keeps its own error type.
+func NewValidator(newError func(key, msg string) error) *Validator {
+	return &Validator{newError: newError}
+}
+
+// Errs returns the problems found so far, in the order they were found.
+func (v *Validator) Errs() []error {
+	return v.errs
+}
+
+func (v *Validator) Errorf(key, format string, args ...interface{}) {
+	v.errs = append(v.errs, v.newError(key, fmt.Sprintf(format, args...)))
+}
+
+func (v *Validator) Required(key, value string) {
+	if value == "" {
+		v.Errorf(key, "is required")
+	}
+}
+
+func (v *Validator) NonNegative(key string, value int) {
+	if value < 0 {
+		v.Errorf(key, "must not be negative, found %d", value)
+	}
+}
+
+func (v *Validator) InRange(key string, value *int, min, max int) {
+	if value != nil && (*value < min || *value > max) {
+		v.Errorf(key, "must be between %d and %d, found %d", min, max, *value)
+	}
+}
+
+// OneOf checks that value, if set, is one of allowed.
+func (v *Validator) OneOf(key, value string, allowed []string) {
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.Errorf(key, "must be one of %s, found %q", strings.Join(allowed, ", "), value)
+}
@@ -0,0 +1,271 @@
+/*
+Package itunes reads the iTunes Library.xml files iTunes and Music export.
+
+Libraries can be hundreds of megabytes, so Decode streams them: each track and
+playlist is decoded on its own and passed to a Handler, and only the one being
+handled is held in memory.
+*/
+package itunes
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Library holds the library's top-level keys, other than its tracks and playlists.
+type Library struct {
+	MajorVersion        int       `plist:"Major Version"`
+	MinorVersion        int       `plist:"Minor Version"`
+	ApplicationVersion  string    `plist:"Application Version"`
+	Date                time.Time `plist:"Date"`
+	Features            int       `plist:"Features"`
+	ShowContentRatings  bool      `plist:"Show Content Ratings"`
+	MusicFolder         string    `plist:"Music Folder"`
+	LibraryPersistentID string    `plist:"Library Persistent ID"`
+
+	Unknown map[string]interface{} `plist:",unknown"`
+}
+
+// Track is an entry in the library's Tracks dict.
+type Track struct {
+	TrackID      int    `plist:"Track ID"`
+	PersistentID string `plist:"Persistent ID"`
+	TrackType    string `plist:"Track Type"`
+	Kind         string `plist:"Kind"`
+	Location     string `plist:"Location"`
+
+	Name        string `plist:"Name"`
+	Artist      string `plist:"Artist"`
+	AlbumArtist string `plist:"Album Artist"`
+	Composer    string `plist:"Composer"`
+	Album       string `plist:"Album"`
+	Genre       string `plist:"Genre"`
+	Grouping    string `plist:"Grouping"`
+	Comments    string `plist:"Comments"`
+	Year        int    `plist:"Year"`
+	DiscNumber  int    `plist:"Disc Number"`
+	DiscCount   int    `plist:"Disc Count"`
+	TrackNumber int    `plist:"Track Number"`
+	TrackCount  int    `plist:"Track Count"`
+	Compilation bool   `plist:"Compilation"`
+
+	SortName        string `plist:"Sort Name"`
+	SortArtist      string `plist:"Sort Artist"`
+	SortAlbumArtist string `plist:"Sort Album Artist"`
+	SortAlbum       string `plist:"Sort Album"`
+	SortComposer    string `plist:"Sort Composer"`
+
+	Size int64 `plist:"Size"`
+	// TotalTime is the track's length in milliseconds.
+	TotalTime  int `plist:"Total Time"`
+	BitRate    int `plist:"Bit Rate"`
+	SampleRate int `plist:"Sample Rate"`
+
+	DateModified time.Time `plist:"Date Modified"`
+	DateAdded    time.Time `plist:"Date Added"`
+	PlayCount    int       `plist:"Play Count"`
+	// PlayDate is PlayDateUTC in seconds since 1904 in local time, as classic Mac OS counted.
+	PlayDate    int64     `plist:"Play Date"`
+	PlayDateUTC time.Time `plist:"Play Date UTC"`
+	SkipCount   int       `plist:"Skip Count"`
+	SkipDate    time.Time `plist:"Skip Date"`
+	// Rating and AlbumRating are 0 to 100, 20 per star.
+	Rating      int  `plist:"Rating"`
+	AlbumRating int  `plist:"Album Rating"`
+	Loved       bool `plist:"Loved"`
+	Disliked    bool `plist:"Disliked"`
+
+	Disabled bool `plist:"Disabled"`
+	Explicit bool `plist:"Explicit"`
+	HasVideo bool `plist:"Has Video"`
+	Movie    bool `plist:"Movie"`
+	TVShow   bool `plist:"TV Show"`
+	Podcast  bool `plist:"Podcast"`
+
+	Unknown map[string]interface{} `plist:",unknown"`
+}
+
+// Playlist is an entry in the library's Playlists array.
+type Playlist struct {
+	Name                 string `plist:"Name"`
+	Description          string `plist:"Description"`
+	PlaylistID           int    `plist:"Playlist ID"`
+	PlaylistPersistentID string `plist:"Playlist Persistent ID"`
+	// ParentPersistentID is the persistent ID of the folder the playlist is in.
+	ParentPersistentID string `plist:"Parent Persistent ID"`
+	// Master is set on the playlist of the whole library.
+	Master            bool `plist:"Master"`
+	Visible           bool `plist:"Visible"`
+	AllItems          bool `plist:"All Items"`
+	Folder            bool `plist:"Folder"`
+	DistinguishedKind int  `plist:"Distinguished Kind"`
+	// SmartInfo and SmartCriteria hold the rules of smart playlists, in an
+	// undocumented binary format.
+	SmartInfo     []byte         `plist:"Smart Info"`
+	SmartCriteria []byte         `plist:"Smart Criteria"`
+	Items         []PlaylistItem `plist:"Playlist Items"`
+
+	Unknown map[string]interface{} `plist:",unknown"`
+}
+
+// PlaylistItem refers to a track in a playlist.
+type PlaylistItem struct {
+	TrackID int `plist:"Track ID"`
+}
+
+/*
+Handler receives the tracks and playlists of a library as Decode reads them.
+
+Either function may be nil, in which case those entries are skipped without
+being decoded. If a function returns an error, Decode stops and returns it.
+*/
+type Handler struct {
+	Track    func(*Track) error
+	Playlist func(*Playlist) error
+}
+
+// Decode reads a library from r, passing each track and playlist to h in the
+// order they appear, and returns the library's other keys.
+func Decode(r io.Reader, h Handler) (*Library, error) {
+	d := xml.NewDecoder(r)
+	value, err := d.NextValue()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := value.(xml.StartDecodingDict); !ok {
+		return nil, fmt.Errorf("itunes: library is not a dict")
+	}
+
+	header := map[string]interface{}{}
+	for {
+		value, err := d.NextValue()
+		if err != nil {
+			return nil, err
+		}
+		entry, ok := value.(xml.DictEntry)
+		if !ok {
+			break
+		}
+
+		switch {
+		case entry.Key == "Tracks" && entry.Value == xml.StartDecodingDict{}:
+			err = decodeTracks(d, h.Track)
+		case entry.Key == "Playlists" && entry.Value == xml.StartDecodingArray{}:
+			err = decodePlaylists(d, h.Playlist)
+		default:
+			header[entry.Key], err = finishDecoding(d, entry.Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	library := new(Library)
+	if err := xml.UnmarshalValue(header, library); err != nil {
+		return nil, fmt.Errorf("itunes: %v", err)
+	}
+	return library, nil
+}
+
+func decodeTracks(d *xml.PlistDecoder, handle func(*Track) error) error {
+	if handle == nil {
+		return skip(d)
+	}
+	for {
+		value, err := d.DecodeValue()
+		if err != nil {
+			return err
+		}
+		entry, ok := value.(xml.DictEntry)
+		if !ok {
+			return nil
+		}
+
+		track := new(Track)
+		if err := xml.UnmarshalValue(entry.Value, track); err != nil {
+			return fmt.Errorf("itunes: track %s: %v", entry.Key, err)
+		}
+		if err := handle(track); err != nil {
+			return err
+		}
+	}
+}
+
+func decodePlaylists(d *xml.PlistDecoder, handle func(*Playlist) error) error {
+	if handle == nil {
+		return skip(d)
+	}
+	for i := 0; ; i++ {
+		value, err := d.DecodeValue()
+		if err != nil {
+			return err
+		}
+		if _, ok := value.(xml.EndDecodingContainer); ok {
+			return nil
+		}
+
+		playlist := new(Playlist)
+		if err := xml.UnmarshalValue(value, playlist); err != nil {
+			return fmt.Errorf("itunes: playlist %d: %v", i, err)
+		}
+		if err := handle(playlist); err != nil {
+			return err
+		}
+	}
+}
+
+// finishDecoding reads the rest of a container whose start NextValue returned,
+// as DecodeValue would have.
+func finishDecoding(d *xml.PlistDecoder, start interface{}) (interface{}, error) {
+	switch start.(type) {
+	case xml.StartDecodingArray:
+		array := []interface{}{}
+		for {
+			value, err := d.DecodeValue()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := value.(xml.EndDecodingContainer); ok {
+				return array, nil
+			}
+			array = append(array, value)
+		}
+	case xml.StartDecodingDict:
+		dict := map[string]interface{}{}
+		for {
+			value, err := d.DecodeValue()
+			if err != nil {
+				return nil, err
+			}
+			entry, ok := value.(xml.DictEntry)
+			if !ok {
+				return dict, nil
+			}
+			dict[entry.Key] = entry.Value
+		}
+	}
+	return start, nil
+}
+
+// skip reads the rest of the current container without decoding it.
+func skip(d *xml.PlistDecoder) error {
+	for depth := 1; depth > 0; {
+		value, err := d.NextValue()
+		if err != nil {
+			return err
+		}
+		if entry, ok := value.(xml.DictEntry); ok {
+			value = entry.Value
+		}
+		switch value.(type) {
+		case xml.StartDecodingArray, xml.StartDecodingDict:
+			depth++
+		case xml.EndDecodingContainer:
+			depth--
+		}
+	}
+	return nil
+}
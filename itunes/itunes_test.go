@@ -0,0 +1,218 @@
+package itunes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const library = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Major Version</key><integer>1</integer>
+	<key>Minor Version</key><integer>1</integer>
+	<key>Date</key><date>2024-01-02T03:04:05Z</date>
+	<key>Application Version</key><string>1.4.2.4</string>
+	<key>Features</key><integer>5</integer>
+	<key>Show Content Ratings</key><true/>
+	<key>Library Persistent ID</key><string>0123456789ABCDEF</string>
+	<key>Tracks</key>
+	<dict>
+		<key>101</key>
+		<dict>
+			<key>Track ID</key><integer>101</integer>
+			<key>Name</key><string>Song &amp; Dance</string>
+			<key>Artist</key><string>The Band</string>
+			<key>Album</key><string>Album</string>
+			<key>Total Time</key><integer>215000</integer>
+			<key>Size</key><integer>5000000000</integer>
+			<key>Date Added</key><date>2023-05-06T07:08:09Z</date>
+			<key>Play Count</key><integer>12</integer>
+			<key>Rating</key><integer>80</integer>
+			<key>Loved</key><true/>
+			<key>Persistent ID</key><string>AAAAAAAAAAAAAAAA</string>
+			<key>Track Type</key><string>File</string>
+			<key>Location</key><string>file:///Music/song.m4a</string>
+			<key>Normalization</key><integer>1234</integer>
+		</dict>
+		<key>102</key>
+		<dict>
+			<key>Track ID</key><integer>102</integer>
+			<key>Name</key><string>Stream</string>
+			<key>Track Type</key><string>URL</string>
+		</dict>
+	</dict>
+	<key>Playlists</key>
+	<array>
+		<dict>
+			<key>Name</key><string>Library</string>
+			<key>Master</key><true/>
+			<key>Playlist ID</key><integer>1</integer>
+			<key>Visible</key><false/>
+			<key>All Items</key><true/>
+			<key>Playlist Items</key>
+			<array>
+				<dict><key>Track ID</key><integer>101</integer></dict>
+				<dict><key>Track ID</key><integer>102</integer></dict>
+			</array>
+		</dict>
+		<dict>
+			<key>Name</key><string>Favorites</string>
+			<key>Playlist ID</key><integer>2</integer>
+			<key>Smart Info</key><data>AQE=</data>
+			<key>Playlist Items</key>
+			<array>
+				<dict><key>Track ID</key><integer>101</integer></dict>
+			</array>
+		</dict>
+	</array>
+	<key>Music Folder</key><string>file:///Music/</string>
+</dict>
+</plist>`
+
+func TestDecode(t *testing.T) {
+	var tracks []*Track
+	var playlists []*Playlist
+	lib, err := Decode(strings.NewReader(library), Handler{
+		Track: func(track *Track) error {
+			tracks = append(tracks, track)
+			return nil
+		},
+		Playlist: func(playlist *Playlist) error {
+			playlists = append(playlists, playlist)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, &Library{
+		MajorVersion:        1,
+		MinorVersion:        1,
+		ApplicationVersion:  "1.4.2.4",
+		Date:                time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Features:            5,
+		ShowContentRatings:  true,
+		MusicFolder:         "file:///Music/",
+		LibraryPersistentID: "0123456789ABCDEF",
+	}, lib)
+
+	assert.Equal(t, []*Track{
+		{
+			TrackID:      101,
+			PersistentID: "AAAAAAAAAAAAAAAA",
+			TrackType:    "File",
+			Location:     "file:///Music/song.m4a",
+			Name:         "Song & Dance",
+			Artist:       "The Band",
+			Album:        "Album",
+			Size:         5000000000,
+			TotalTime:    215000,
+			DateAdded:    time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC),
+			PlayCount:    12,
+			Rating:       80,
+			Loved:        true,
+			Unknown:      map[string]interface{}{"Normalization": int64(1234)},
+		},
+		{TrackID: 102, Name: "Stream", TrackType: "URL"},
+	}, tracks)
+
+	assert.Equal(t, []*Playlist{
+		{
+			Name:       "Library",
+			PlaylistID: 1,
+			Master:     true,
+			AllItems:   true,
+			Items:      []PlaylistItem{{101}, {102}},
+		},
+		{
+			Name:       "Favorites",
+			PlaylistID: 2,
+			SmartInfo:  []byte{1, 1},
+			Items:      []PlaylistItem{{101}},
+		},
+	}, playlists)
+}
+
+func TestDecodeSkip(t *testing.T) {
+	var names []string
+	lib, err := Decode(strings.NewReader(library), Handler{
+		Playlist: func(playlist *Playlist) error {
+			names = append(names, playlist.Name)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Library", "Favorites"}, names)
+	assert.Equal(t, "file:///Music/", lib.MusicFolder)
+
+	lib, err = Decode(strings.NewReader(library), Handler{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lib.MajorVersion)
+}
+
+func TestDecodeHandlerError(t *testing.T) {
+	stop := errors.New("stop")
+	var count int
+	_, err := Decode(strings.NewReader(library), Handler{
+		Track: func(*Track) error {
+			count++
+			return stop
+		},
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, err := Decode(strings.NewReader(`<plist><array></array></plist>`), Handler{})
+	assert.EqualError(t, err, "itunes: library is not a dict")
+
+	_, err = Decode(strings.NewReader(`<plist><dict><key>Tracks</key><dict>
+		<key>1</key><dict><key>Track ID</key><string>one</string></dict>
+	</dict></dict></plist>`), Handler{Track: func(*Track) error { return nil }})
+	assert.EqualError(t, err, "itunes: track 1: cannot unmarshal string into Go value of type int at Track ID")
+}
+
+// generate writes a library with n tracks, all in one playlist.
+func generate(w *io.PipeWriter, n int) {
+	fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><dict><key>Tracks</key><dict>`)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(w, `<key>%[1]d</key><dict><key>Track ID</key><integer>%[1]d</integer><key>Name</key><string>Track %[1]d</string></dict>`, i)
+	}
+	fmt.Fprint(w, `</dict><key>Playlists</key><array><dict><key>Name</key><string>Library</string><key>Playlist Items</key><array>`)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(w, `<dict><key>Track ID</key><integer>%d</integer></dict>`, i)
+	}
+	fmt.Fprint(w, `</array></dict></array></dict></plist>`)
+	w.Close()
+}
+
+func TestDecodeLarge(t *testing.T) {
+	const n = 50000
+	r, w := io.Pipe()
+	go generate(w, n)
+
+	var tracks, items int
+	_, err := Decode(r, Handler{
+		Track: func(track *Track) error {
+			if track.TrackID != tracks {
+				return fmt.Errorf("expected track %d, got %d", tracks, track.TrackID)
+			}
+			tracks++
+			return nil
+		},
+		Playlist: func(playlist *Playlist) error {
+			items += len(playlist.Items)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, n, tracks)
+	assert.Equal(t, n, items)
+}
@@ -0,0 +1,260 @@
+package keyedarchive
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/zach-klippenstein/goplist"
+)
+
+// Encode archives root and writes the archive to w as an XML plist.
+func Encode(w io.Writer, root interface{}) error {
+	data, err := Marshal(root, plist.XMLFormat)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Marshal archives root and returns the archive as a plist in format.
+// NSKeyedArchiver writes archives in the binary format.
+func Marshal(root interface{}, format plist.Format) ([]byte, error) {
+	archive, err := Archive(root)
+	if err != nil {
+		return nil, err
+	}
+	return plist.Marshal(archive, format)
+}
+
+/*
+Archive returns the archive of root as a plist value, e.g. to embed it in
+another plist or write it with xml.DictEncoder.WriteValue.
+
+The Go types listed in the package documentation are archived as their
+Foundation classes. Strings are stored once, however many times they appear.
+For an *Object, numbers, bools and []byte in Fields are stored inline like
+NSCoder's encodeInt:forKey: and encodeBytes:length:forKey:, and other values
+are stored as references.
+*/
+func Archive(root interface{}) (map[string]interface{}, error) {
+	s := archiveState{
+		objects:  []interface{}{nullObject},
+		classes:  map[string]UID{},
+		strings:  map[string]UID{},
+		archived: map[*Object]map[string]interface{}{},
+	}
+	ref, err := s.archive(root)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"$archiver": archiverName,
+		"$version":  int64(archiverVersion),
+		"$top":      map[string]interface{}{"root": ref},
+		"$objects":  s.objects,
+	}, nil
+}
+
+type archiveState struct {
+	objects []interface{}
+	classes map[string]UID
+	strings map[string]UID
+	// archived holds the references to the *Objects archived so far, so an
+	// object is stored once, and objects can refer back to it.
+	archived map[*Object]map[string]interface{}
+}
+
+func uidValue(uid UID) map[string]interface{} {
+	return map[string]interface{}{uidKey: uint64(uid)}
+}
+
+// add appends object to $objects and returns a reference to it.
+func (s *archiveState) add(object interface{}) map[string]interface{} {
+	s.objects = append(s.objects, object)
+	return uidValue(UID(len(s.objects) - 1))
+}
+
+// archive adds value to $objects and returns a reference to it.
+func (s *archiveState) archive(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return uidValue(0), nil
+	case Marshaler:
+		o, err := value.MarshalArchive()
+		if err != nil {
+			return nil, err
+		}
+		return s.archiveObject(o)
+	case *Object:
+		return s.archiveObject(value)
+	case string:
+		if uid, ok := s.strings[value]; ok {
+			return uidValue(uid), nil
+		}
+		ref := s.add(value)
+		s.strings[value] = UID(len(s.objects) - 1)
+		return ref, nil
+	case time.Time:
+		seconds := float64(value.Sub(referenceDate)) / float64(time.Second)
+		return s.archiveObject(&Object{ClassName: "NSDate", Fields: map[string]interface{}{"NS.time": seconds}})
+	case []byte:
+		return s.archiveObject(&Object{ClassName: "NSData", Fields: map[string]interface{}{"NS.data": value}})
+	case *url.URL:
+		return s.archiveObject(&Object{ClassName: "NSURL", Fields: map[string]interface{}{
+			"NS.base":     nil,
+			"NS.relative": value.String(),
+		}})
+	case UUID:
+		return s.archiveObject(&Object{ClassName: "NSUUID", Fields: map[string]interface{}{"NS.uuidbytes": value[:]}})
+	}
+
+	if number, ok := inlineValue(value); ok {
+		return s.add(number), nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return uidValue(0), nil
+		}
+		return s.archive(v.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		return s.archiveArray(v)
+	case reflect.Map:
+		return s.archiveDictionary(v)
+	}
+	return nil, fmt.Errorf("keyedarchive: cannot archive value of type %T", value)
+}
+
+// inlineValue returns value as a plist number or bool, if it is one.
+func inlineValue(value interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return nil, false
+}
+
+func (s *archiveState) archiveArray(v reflect.Value) (interface{}, error) {
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return s.archiveObject(&Object{ClassName: "NSArray", Fields: map[string]interface{}{"NS.objects": items}})
+}
+
+func (s *archiveState) archiveDictionary(v reflect.Value) (interface{}, error) {
+	keys := v.MapKeys()
+	sort.Sort(byString(keys))
+
+	keyItems := make([]interface{}, len(keys))
+	valueItems := make([]interface{}, len(keys))
+	for i, key := range keys {
+		keyItems[i] = key.Interface()
+		valueItems[i] = v.MapIndex(key).Interface()
+	}
+	return s.archiveObject(&Object{ClassName: "NSDictionary", Fields: map[string]interface{}{
+		"NS.keys":    keyItems,
+		"NS.objects": valueItems,
+	}})
+}
+
+func (s *archiveState) archiveObject(o *Object) (interface{}, error) {
+	if o == nil {
+		return uidValue(0), nil
+	}
+
+	if ref, ok := s.archived[o]; ok {
+		return ref, nil
+	}
+
+	// Reserve the object's slot first, so it comes before the objects it refers to.
+	ref := s.add(nil)
+	uid := UID(len(s.objects) - 1)
+	s.archived[o] = ref
+
+	fields := map[string]interface{}{"$class": s.archiveClass(o)}
+	keys := make([]string, 0, len(o.Fields))
+	for key := range o.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, err := s.archiveField(o.Fields[key])
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = value
+	}
+
+	s.objects[uid] = fields
+	return ref, nil
+}
+
+// archiveField returns the value to store in an object's field: numbers, bools
+// and data inline, arrays as arrays of references, and anything else as a reference.
+func (s *archiveState) archiveField(value interface{}) (interface{}, error) {
+	if data, ok := value.([]byte); ok {
+		return data, nil
+	}
+	if number, ok := inlineValue(value); ok {
+		return number, nil
+	}
+	if items, ok := value.([]interface{}); ok {
+		refs := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			if refs[i], err = s.archive(item); err != nil {
+				return nil, err
+			}
+		}
+		return refs, nil
+	}
+	return s.archive(value)
+}
+
+func (s *archiveState) archiveClass(o *Object) map[string]interface{} {
+	if uid, ok := s.classes[o.ClassName]; ok {
+		return uidValue(uid)
+	}
+
+	classes := o.Classes
+	if len(classes) == 0 {
+		classes = []string{o.ClassName, "NSObject"}
+	}
+	classNames := make([]interface{}, len(classes))
+	for i, name := range classes {
+		classNames[i] = name
+	}
+
+	ref := s.add(map[string]interface{}{
+		"$classname": o.ClassName,
+		"$classes":   classNames,
+	})
+	s.classes[o.ClassName] = UID(len(s.objects) - 1)
+	return ref
+}
+
+// byString sorts map keys by their string form, so dictionaries are archived
+// in a stable order.
+type byString []reflect.Value
+
+func (k byString) Len() int      { return len(k) }
+func (k byString) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k byString) Less(i, j int) bool {
+	return fmt.Sprint(k[i].Interface()) < fmt.Sprint(k[j].Interface())
+}
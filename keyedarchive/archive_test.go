@@ -0,0 +1,122 @@
+package keyedarchive
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist"
+	"github.com/zach-klippenstein/goplist/binary"
+)
+
+func TestArchive(t *testing.T) {
+	archive, err := Archive([]string{"a", "a"})
+	assert.NoError(t, err)
+
+	uid := func(i uint64) map[string]interface{} {
+		return map[string]interface{}{"CF$UID": i}
+	}
+	assert.Equal(t, map[string]interface{}{
+		"$archiver": "NSKeyedArchiver",
+		"$version":  int64(100000),
+		"$top":      map[string]interface{}{"root": uid(1)},
+		"$objects": []interface{}{
+			"$null",
+			map[string]interface{}{
+				"$class":     uid(2),
+				"NS.objects": []interface{}{uid(3), uid(3)},
+			},
+			map[string]interface{}{
+				"$classname": "NSArray",
+				"$classes":   []interface{}{"NSArray", "NSObject"},
+			},
+			"a",
+		},
+	}, archive)
+}
+
+type point struct {
+	x, y int
+}
+
+func (p point) MarshalArchive() (*Object, error) {
+	return &Object{
+		ClassName: "Point",
+		Fields:    map[string]interface{}{"x": p.x, "y": p.y},
+	}, nil
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	homepage, _ := url.Parse("https://example.com/a?b=c")
+	created := time.Date(2020, 1, 6, 10, 40, 0, 500000000, time.UTC)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, Encode(&buffer, map[string]interface{}{
+		"name":     "example",
+		"count":    3,
+		"ratio":    0.5,
+		"enabled":  true,
+		"nothing":  nil,
+		"created":  created,
+		"data":     []byte{1, 2, 3},
+		"homepage": homepage,
+		"id":       UUID{1},
+		"tags":     []interface{}{"a", map[string]interface{}{"nested": "a"}},
+		"origin":   point{1, 2},
+	}))
+
+	root, err := Decode(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":     "example",
+		"count":    int64(3),
+		"ratio":    0.5,
+		"enabled":  true,
+		"nothing":  nil,
+		"created":  created,
+		"data":     []byte{1, 2, 3},
+		"homepage": homepage,
+		"id":       UUID{1},
+		"tags":     []interface{}{"a", map[string]interface{}{"nested": "a"}},
+		"origin": &Object{
+			ClassName: "Point",
+			Classes:   []string{"Point", "NSObject"},
+			Fields:    map[string]interface{}{"x": int64(1), "y": int64(2)},
+		},
+	}, root)
+}
+
+func TestMarshalBinary(t *testing.T) {
+	data, err := Marshal(map[string]interface{}{"name": "example", "tags": []interface{}{"a", "a"}}, plist.BinaryFormat)
+	assert.NoError(t, err)
+	assert.True(t, binary.IsBinary(data))
+
+	root, err := Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "example", "tags": []interface{}{"a", "a"}}, root)
+}
+
+func TestArchiveCycle(t *testing.T) {
+	parent := &Object{ClassName: "Node", Fields: map[string]interface{}{}}
+	child := &Object{ClassName: "Node", Fields: map[string]interface{}{"parent": parent}}
+	parent.Fields["children"] = []interface{}{child}
+
+	data, err := Marshal(parent, plist.BinaryFormat)
+	assert.NoError(t, err)
+	root, err := Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	decoded := root.(*Object)
+	decodedChild := decoded.Fields["children"].([]interface{})[0].(*Object)
+	assert.True(t, decodedChild.Fields["parent"] == decoded)
+}
+
+func TestArchiveUnsupported(t *testing.T) {
+	_, err := Archive(struct{}{})
+	assert.EqualError(t, err, "keyedarchive: cannot archive value of type struct {}")
+
+	_, err = Archive([]interface{}{make(chan int)})
+	assert.EqualError(t, err, "keyedarchive: cannot archive value of type chan int")
+}
@@ -0,0 +1,81 @@
+/*
+Package keyedarchive reads and writes NSKeyedArchiver archives.
+
+An archive is a plist dict whose $objects array holds every archived object,
+with references between objects written as UIDs (in XML, a dict with a single
+CF$UID key). $top maps names to the root objects, which are usually stored
+under "root".
+
+Unarchiving resolves the references and converts each object to a Go value with
+the ClassDecoder registered for its class:
+
+	NSString             string
+	NSNumber             bool, int64, uint64 or float64
+	NSArray, NSSet       []interface{}
+	NSDictionary         map[string]interface{}, or map[interface{}]interface{} for non-string keys
+	NSDate               time.Time
+	NSData               []byte
+	NSURL                *url.URL
+	NSUUID               UUID
+
+Subclasses, like NSMutableArray, use the decoder of the nearest superclass
+that has one. Objects of other classes are returned as *Object. An object can
+refer back to an *Object that contains it, as delegates and parents do, and
+gets the same *Object. A cycle through a value returned by a ClassDecoder, like
+an NSArray that contains itself, is an error.
+
+Archive does the reverse, and also accepts any slice or map with string keys,
+*Object, and types that implement Marshaler. Each *Object is archived once,
+so cycles between them are kept.
+
+Decode reads archives in either plist format. Marshal writes either, and Encode
+writes XML.
+*/
+package keyedarchive
+
+import (
+	"fmt"
+	"time"
+)
+
+// UID is a reference to an object in an archive's $objects array.
+type UID uint64
+
+// UUID is the value of an NSUUID.
+type UUID [16]byte
+
+func (u UUID) String() string {
+	return fmt.Sprintf("%X-%X-%X-%X-%X", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+/*
+Object is an object of a class that has no decoder, or the archived form of a
+Go value returned by Marshaler.
+
+When unarchived, Fields holds the object's keys with their values unarchived.
+When archived, Fields are archived the same way the root value is.
+*/
+type Object struct {
+	// ClassName is the object's class, e.g. "NSColor".
+	ClassName string
+	// Classes is the class hierarchy, starting with ClassName, e.g.
+	// ["NSColor", "NSObject"]. If empty when archiving, it defaults to
+	// ClassName and NSObject.
+	Classes []string
+	Fields  map[string]interface{}
+}
+
+// Marshaler is implemented by types that can archive themselves.
+type Marshaler interface {
+	MarshalArchive() (*Object, error)
+}
+
+const (
+	archiverName    = "NSKeyedArchiver"
+	archiverVersion = 100000
+	nullObject      = "$null"
+	uidKey          = "CF$UID"
+)
+
+// referenceDate is the epoch of NSDate's time interval.
+var referenceDate = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -0,0 +1,423 @@
+package keyedarchive
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/zach-klippenstein/goplist"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// ClassDecoder converts an archived object to a Go value.
+type ClassDecoder func(c *Coder) (interface{}, error)
+
+// Unarchiver unarchives objects with a set of ClassDecoders.
+type Unarchiver struct {
+	decoders map[string]ClassDecoder
+}
+
+// NewUnarchiver returns an Unarchiver with decoders for the classes listed
+// in the package documentation.
+func NewUnarchiver() *Unarchiver {
+	return &Unarchiver{
+		decoders: map[string]ClassDecoder{
+			"NSString":     decodeString,
+			"NSArray":      decodeArray,
+			"NSSet":        decodeArray,
+			"NSOrderedSet": decodeArray,
+			"NSDictionary": decodeDictionary,
+			"NSDate":       decodeDate,
+			"NSData":       decodeData,
+			"NSURL":        decodeURL,
+			"NSUUID":       decodeUUID,
+		},
+	}
+}
+
+// Register sets the decoder for className and its subclasses, replacing any
+// existing decoder.
+func (u *Unarchiver) Register(className string, decode ClassDecoder) {
+	u.decoders[className] = decode
+}
+
+// Decode reads an archive from r with a default Unarchiver.
+func Decode(r io.Reader) (interface{}, error) {
+	return NewUnarchiver().Decode(r)
+}
+
+// Decode reads an archive from r, in the binary or XML plist format, and
+// returns its root object.
+func (u *Unarchiver) Decode(r io.Reader) (interface{}, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	archive, _, err := plist.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return u.Unarchive(archive)
+}
+
+/*
+Unarchive returns the root object of archive, which is a plist value as
+returned by xml.PlistDecoder.DecodeValue. The root is the $top object named
+"root", or the only $top object if there's just one.
+*/
+func (u *Unarchiver) Unarchive(archive interface{}) (interface{}, error) {
+	top, err := u.UnarchiveTop(archive)
+	if err != nil {
+		return nil, err
+	}
+	if root, ok := top["root"]; ok {
+		return root, nil
+	}
+	if len(top) == 1 {
+		for _, root := range top {
+			return root, nil
+		}
+	}
+	return nil, fmt.Errorf("keyedarchive: no root object in $top")
+}
+
+// UnarchiveTop returns every object in archive's $top dict, by name.
+func (u *Unarchiver) UnarchiveTop(archive interface{}) (map[string]interface{}, error) {
+	dict, ok := archive.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("keyedarchive: archive must be a dict, found %T", archive)
+	}
+	if archiver, _ := dict["$archiver"].(string); archiver != archiverName {
+		return nil, fmt.Errorf("keyedarchive: unsupported $archiver %q", archiver)
+	}
+	objects, ok := dict["$objects"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("keyedarchive: $objects must be an array")
+	}
+	top, ok := dict["$top"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("keyedarchive: $top must be a dict")
+	}
+
+	s := unarchiveState{
+		unarchiver: u,
+		objects:    objects,
+		decoded:    map[UID]interface{}{},
+		decoding:   map[UID]bool{},
+	}
+	values := map[string]interface{}{}
+	for name, ref := range top {
+		value, err := s.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+type unarchiveState struct {
+	unarchiver *Unarchiver
+	objects    []interface{}
+	decoded    map[UID]interface{}
+	// decoding holds the objects being decoded, to detect cycles.
+	decoding map[UID]bool
+}
+
+// resolve unarchives the object value refers to, if it's a UID, and otherwise
+// returns value as is. Arrays of UIDs are resolved element-wise.
+func (s *unarchiveState) resolve(value interface{}) (interface{}, error) {
+	if uid, ok := asUID(value); ok {
+		return s.object(uid)
+	}
+	if array, ok := value.([]interface{}); ok {
+		resolved := make([]interface{}, len(array))
+		for i, item := range array {
+			var err error
+			if resolved[i], err = s.resolve(item); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	}
+	return value, nil
+}
+
+func (s *unarchiveState) object(uid UID) (interface{}, error) {
+	if value, ok := s.decoded[uid]; ok {
+		return value, nil
+	}
+	if uid >= UID(len(s.objects)) {
+		return nil, fmt.Errorf("keyedarchive: reference to object %d out of range", uid)
+	}
+	if s.decoding[uid] {
+		return nil, fmt.Errorf("keyedarchive: cyclic reference to object %d", uid)
+	}
+	s.decoding[uid] = true
+	defer delete(s.decoding, uid)
+
+	value, err := s.decodeObject(uid, s.objects[uid])
+	if err != nil {
+		return nil, err
+	}
+	s.decoded[uid] = value
+	return value, nil
+}
+
+func (s *unarchiveState) decodeObject(uid UID, raw interface{}) (interface{}, error) {
+	if raw == nullObject {
+		return nil, nil
+	}
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		// Strings and numbers are stored as plain values.
+		return raw, nil
+	}
+
+	classUID, ok := asUID(fields["$class"])
+	if !ok {
+		return nil, fmt.Errorf("keyedarchive: object has no $class")
+	}
+	if classUID >= UID(len(s.objects)) {
+		return nil, fmt.Errorf("keyedarchive: reference to class %d out of range", classUID)
+	}
+	class, ok := s.objects[classUID].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("keyedarchive: class %d is not a dict", classUID)
+	}
+	className, _ := class["$classname"].(string)
+	if className == "" {
+		return nil, fmt.Errorf("keyedarchive: class %d has no $classname", classUID)
+	}
+	var classes []string
+	if err := xml.UnmarshalValue(class["$classes"], &classes); err != nil || len(classes) == 0 {
+		classes = []string{className}
+	}
+
+	c := &Coder{uid: uid, className: className, classes: classes, fields: fields, state: s}
+	for _, name := range classes {
+		if decode, ok := s.unarchiver.decoders[name]; ok {
+			return decode(c)
+		}
+	}
+	return c.object()
+}
+
+func asUID(value interface{}) (UID, bool) {
+	dict, ok := value.(map[string]interface{})
+	if !ok || len(dict) != 1 {
+		return 0, false
+	}
+	switch uid := dict[uidKey].(type) {
+	case int64:
+		if uid >= 0 {
+			return UID(uid), true
+		}
+	case uint64:
+		return UID(uid), true
+	}
+	return 0, false
+}
+
+// Coder gives a ClassDecoder access to an archived object's fields.
+type Coder struct {
+	uid       UID
+	className string
+	classes   []string
+	fields    map[string]interface{}
+	state     *unarchiveState
+}
+
+// ClassName returns the object's class.
+func (c *Coder) ClassName() string {
+	return c.className
+}
+
+// Classes returns the object's class hierarchy, starting with its class.
+func (c *Coder) Classes() []string {
+	return c.classes
+}
+
+// Keys returns the object's keys, except $class, in order.
+func (c *Coder) Keys() []string {
+	var keys []string
+	for key := range c.fields {
+		if key != "$class" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Contains returns true if the object has a value for key.
+func (c *Coder) Contains(key string) bool {
+	_, ok := c.fields[key]
+	return ok
+}
+
+// Raw returns the value of key without resolving references.
+func (c *Coder) Raw(key string) interface{} {
+	return c.fields[key]
+}
+
+// Decode returns the value of key, unarchiving it if it's a reference to
+// another object. Arrays of references are unarchived to []interface{}.
+// Missing keys decode to nil.
+func (c *Coder) Decode(key string) (interface{}, error) {
+	return c.state.resolve(c.fields[key])
+}
+
+// DecodeString decodes key, which must be a string or missing.
+func (c *Coder) DecodeString(key string) (string, error) {
+	value, err := c.Decode(key)
+	if value == nil || err != nil {
+		return "", err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("keyedarchive: %s.%s must be a string, found %T", c.className, key, value)
+	}
+	return s, nil
+}
+
+// DecodeArray decodes key, which must be an array or missing.
+func (c *Coder) DecodeArray(key string) ([]interface{}, error) {
+	value, err := c.Decode(key)
+	if value == nil || err != nil {
+		return nil, err
+	}
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("keyedarchive: %s.%s must be an array, found %T", c.className, key, value)
+	}
+	return array, nil
+}
+
+func (c *Coder) object() (*Object, error) {
+	o := &Object{ClassName: c.className, Classes: c.classes, Fields: map[string]interface{}{}}
+	// Objects inside this one, like delegates, can refer back to it.
+	c.state.decoded[c.uid] = o
+	for _, key := range c.Keys() {
+		value, err := c.Decode(key)
+		if err != nil {
+			return nil, err
+		}
+		o.Fields[key] = value
+	}
+	return o, nil
+}
+
+func decodeString(c *Coder) (interface{}, error) {
+	return c.DecodeString("NS.string")
+}
+
+func decodeArray(c *Coder) (interface{}, error) {
+	array, err := c.DecodeArray("NS.objects")
+	if array == nil && err == nil {
+		array = []interface{}{}
+	}
+	return array, err
+}
+
+func decodeDictionary(c *Coder) (interface{}, error) {
+	keys, err := c.DecodeArray("NS.keys")
+	if err != nil {
+		return nil, err
+	}
+	values, err := c.DecodeArray("NS.objects")
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("keyedarchive: %s has %d keys but %d values", c.className, len(keys), len(values))
+	}
+
+	stringKeys := map[string]interface{}{}
+	for i, key := range keys {
+		s, ok := key.(string)
+		if !ok {
+			return decodeInterfaceDictionary(c, keys, values)
+		}
+		stringKeys[s] = values[i]
+	}
+	return stringKeys, nil
+}
+
+func decodeInterfaceDictionary(c *Coder, keys, values []interface{}) (interface{}, error) {
+	dict := map[interface{}]interface{}{}
+	for i, key := range keys {
+		// Keys decoded to *Object would only be equal to themselves, and other
+		// keys, like big.Ints or a ClassDecoder's values, may not be comparable.
+		_, isObject := key.(*Object)
+		if isObject || key != nil && !reflect.TypeOf(key).Comparable() {
+			return nil, fmt.Errorf("keyedarchive: %s key of type %T can't be used as a Go map key", c.className, key)
+		}
+		dict[key] = values[i]
+	}
+	return dict, nil
+}
+
+func decodeDate(c *Coder) (interface{}, error) {
+	var seconds float64
+	switch value := c.Raw("NS.time").(type) {
+	case float64:
+		seconds = value
+	case int64:
+		seconds = float64(value)
+	default:
+		return nil, fmt.Errorf("keyedarchive: %s.NS.time must be a number, found %T", c.className, value)
+	}
+	whole, fraction := math.Modf(seconds)
+	return referenceDate.Add(time.Duration(whole) * time.Second).Add(time.Duration(fraction * float64(time.Second))), nil
+}
+
+func decodeData(c *Coder) (interface{}, error) {
+	value, err := c.Decode("NS.data")
+	if err != nil {
+		return nil, err
+	}
+	data, ok := value.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("keyedarchive: %s.NS.data must be data, found %T", c.className, value)
+	}
+	return data, nil
+}
+
+func decodeURL(c *Coder) (interface{}, error) {
+	relative, err := c.DecodeString("NS.relative")
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(relative)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := c.Decode("NS.base")
+	if err != nil {
+		return nil, err
+	}
+	switch base := base.(type) {
+	case nil:
+		return u, nil
+	case *url.URL:
+		return base.ResolveReference(u), nil
+	}
+	return nil, fmt.Errorf("keyedarchive: %s.NS.base must be a URL, found %T", c.className, base)
+}
+
+func decodeUUID(c *Coder) (interface{}, error) {
+	bytes, ok := c.Raw("NS.uuidbytes").([]byte)
+	if !ok || len(bytes) != 16 {
+		return nil, fmt.Errorf("keyedarchive: %s.NS.uuidbytes must be 16 bytes", c.className)
+	}
+	var uuid UUID
+	copy(uuid[:], bytes)
+	return uuid, nil
+}
@@ -0,0 +1,295 @@
+package keyedarchive
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// An NSMutableDictionary archive, laid out the way NSKeyedArchiver and plutil -convert xml1 write it.
+const sampleArchive = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>$archiver</key>
+	<string>NSKeyedArchiver</string>
+	<key>$objects</key>
+	<array>
+		<string>$null</string>
+		<dict>
+			<key>$class</key>
+			<dict>
+				<key>CF$UID</key>
+				<integer>14</integer>
+			</dict>
+			<key>NS.keys</key>
+			<array>
+				<dict><key>CF$UID</key><integer>2</integer></dict>
+				<dict><key>CF$UID</key><integer>3</integer></dict>
+				<dict><key>CF$UID</key><integer>4</integer></dict>
+				<dict><key>CF$UID</key><integer>5</integer></dict>
+				<dict><key>CF$UID</key><integer>6</integer></dict>
+			</array>
+			<key>NS.objects</key>
+			<array>
+				<dict><key>CF$UID</key><integer>7</integer></dict>
+				<dict><key>CF$UID</key><integer>10</integer></dict>
+				<dict><key>CF$UID</key><integer>12</integer></dict>
+				<dict><key>CF$UID</key><integer>15</integer></dict>
+				<dict><key>CF$UID</key><integer>17</integer></dict>
+			</array>
+		</dict>
+		<string>tags</string>
+		<string>created</string>
+		<string>homepage</string>
+		<string>id</string>
+		<string>color</string>
+		<dict>
+			<key>$class</key>
+			<dict><key>CF$UID</key><integer>9</integer></dict>
+			<key>NS.objects</key>
+			<array>
+				<dict><key>CF$UID</key><integer>8</integer></dict>
+				<integer>42</integer>
+				<dict><key>CF$UID</key><integer>0</integer></dict>
+				<dict><key>CF$UID</key><integer>8</integer></dict>
+			</array>
+		</dict>
+		<string>a</string>
+		<dict>
+			<key>$classes</key>
+			<array>
+				<string>NSMutableArray</string>
+				<string>NSArray</string>
+				<string>NSObject</string>
+			</array>
+			<key>$classname</key>
+			<string>NSMutableArray</string>
+		</dict>
+		<dict>
+			<key>$class</key>
+			<dict><key>CF$UID</key><integer>11</integer></dict>
+			<key>NS.time</key>
+			<real>600000000.5</real>
+		</dict>
+		<dict>
+			<key>$classes</key>
+			<array>
+				<string>NSDate</string>
+				<string>NSObject</string>
+			</array>
+			<key>$classname</key>
+			<string>NSDate</string>
+		</dict>
+		<dict>
+			<key>$class</key>
+			<dict><key>CF$UID</key><integer>16</integer></dict>
+			<key>NS.base</key>
+			<dict><key>CF$UID</key><integer>18</integer></dict>
+			<key>NS.relative</key>
+			<dict><key>CF$UID</key><integer>13</integer></dict>
+		</dict>
+		<string>docs/index.html</string>
+		<dict>
+			<key>$classes</key>
+			<array>
+				<string>NSMutableDictionary</string>
+				<string>NSDictionary</string>
+				<string>NSObject</string>
+			</array>
+			<key>$classname</key>
+			<string>NSMutableDictionary</string>
+		</dict>
+		<dict>
+			<key>$class</key>
+			<dict><key>CF$UID</key><integer>19</integer></dict>
+			<key>NS.uuidbytes</key>
+			<data>AAECAwQFBgcICQoLDA0ODw==</data>
+		</dict>
+		<dict>
+			<key>$classes</key>
+			<array>
+				<string>NSURL</string>
+				<string>NSObject</string>
+			</array>
+			<key>$classname</key>
+			<string>NSURL</string>
+		</dict>
+		<dict>
+			<key>$class</key>
+			<dict><key>CF$UID</key><integer>20</integer></dict>
+			<key>NSColorSpace</key>
+			<integer>1</integer>
+			<key>NSRGB</key>
+			<data>MSAwIDAA</data>
+		</dict>
+		<dict>
+			<key>$class</key>
+			<dict><key>CF$UID</key><integer>16</integer></dict>
+			<key>NS.base</key>
+			<dict><key>CF$UID</key><integer>0</integer></dict>
+			<key>NS.relative</key>
+			<dict><key>CF$UID</key><integer>21</integer></dict>
+		</dict>
+		<dict>
+			<key>$classes</key>
+			<array>
+				<string>NSUUID</string>
+				<string>NSObject</string>
+			</array>
+			<key>$classname</key>
+			<string>NSUUID</string>
+		</dict>
+		<dict>
+			<key>$classes</key>
+			<array>
+				<string>NSColor</string>
+				<string>NSObject</string>
+			</array>
+			<key>$classname</key>
+			<string>NSColor</string>
+		</dict>
+		<string>https://example.com/</string>
+	</array>
+	<key>$top</key>
+	<dict>
+		<key>root</key>
+		<dict>
+			<key>CF$UID</key>
+			<integer>1</integer>
+		</dict>
+	</dict>
+	<key>$version</key>
+	<integer>100000</integer>
+</dict>
+</plist>`
+
+func TestDecode(t *testing.T) {
+	root, err := Decode(strings.NewReader(sampleArchive))
+	assert.NoError(t, err)
+
+	dict, ok := root.(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{"a", int64(42), nil, "a"}, dict["tags"])
+	assert.Equal(t, time.Date(2020, 1, 6, 10, 40, 0, 500000000, time.UTC), dict["created"])
+	assert.Equal(t, "https://example.com/docs/index.html", dict["homepage"].(*url.URL).String())
+	assert.Equal(t, UUID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, dict["id"])
+	assert.Equal(t, "00010203-0405-0607-0809-0A0B0C0D0E0F", dict["id"].(UUID).String())
+	assert.Equal(t, &Object{
+		ClassName: "NSColor",
+		Classes:   []string{"NSColor", "NSObject"},
+		Fields: map[string]interface{}{
+			"NSColorSpace": int64(1),
+			"NSRGB":        []byte("1 0 0\x00"),
+		},
+	}, dict["color"])
+}
+
+type color struct {
+	r, g, b string
+}
+
+func TestRegister(t *testing.T) {
+	u := NewUnarchiver()
+	u.Register("NSColor", func(c *Coder) (interface{}, error) {
+		assert.Equal(t, []string{"NSColorSpace", "NSRGB"}, c.Keys())
+		assert.True(t, c.Contains("NSRGB"))
+		components := strings.Fields(strings.TrimRight(string(c.Raw("NSRGB").([]byte)), "\x00"))
+		return color{components[0], components[1], components[2]}, nil
+	})
+
+	root, err := u.Decode(strings.NewReader(sampleArchive))
+	assert.NoError(t, err)
+	assert.Equal(t, color{"1", "0", "0"}, root.(map[string]interface{})["color"])
+}
+
+func TestUnarchiveBackReference(t *testing.T) {
+	uid := func(i int64) map[string]interface{} {
+		return map[string]interface{}{"CF$UID": i}
+	}
+	// A view whose subview refers back to it as its superview.
+	archive := map[string]interface{}{
+		"$archiver": "NSKeyedArchiver",
+		"$top":      map[string]interface{}{"root": uid(1)},
+		"$objects": []interface{}{
+			"$null",
+			map[string]interface{}{"$class": uid(2), "subviews": []interface{}{uid(3)}},
+			map[string]interface{}{"$classname": "NSView"},
+			map[string]interface{}{"$class": uid(2), "superview": uid(1)},
+		},
+	}
+
+	root, err := NewUnarchiver().Unarchive(archive)
+	assert.NoError(t, err)
+	view := root.(*Object)
+	subview := view.Fields["subviews"].([]interface{})[0].(*Object)
+	assert.True(t, subview.Fields["superview"] == view)
+}
+
+func TestUnarchiveUnhashableKey(t *testing.T) {
+	// A dictionary whose key is an integer too wide for xml.Int128, which
+	// decodes to a big.Int.
+	const archive = `<plist version="1.0">
+<dict>
+	<key>$archiver</key>
+	<string>NSKeyedArchiver</string>
+	<key>$top</key>
+	<dict><key>root</key><dict><key>CF$UID</key><integer>1</integer></dict></dict>
+	<key>$objects</key>
+	<array>
+		<string>$null</string>
+		<dict>
+			<key>$class</key>
+			<dict><key>CF$UID</key><integer>3</integer></dict>
+			<key>NS.keys</key>
+			<array><dict><key>CF$UID</key><integer>2</integer></dict></array>
+			<key>NS.objects</key>
+			<array><dict><key>CF$UID</key><integer>2</integer></dict></array>
+		</dict>
+		<integer>1606938044258990275541962092341162602522202993782792835301376</integer>
+		<dict><key>$classname</key><string>NSDictionary</string></dict>
+	</array>
+</dict>
+</plist>`
+
+	_, err := Decode(strings.NewReader(archive))
+	assert.EqualError(t, err, "keyedarchive: NSDictionary key of type big.Int can't be used as a Go map key")
+}
+
+func TestUnarchiveInvalid(t *testing.T) {
+	uid := func(i int64) map[string]interface{} {
+		return map[string]interface{}{"CF$UID": i}
+	}
+	archive := func(objects ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"$archiver": "NSKeyedArchiver",
+			"$top":      map[string]interface{}{"root": uid(1)},
+			"$objects":  append([]interface{}{"$null"}, objects...),
+		}
+	}
+	class := map[string]interface{}{"$classname": "NSArray"}
+
+	for _, test := range []struct {
+		archive  interface{}
+		expected string
+	}{
+		{"archive", "keyedarchive: archive must be a dict, found string"},
+		{map[string]interface{}{"$archiver": "NSArchiver"}, `keyedarchive: unsupported $archiver "NSArchiver"`},
+		{archive(), "keyedarchive: reference to object 1 out of range"},
+		{archive(map[string]interface{}{}), "keyedarchive: object has no $class"},
+		{
+			archive(map[string]interface{}{"$class": uid(2), "NS.objects": []interface{}{uid(1)}}, class),
+			"keyedarchive: cyclic reference to object 1",
+		},
+		{
+			archive(map[string]interface{}{"$class": uid(2), "NS.objects": "a"}, class),
+			"keyedarchive: NSArray.NS.objects must be an array, found string",
+		},
+	} {
+		_, err := NewUnarchiver().Unarchive(test.archive)
+		assert.EqualError(t, err, test.expected)
+	}
+}
@@ -0,0 +1,304 @@
+/*
+Package launchd writes launchd job definitions, the plists installed in
+LaunchAgents and LaunchDaemons directories.
+
+The keys are documented in launchd.plist(5). Zero values are omitted, so only
+the keys that are set are written. Call Validate before installing a job to
+catch the mistakes launchctl would reject it for.
+*/
+package launchd
+
+import (
+	"io"
+
+	"github.com/zach-klippenstein/goplist/internal/plistutil"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Job is a launchd job definition.
+type Job struct {
+	Label    string
+	Disabled bool
+
+	UserName   string
+	GroupName  string
+	InitGroups bool
+
+	Program          string
+	ProgramArguments []string
+	EnableGlobbing   bool
+
+	WorkingDirectory     string
+	RootDirectory        string
+	EnvironmentVariables map[string]string
+	// Umask is the file mode creation mask, or nil to inherit launchd's.
+	Umask *int
+
+	RunAtLoad             bool
+	KeepAlive             *KeepAlive
+	StartInterval         int
+	StartCalendarInterval []CalendarInterval
+	StartOnMount          bool
+	WatchPaths            []string
+	QueueDirectories      []string
+
+	StandardInPath    string
+	StandardOutPath   string
+	StandardErrorPath string
+
+	ThrottleInterval    int
+	ExitTimeOut         int
+	TimeOut             int
+	AbandonProcessGroup bool
+	ProcessType         string
+	Nice                int
+	LowPriorityIO       bool
+
+	LimitLoadToSessionType []string
+	LimitLoadToHosts       []string
+	LimitLoadFromHosts     []string
+
+	// MachServices maps Mach service names to whether they should be reset
+	// (ResetAtClose) when the job exits.
+	MachServices map[string]bool
+	// Sockets maps socket names, passed to launch_activate_socket, to the
+	// sockets launchd listens on for the job.
+	Sockets map[string][]Socket
+}
+
+/*
+KeepAlive controls whether launchd restarts a job when it exits.
+
+If Always is true, the job is kept running unconditionally and the other
+fields must be empty. Otherwise the job is kept alive while any of the
+conditions hold. SuccessfulExit, Crashed and NetworkState are only written if
+they're not nil.
+*/
+type KeepAlive struct {
+	Always bool
+
+	SuccessfulExit *bool
+	Crashed        *bool
+	NetworkState   *bool
+	// PathState maps paths to whether the job should run while they exist.
+	PathState map[string]bool
+	// OtherJobEnabled maps labels to whether the job should run while they're loaded.
+	OtherJobEnabled map[string]bool
+	// AfterInitialDemand maps labels to whether the job should only be kept
+	// alive after the other job has been started.
+	AfterInitialDemand map[string]bool
+}
+
+func (k *KeepAlive) hasConditions() bool {
+	return k.SuccessfulExit != nil || k.Crashed != nil || k.NetworkState != nil ||
+		len(k.PathState) > 0 || len(k.OtherJobEnabled) > 0 || len(k.AfterInitialDemand) > 0
+}
+
+// CalendarInterval is an entry of StartCalendarInterval. Nil fields are
+// wildcards. Weekday 0 and 7 are both Sunday.
+type CalendarInterval struct {
+	Minute  *int
+	Hour    *int
+	Day     *int
+	Weekday *int
+	Month   *int
+}
+
+// Socket is a socket launchd listens on for a job.
+type Socket struct {
+	// SockType is "stream", "dgram" or "seqpacket". launchd defaults to "stream".
+	SockType string
+	// SockPassive is whether to listen, or nil for launchd's default of true.
+	SockPassive *bool
+
+	SockNodeName    string
+	SockServiceName string
+	// SockFamily is "IPv4", "IPv6", "IPv4v6" or "Unix".
+	SockFamily string
+	// SockProtocol is "TCP" or "UDP".
+	SockProtocol string
+
+	SockPathName  string
+	SockPathOwner *int
+	SockPathGroup *int
+	SockPathMode  *int
+
+	SecureSocketWithKey string
+	Bonjour             bool
+}
+
+// Bool returns a pointer to b, for the optional fields of KeepAlive and Socket.
+func Bool(b bool) *bool {
+	return &b
+}
+
+// Int returns a pointer to i, for the optional fields of Job, CalendarInterval and Socket.
+func Int(i int) *int {
+	return &i
+}
+
+// Encode writes the job as an XML plist to w.
+func (j *Job) Encode(w io.Writer) error {
+	return xml.EncodeDictPlist(w, j.EncodeDict)
+}
+
+// EncodeDict writes the job's keys to e, e.g. to embed the job in another plist.
+func (j *Job) EncodeDict(e *xml.DictEncoder) error {
+	w := plistutil.NewDictWriter(e)
+
+	w.String("Label", j.Label)
+	w.Bool("Disabled", j.Disabled)
+	w.String("UserName", j.UserName)
+	w.String("GroupName", j.GroupName)
+	w.Bool("InitGroups", j.InitGroups)
+
+	w.String("Program", j.Program)
+	w.Strings("ProgramArguments", j.ProgramArguments)
+	w.Bool("EnableGlobbing", j.EnableGlobbing)
+
+	w.String("WorkingDirectory", j.WorkingDirectory)
+	w.String("RootDirectory", j.RootDirectory)
+	if len(j.EnvironmentVariables) > 0 {
+		w.Dict("EnvironmentVariables", func(e *xml.DictEncoder) error {
+			for _, key := range plistutil.SortedKeys(j.EnvironmentVariables) {
+				if err := e.WriteString(key, j.EnvironmentVariables[key]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	w.IntPtr("Umask", j.Umask)
+
+	w.Bool("RunAtLoad", j.RunAtLoad)
+	if j.KeepAlive != nil {
+		if j.KeepAlive.hasConditions() {
+			w.Dict("KeepAlive", j.KeepAlive.encode)
+		} else {
+			w.ForceBool("KeepAlive", j.KeepAlive.Always)
+		}
+	}
+	w.Int("StartInterval", j.StartInterval)
+	switch len(j.StartCalendarInterval) {
+	case 0:
+	case 1:
+		w.Dict("StartCalendarInterval", j.StartCalendarInterval[0].encode)
+	default:
+		w.Array("StartCalendarInterval", func(e *xml.ArrayEncoder) error {
+			for _, interval := range j.StartCalendarInterval {
+				if err := e.WriteDict(interval.encode); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	w.Bool("StartOnMount", j.StartOnMount)
+	w.Strings("WatchPaths", j.WatchPaths)
+	w.Strings("QueueDirectories", j.QueueDirectories)
+
+	w.String("StandardInPath", j.StandardInPath)
+	w.String("StandardOutPath", j.StandardOutPath)
+	w.String("StandardErrorPath", j.StandardErrorPath)
+
+	w.Int("ThrottleInterval", j.ThrottleInterval)
+	w.Int("ExitTimeOut", j.ExitTimeOut)
+	w.Int("TimeOut", j.TimeOut)
+	w.Bool("AbandonProcessGroup", j.AbandonProcessGroup)
+	w.String("ProcessType", j.ProcessType)
+	w.Int("Nice", j.Nice)
+	w.Bool("LowPriorityIO", j.LowPriorityIO)
+
+	if len(j.LimitLoadToSessionType) == 1 {
+		w.String("LimitLoadToSessionType", j.LimitLoadToSessionType[0])
+	} else {
+		w.Strings("LimitLoadToSessionType", j.LimitLoadToSessionType)
+	}
+	w.Strings("LimitLoadToHosts", j.LimitLoadToHosts)
+	w.Strings("LimitLoadFromHosts", j.LimitLoadFromHosts)
+
+	if len(j.MachServices) > 0 {
+		w.Dict("MachServices", func(e *xml.DictEncoder) error {
+			for _, name := range plistutil.SortedKeys(j.MachServices) {
+				if !j.MachServices[name] {
+					if err := e.WriteBool(name, true); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := e.WriteDict(name, func(e *xml.DictEncoder) error {
+					return e.WriteBool("ResetAtClose", true)
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if len(j.Sockets) > 0 {
+		w.Dict("Sockets", j.encodeSockets)
+	}
+
+	return w.Err()
+}
+
+func (j *Job) encodeSockets(e *xml.DictEncoder) error {
+	for _, name := range plistutil.SortedKeys(j.Sockets) {
+		sockets := j.Sockets[name]
+		var err error
+		if len(sockets) == 1 {
+			err = e.WriteDict(name, sockets[0].encode)
+		} else {
+			err = e.WriteArray(name, func(e *xml.ArrayEncoder) error {
+				for _, socket := range sockets {
+					if err := e.WriteDict(socket.encode); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KeepAlive) encode(e *xml.DictEncoder) error {
+	w := plistutil.NewDictWriter(e)
+	w.BoolPtr("SuccessfulExit", k.SuccessfulExit)
+	w.BoolPtr("Crashed", k.Crashed)
+	w.BoolPtr("NetworkState", k.NetworkState)
+	w.BoolMap("PathState", k.PathState)
+	w.BoolMap("OtherJobEnabled", k.OtherJobEnabled)
+	w.BoolMap("AfterInitialDemand", k.AfterInitialDemand)
+	return w.Err()
+}
+
+func (c CalendarInterval) encode(e *xml.DictEncoder) error {
+	w := plistutil.NewDictWriter(e)
+	w.IntPtr("Minute", c.Minute)
+	w.IntPtr("Hour", c.Hour)
+	w.IntPtr("Day", c.Day)
+	w.IntPtr("Weekday", c.Weekday)
+	w.IntPtr("Month", c.Month)
+	return w.Err()
+}
+
+func (s Socket) encode(e *xml.DictEncoder) error {
+	w := plistutil.NewDictWriter(e)
+	w.String("SockType", s.SockType)
+	w.BoolPtr("SockPassive", s.SockPassive)
+	w.String("SockNodeName", s.SockNodeName)
+	w.String("SockServiceName", s.SockServiceName)
+	w.String("SockFamily", s.SockFamily)
+	w.String("SockProtocol", s.SockProtocol)
+	w.String("SockPathName", s.SockPathName)
+	w.IntPtr("SockPathOwner", s.SockPathOwner)
+	w.IntPtr("SockPathGroup", s.SockPathGroup)
+	w.IntPtr("SockPathMode", s.SockPathMode)
+	w.String("SecureSocketWithKey", s.SecureSocketWithKey)
+	w.Bool("Bonjour", s.Bonjour)
+	return w.Err()
+}
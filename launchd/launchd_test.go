@@ -0,0 +1,139 @@
+package launchd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const plistHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+`
+
+func TestEncode(t *testing.T) {
+	job := Job{
+		Label:                "com.example.agent",
+		ProgramArguments:     []string{"/usr/local/bin/agent", "--verbose"},
+		EnvironmentVariables: map[string]string{"PATH": "/usr/bin:/bin", "HOME": "/var/agent"},
+		RunAtLoad:            true,
+		KeepAlive:            &KeepAlive{Always: true},
+		StartCalendarInterval: []CalendarInterval{
+			{Hour: Int(3), Minute: Int(0)},
+		},
+		StandardOutPath:        "/var/log/agent.log",
+		LimitLoadToSessionType: []string{"Aqua"},
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, job.Encode(&buffer))
+	assert.Equal(t, plistHeader+`	<dict>
+		<key>Label</key>
+		<string>com.example.agent</string>
+		<key>ProgramArguments</key>
+		<array>
+			<string>/usr/local/bin/agent</string>
+			<string>--verbose</string>
+		</array>
+		<key>EnvironmentVariables</key>
+		<dict>
+			<key>HOME</key>
+			<string>/var/agent</string>
+			<key>PATH</key>
+			<string>/usr/bin:/bin</string>
+		</dict>
+		<key>RunAtLoad</key>
+		<true></true>
+		<key>KeepAlive</key>
+		<true></true>
+		<key>StartCalendarInterval</key>
+		<dict>
+			<key>Minute</key>
+			<integer>0</integer>
+			<key>Hour</key>
+			<integer>3</integer>
+		</dict>
+		<key>StandardOutPath</key>
+		<string>/var/log/agent.log</string>
+		<key>LimitLoadToSessionType</key>
+		<string>Aqua</string>
+	</dict>
+</plist>`, buffer.String())
+}
+
+func TestEncodeConditionsAndSockets(t *testing.T) {
+	job := Job{
+		Label:   "com.example.daemon",
+		Program: "/usr/local/libexec/daemon",
+		KeepAlive: &KeepAlive{
+			SuccessfulExit: Bool(false),
+			PathState:      map[string]bool{"/etc/daemon.conf": true},
+		},
+		StartCalendarInterval: []CalendarInterval{
+			{Weekday: Int(1)},
+			{Weekday: Int(5)},
+		},
+		MachServices: map[string]bool{"com.example.daemon.xpc": false},
+		Sockets: map[string][]Socket{
+			"Listeners": {
+				{SockServiceName: "8080", SockFamily: "IPv4"},
+				{SockPathName: "/var/run/daemon.sock", SockPathMode: Int(0600)},
+			},
+		},
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, job.Encode(&buffer))
+	assert.Equal(t, plistHeader+`	<dict>
+		<key>Label</key>
+		<string>com.example.daemon</string>
+		<key>Program</key>
+		<string>/usr/local/libexec/daemon</string>
+		<key>KeepAlive</key>
+		<dict>
+			<key>SuccessfulExit</key>
+			<false></false>
+			<key>PathState</key>
+			<dict>
+				<key>/etc/daemon.conf</key>
+				<true></true>
+			</dict>
+		</dict>
+		<key>StartCalendarInterval</key>
+		<array>
+			<dict>
+				<key>Weekday</key>
+				<integer>1</integer>
+			</dict>
+			<dict>
+				<key>Weekday</key>
+				<integer>5</integer>
+			</dict>
+		</array>
+		<key>MachServices</key>
+		<dict>
+			<key>com.example.daemon.xpc</key>
+			<true></true>
+		</dict>
+		<key>Sockets</key>
+		<dict>
+			<key>Listeners</key>
+			<array>
+				<dict>
+					<key>SockServiceName</key>
+					<string>8080</string>
+					<key>SockFamily</key>
+					<string>IPv4</string>
+				</dict>
+				<dict>
+					<key>SockPathName</key>
+					<string>/var/run/daemon.sock</string>
+					<key>SockPathMode</key>
+					<integer>384</integer>
+				</dict>
+			</array>
+		</dict>
+	</dict>
+</plist>`, buffer.String())
+}
@@ -0,0 +1,119 @@
+package launchd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/zach-klippenstein/goplist/internal/plistutil"
+)
+
+// ValidationError describes a problem with a job definition.
+type ValidationError struct {
+	// Key is the path to the offending key, e.g. "Sockets.Listeners[1].SockFamily".
+	Key string
+	Msg string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Key == "" {
+		return "launchd: " + e.Msg
+	}
+	return fmt.Sprintf("launchd: %s: %s", e.Key, e.Msg)
+}
+
+func newValidationError(key, msg string) error {
+	return &ValidationError{key, msg}
+}
+
+var (
+	processTypes    = []string{"Background", "Standard", "Adaptive", "Interactive"}
+	sessionTypes    = []string{"Aqua", "Background", "LoginWindow", "StandardIO", "System"}
+	socketTypes     = []string{"stream", "dgram", "seqpacket"}
+	socketFamilies  = []string{"IPv4", "IPv6", "IPv4v6", "Unix"}
+	socketProtocols = []string{"TCP", "UDP"}
+)
+
+/*
+Validate returns the problems with the job that would make launchctl refuse to
+load it, or launchd fail to start it, in the order the keys are written. It
+returns nil if the job is valid.
+*/
+func (j *Job) Validate() []error {
+	v := plistutil.NewValidator(newValidationError)
+
+	if j.Label == "" {
+		v.Errorf("Label", "is required")
+	}
+	if j.Program == "" && len(j.ProgramArguments) == 0 {
+		v.Errorf("", "one of Program or ProgramArguments is required")
+	}
+	if j.Program != "" && !path.IsAbs(j.Program) {
+		v.Errorf("Program", "must be an absolute path, found %q", j.Program)
+	}
+	if j.Program == "" && len(j.ProgramArguments) > 0 && j.ProgramArguments[0] == "" {
+		v.Errorf("ProgramArguments[0]", "must not be empty when Program is not set")
+	}
+	for _, key := range plistutil.SortedKeys(j.EnvironmentVariables) {
+		if key == "" || strings.Contains(key, "=") {
+			v.Errorf("EnvironmentVariables", "invalid variable name %q", key)
+		}
+	}
+	if j.Umask != nil && (*j.Umask < 0 || *j.Umask > 0777) {
+		v.Errorf("Umask", "must be between 0 and 0777, found %#o", *j.Umask)
+	}
+
+	if j.KeepAlive != nil && j.KeepAlive.Always && j.KeepAlive.hasConditions() {
+		v.Errorf("KeepAlive", "cannot be unconditional and have conditions")
+	}
+	v.NonNegative("StartInterval", j.StartInterval)
+	for i, interval := range j.StartCalendarInterval {
+		key := fmt.Sprintf("StartCalendarInterval[%d]", i)
+		v.InRange(key+".Minute", interval.Minute, 0, 59)
+		v.InRange(key+".Hour", interval.Hour, 0, 23)
+		v.InRange(key+".Day", interval.Day, 1, 31)
+		v.InRange(key+".Weekday", interval.Weekday, 0, 7)
+		v.InRange(key+".Month", interval.Month, 1, 12)
+	}
+
+	v.NonNegative("ThrottleInterval", j.ThrottleInterval)
+	v.NonNegative("ExitTimeOut", j.ExitTimeOut)
+	v.NonNegative("TimeOut", j.TimeOut)
+	v.OneOf("ProcessType", j.ProcessType, processTypes)
+	v.InRange("Nice", &j.Nice, -20, 20)
+	for _, sessionType := range j.LimitLoadToSessionType {
+		v.OneOf("LimitLoadToSessionType", sessionType, sessionTypes)
+	}
+
+	for _, name := range plistutil.SortedKeys(j.Sockets) {
+		for i, socket := range j.Sockets[name] {
+			key := "Sockets." + name
+			if len(j.Sockets[name]) > 1 {
+				key = fmt.Sprintf("%s[%d]", key, i)
+			}
+			socket.validate(v, key)
+		}
+	}
+
+	return v.Errs()
+}
+
+func (s Socket) validate(v *plistutil.Validator, key string) {
+	v.OneOf(key+".SockType", s.SockType, socketTypes)
+	v.OneOf(key+".SockFamily", s.SockFamily, socketFamilies)
+	v.OneOf(key+".SockProtocol", s.SockProtocol, socketProtocols)
+
+	if s.SockPathName != "" {
+		if s.SockNodeName != "" || s.SockServiceName != "" {
+			v.Errorf(key, "SockPathName cannot be combined with SockNodeName or SockServiceName")
+		}
+		if s.SockFamily != "" && s.SockFamily != "Unix" {
+			v.Errorf(key+".SockFamily", "must be Unix with SockPathName, found %q", s.SockFamily)
+		}
+	} else if s.SockFamily == "Unix" {
+		v.Errorf(key+".SockPathName", "is required for Unix sockets")
+	}
+	if s.SockPathMode != nil && (*s.SockPathMode < 0 || *s.SockPathMode > 0777) {
+		v.Errorf(key+".SockPathMode", "must be between 0 and 0777, found %#o", *s.SockPathMode)
+	}
+}
@@ -0,0 +1,75 @@
+package launchd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateValid(t *testing.T) {
+	job := Job{
+		Label:            "com.example.agent",
+		ProgramArguments: []string{"agent"},
+		KeepAlive:        &KeepAlive{NetworkState: Bool(true)},
+		StartCalendarInterval: []CalendarInterval{
+			{Minute: Int(59), Hour: Int(23), Day: Int(31), Weekday: Int(7), Month: Int(12)},
+		},
+		ProcessType: "Background",
+		Nice:        -5,
+		Sockets: map[string][]Socket{
+			"Listeners": {{SockPathName: "/var/run/agent.sock", SockFamily: "Unix"}},
+		},
+	}
+	assert.Empty(t, job.Validate())
+}
+
+func TestValidateInvalid(t *testing.T) {
+	for _, test := range []struct {
+		job      Job
+		expected []string
+	}{
+		{Job{}, []string{
+			"launchd: Label: is required",
+			"launchd: one of Program or ProgramArguments is required",
+		}},
+		{Job{Label: "a", Program: "bin/agent"}, []string{
+			`launchd: Program: must be an absolute path, found "bin/agent"`,
+		}},
+		{Job{Label: "a", ProgramArguments: []string{""}}, []string{
+			"launchd: ProgramArguments[0]: must not be empty when Program is not set",
+		}},
+		{Job{Label: "a", Program: "/a", EnvironmentVariables: map[string]string{"A=B": ""}, Umask: Int(01000)}, []string{
+			`launchd: EnvironmentVariables: invalid variable name "A=B"`,
+			"launchd: Umask: must be between 0 and 0777, found 01000",
+		}},
+		{Job{Label: "a", Program: "/a", KeepAlive: &KeepAlive{Always: true, Crashed: Bool(true)}, StartInterval: -1}, []string{
+			"launchd: KeepAlive: cannot be unconditional and have conditions",
+			"launchd: StartInterval: must not be negative, found -1",
+		}},
+		{Job{Label: "a", Program: "/a", StartCalendarInterval: []CalendarInterval{{}, {Minute: Int(60), Day: Int(0), Month: Int(13)}}}, []string{
+			"launchd: StartCalendarInterval[1].Minute: must be between 0 and 59, found 60",
+			"launchd: StartCalendarInterval[1].Day: must be between 1 and 31, found 0",
+			"launchd: StartCalendarInterval[1].Month: must be between 1 and 12, found 13",
+		}},
+		{Job{Label: "a", Program: "/a", ProcessType: "Fast", Nice: 21, LimitLoadToSessionType: []string{"GUI"}}, []string{
+			`launchd: ProcessType: must be one of Background, Standard, Adaptive, Interactive, found "Fast"`,
+			"launchd: Nice: must be between -20 and 20, found 21",
+			`launchd: LimitLoadToSessionType: must be one of Aqua, Background, LoginWindow, StandardIO, System, found "GUI"`,
+		}},
+		{Job{Label: "a", Program: "/a", Sockets: map[string][]Socket{
+			"A": {{SockType: "raw", SockFamily: "Unix"}},
+			"B": {{}, {SockPathName: "/a.sock", SockServiceName: "80", SockFamily: "IPv4"}},
+		}}, []string{
+			`launchd: Sockets.A.SockType: must be one of stream, dgram, seqpacket, found "raw"`,
+			"launchd: Sockets.A.SockPathName: is required for Unix sockets",
+			"launchd: Sockets.B[1]: SockPathName cannot be combined with SockNodeName or SockServiceName",
+			`launchd: Sockets.B[1].SockFamily: must be Unix with SockPathName, found "IPv4"`,
+		}},
+	} {
+		var messages []string
+		for _, err := range test.job.Validate() {
+			messages = append(messages, err.Error())
+		}
+		assert.Equal(t, test.expected, messages)
+	}
+}
@@ -0,0 +1,73 @@
+package mdm
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Command is a command for a device to run.
+type Command struct {
+	// CommandUUID identifies the command in the device's Response.
+	CommandUUID string
+	RequestType string
+	// Params are the command's keys other than RequestType, with any of the
+	// types xml.DictEncoder.WriteValue accepts.
+	Params map[string]interface{}
+}
+
+// NewCommand returns a command with a random CommandUUID.
+func NewCommand(requestType string, params map[string]interface{}) (*Command, error) {
+	uuid, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	return &Command{CommandUUID: uuid, RequestType: requestType, Params: params}, nil
+}
+
+// Encode writes the command as an XML plist to w.
+func (c *Command) Encode(w io.Writer) error {
+	if c.CommandUUID == "" || c.RequestType == "" {
+		return fmt.Errorf("mdm: command must have a CommandUUID and a RequestType")
+	}
+	if _, ok := c.Params["RequestType"]; ok {
+		return fmt.Errorf("mdm: RequestType must not be in Params")
+	}
+
+	keys := make([]string, 0, len(c.Params))
+	for key := range c.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return xml.EncodeDictPlist(w, func(e *xml.DictEncoder) error {
+		if err := e.WriteDict("Command", func(e *xml.DictEncoder) error {
+			if err := e.WriteString("RequestType", c.RequestType); err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if err := e.WriteValue(key, c.Params[key]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		return e.WriteString("CommandUUID", c.CommandUUID)
+	})
+}
+
+// newUUID returns a random (version 4) UUID, in upper case like Apple's tools write them.
+func newUUID() (string, error) {
+	var uuid [16]byte
+	if _, err := io.ReadFull(rand.Reader, uuid[:]); err != nil {
+		return "", err
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16]), nil
+}
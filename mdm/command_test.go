@@ -0,0 +1,62 @@
+package mdm
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+func TestCommandEncode(t *testing.T) {
+	command := &Command{
+		CommandUUID: "0001",
+		RequestType: "DeviceInformation",
+		Params:      map[string]interface{}{"Queries": []string{"DeviceName", "OSVersion"}},
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, command.Encode(&b))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+	<dict>
+		<key>Command</key>
+		<dict>
+			<key>RequestType</key>
+			<string>DeviceInformation</string>
+			<key>Queries</key>
+			<array>
+				<string>DeviceName</string>
+				<string>OSVersion</string>
+			</array>
+		</dict>
+		<key>CommandUUID</key>
+		<string>0001</string>
+	</dict>
+</plist>`, b.String())
+}
+
+func TestNewCommand(t *testing.T) {
+	command, err := NewCommand("RestartDevice", nil)
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9A-F]{8}-[0-9A-F]{4}-4[0-9A-F]{3}-[89AB][0-9A-F]{3}-[0-9A-F]{12}$`), command.CommandUUID)
+
+	var b bytes.Buffer
+	assert.NoError(t, command.Encode(&b))
+	value, err := xml.NewDecoder(&b).DecodeValue()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"CommandUUID": command.CommandUUID,
+		"Command":     map[string]interface{}{"RequestType": "RestartDevice"},
+	}, value)
+}
+
+func TestCommandEncodeErrors(t *testing.T) {
+	var b bytes.Buffer
+	assert.EqualError(t, (&Command{RequestType: "RestartDevice"}).Encode(&b),
+		"mdm: command must have a CommandUUID and a RequestType")
+	assert.EqualError(t, (&Command{CommandUUID: "1", RequestType: "A", Params: map[string]interface{}{"RequestType": "B"}}).Encode(&b),
+		"mdm: RequestType must not be in Params")
+}
@@ -0,0 +1,95 @@
+package mdm
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+)
+
+/*
+Handler is an http.Handler for the URLs devices check in at and poll for
+commands, which may be the same URL. Check-in messages are told apart from
+command responses by their MessageType key.
+
+Devices send each message as the body of a PUT request. If a function returns
+an error, the request fails with 500 Internal Server Error, which for
+Authenticate and TokenUpdate makes the device give up enrolling.
+*/
+type Handler struct {
+	// Checkin is called with each check-in message.
+	Checkin func(r *http.Request, message *CheckinMessage) error
+	// Command is called with each command response, and returns the next
+	// command for the device, or nil if it has none.
+	Command func(r *http.Request, response *Response) (*Command, error)
+	// ErrorLog logs the errors returned by Checkin and Command, or is nil to
+	// use the log package's standard logger.
+	ErrorLog *log.Logger
+}
+
+var _ http.Handler = &Handler{}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		w.Header().Set("Allow", "PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	value, err := decodeMessage(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := value["MessageType"]; ok {
+		message, err := unmarshalCheckin(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if h.Checkin == nil {
+			http.Error(w, "check-in not supported", http.StatusNotFound)
+			return
+		}
+		if err := h.Checkin(r, message); err != nil {
+			h.fail(w, err)
+		}
+		return
+	}
+
+	response, err := unmarshalResponse(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if h.Command == nil {
+		http.Error(w, "commands not supported", http.StatusNotFound)
+		return
+	}
+	command, err := h.Command(r, response)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	if command == nil {
+		return
+	}
+
+	// Encode the command before writing anything, so an error can still be reported.
+	var b bytes.Buffer
+	if err := command.Encode(&b); err != nil {
+		h.fail(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(b.Bytes())
+}
+
+func (h *Handler) fail(w http.ResponseWriter, err error) {
+	if h.ErrorLog != nil {
+		h.ErrorLog.Printf("mdm: %v", err)
+	} else {
+		log.Printf("mdm: %v", err)
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
@@ -0,0 +1,98 @@
+package mdm
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func put(h http.Handler, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", "/mdm", strings.NewReader(body))
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerCheckin(t *testing.T) {
+	var messages []*CheckinMessage
+	h := &Handler{
+		Checkin: func(r *http.Request, message *CheckinMessage) error {
+			messages = append(messages, message)
+			if message.MessageType == TokenUpdate {
+				return errors.New("unknown device")
+			}
+			return nil
+		},
+		ErrorLog: log.New(ioutil.Discard, "", 0),
+	}
+
+	w := put(h, authenticate)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = put(h, tokenUpdate)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "C02ABC", messages[0].SerialNumber)
+}
+
+func TestHandlerCommand(t *testing.T) {
+	var statuses []string
+	queue := []*Command{{CommandUUID: "1", RequestType: "ProfileList"}}
+	h := &Handler{
+		Command: func(r *http.Request, response *Response) (*Command, error) {
+			statuses = append(statuses, response.Status)
+			if len(queue) == 0 {
+				return nil, nil
+			}
+			command := queue[0]
+			queue = queue[1:]
+			return command, nil
+		},
+	}
+
+	w := put(h, `<plist><dict><key>Status</key><string>Idle</string></dict></plist>`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<string>ProfileList</string>")
+
+	w = put(h, `<plist><dict><key>Status</key><string>Acknowledged</string><key>CommandUUID</key><string>1</string></dict></plist>`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	assert.Equal(t, []string{Idle, Acknowledged}, statuses)
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h := &Handler{ErrorLog: log.New(ioutil.Discard, "", 0)}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/mdm", nil)
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "PUT", w.Header().Get("Allow"))
+
+	for _, test := range []struct {
+		body string
+		code int
+	}{
+		{"not a plist", http.StatusBadRequest},
+		{`<plist><dict><key>UDID</key><string>1</string></dict></plist>`, http.StatusBadRequest},
+		{authenticate, http.StatusNotFound},
+		{errorResponse, http.StatusNotFound},
+	} {
+		assert.Equal(t, test.code, put(h, test.body).Code, test.body)
+	}
+
+	h.Command = func(*http.Request, *Response) (*Command, error) {
+		return &Command{}, nil
+	}
+	assert.Equal(t, http.StatusInternalServerError, put(h, errorResponse).Code)
+}
@@ -0,0 +1,155 @@
+/*
+Package mdm implements the plist messages of Apple's Mobile Device Management
+protocol, and an http.Handler for the server side of it.
+
+Devices send check-in messages (Authenticate, TokenUpdate, CheckOut) when they
+enroll, renew their push token and unenroll. They then poll the server, sending
+the result of the last command in a Response, and the server replies with the
+next Command to run, or nothing when there are none left.
+*/
+package mdm
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Check-in message types.
+const (
+	Authenticate = "Authenticate"
+	TokenUpdate  = "TokenUpdate"
+	CheckOut     = "CheckOut"
+)
+
+// Command statuses reported in Response.Status.
+const (
+	// Acknowledged means the command succeeded.
+	Acknowledged = "Acknowledged"
+	// Error means the command failed. Response.ErrorChain says why.
+	Error = "Error"
+	// CommandFormatError means the command was malformed.
+	CommandFormatError = "CommandFormatError"
+	// Idle means the device is ready for a command, and has no result to report.
+	Idle = "Idle"
+	// NotNow means the device can't run the command now, e.g. because it is
+	// locked, and will poll again later.
+	NotNow = "NotNow"
+)
+
+// decoderOptions bounds the resources decoding a message from a device uses.
+var decoderOptions = xml.DecoderOptions{
+	MaxDepth:            32,
+	MaxContainerEntries: 10000,
+	MaxStringBytes:      1 << 20,
+	MaxDataBytes:        1 << 20,
+	MaxTotalBytes:       4 << 20,
+}
+
+// CheckinMessage is a check-in message. Which fields are set depends on MessageType.
+type CheckinMessage struct {
+	MessageType string
+	UDID        string
+	Topic       string
+
+	// Set for user channel check-ins, and for User Enrollment, where
+	// EnrollmentID is sent instead of UDID.
+	UserID        string
+	UserLongName  string
+	UserShortName string
+	EnrollmentID  string
+
+	// Set for Authenticate.
+	BuildVersion string
+	DeviceName   string
+	IMEI         string
+	MEID         string
+	Model        string
+	ModelName    string
+	OSVersion    string
+	ProductName  string
+	SerialNumber string
+
+	// Set for TokenUpdate.
+	Token                 []byte
+	PushMagic             string
+	UnlockToken           []byte
+	AwaitingConfiguration bool
+
+	Unknown map[string]interface{} `plist:",unknown"`
+}
+
+// Response is a device's report on the last command it ran, or that it is idle.
+type Response struct {
+	UDID         string
+	UserID       string
+	EnrollmentID string
+
+	Status      string
+	CommandUUID string
+	ErrorChain  []ErrorChainItem
+
+	// Unknown holds the command's results, e.g. QueryResponses for DeviceInformation.
+	Unknown map[string]interface{} `plist:",unknown"`
+}
+
+// ErrorChainItem describes one of the errors that caused a command to fail.
+type ErrorChainItem struct {
+	ErrorCode            int
+	ErrorDomain          string
+	LocalizedDescription string
+	USEnglishDescription string
+}
+
+// DecodeCheckin reads a check-in message from r.
+func DecodeCheckin(r io.Reader) (*CheckinMessage, error) {
+	value, err := decodeMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalCheckin(value)
+}
+
+func unmarshalCheckin(value map[string]interface{}) (*CheckinMessage, error) {
+	message := new(CheckinMessage)
+	if err := xml.UnmarshalValue(value, message); err != nil {
+		return nil, fmt.Errorf("mdm: %v", err)
+	}
+	if message.MessageType == "" {
+		return nil, fmt.Errorf("mdm: check-in message has no MessageType")
+	}
+	return message, nil
+}
+
+// DecodeResponse reads a command response from r.
+func DecodeResponse(r io.Reader) (*Response, error) {
+	value, err := decodeMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalResponse(value)
+}
+
+func unmarshalResponse(value map[string]interface{}) (*Response, error) {
+	response := new(Response)
+	if err := xml.UnmarshalValue(value, response); err != nil {
+		return nil, fmt.Errorf("mdm: %v", err)
+	}
+	if response.Status == "" {
+		return nil, fmt.Errorf("mdm: response has no Status")
+	}
+	return response, nil
+}
+
+func decodeMessage(r io.Reader) (map[string]interface{}, error) {
+	value, err := xml.NewDecoderWithOptions(r, decoderOptions).DecodeValue()
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mdm: message is not a dict")
+	}
+	return dict, nil
+}
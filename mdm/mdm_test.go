@@ -0,0 +1,112 @@
+package mdm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const authenticate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>MessageType</key>
+	<string>Authenticate</string>
+	<key>Topic</key>
+	<string>com.apple.mgmt.External.1234</string>
+	<key>UDID</key>
+	<string>00008030-0001</string>
+	<key>SerialNumber</key>
+	<string>C02ABC</string>
+	<key>OSVersion</key>
+	<string>17.0</string>
+	<key>ProductName</key>
+	<string>iPhone15,2</string>
+</dict>
+</plist>`
+
+const tokenUpdate = `<plist version="1.0">
+<dict>
+	<key>MessageType</key><string>TokenUpdate</string>
+	<key>UDID</key><string>00008030-0001</string>
+	<key>Token</key><data>AQID</data>
+	<key>PushMagic</key><string>magic</string>
+	<key>AwaitingConfiguration</key><true/>
+</dict>
+</plist>`
+
+const errorResponse = `<plist version="1.0">
+<dict>
+	<key>UDID</key><string>00008030-0001</string>
+	<key>Status</key><string>Error</string>
+	<key>CommandUUID</key><string>ABC</string>
+	<key>ErrorChain</key>
+	<array>
+		<dict>
+			<key>ErrorCode</key><integer>12021</integer>
+			<key>ErrorDomain</key><string>MCMDMErrorDomain</string>
+			<key>LocalizedDescription</key><string>Unknown command</string>
+		</dict>
+	</array>
+</dict>
+</plist>`
+
+func TestDecodeCheckin(t *testing.T) {
+	message, err := DecodeCheckin(strings.NewReader(authenticate))
+	assert.NoError(t, err)
+	assert.Equal(t, &CheckinMessage{
+		MessageType:  Authenticate,
+		Topic:        "com.apple.mgmt.External.1234",
+		UDID:         "00008030-0001",
+		SerialNumber: "C02ABC",
+		OSVersion:    "17.0",
+		ProductName:  "iPhone15,2",
+	}, message)
+
+	message, err = DecodeCheckin(strings.NewReader(tokenUpdate))
+	assert.NoError(t, err)
+	assert.Equal(t, &CheckinMessage{
+		MessageType:           TokenUpdate,
+		UDID:                  "00008030-0001",
+		Token:                 []byte{1, 2, 3},
+		PushMagic:             "magic",
+		AwaitingConfiguration: true,
+	}, message)
+}
+
+func TestDecodeResponse(t *testing.T) {
+	response, err := DecodeResponse(strings.NewReader(errorResponse))
+	assert.NoError(t, err)
+	assert.Equal(t, &Response{
+		UDID:        "00008030-0001",
+		Status:      Error,
+		CommandUUID: "ABC",
+		ErrorChain: []ErrorChainItem{
+			{ErrorCode: 12021, ErrorDomain: "MCMDMErrorDomain", LocalizedDescription: "Unknown command"},
+		},
+	}, response)
+
+	response, err = DecodeResponse(strings.NewReader(`<plist><dict>
+		<key>Status</key><string>Acknowledged</string>
+		<key>QueryResponses</key><dict><key>DeviceName</key><string>Phone</string></dict>
+	</dict></plist>`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"QueryResponses": map[string]interface{}{"DeviceName": "Phone"},
+	}, response.Unknown)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, err := DecodeCheckin(strings.NewReader(`<plist><array/></plist>`))
+	assert.EqualError(t, err, "mdm: message is not a dict")
+
+	_, err = DecodeCheckin(strings.NewReader(`<plist><dict/></plist>`))
+	assert.EqualError(t, err, "mdm: check-in message has no MessageType")
+
+	_, err = DecodeResponse(strings.NewReader(`<plist><dict/></plist>`))
+	assert.EqualError(t, err, "mdm: response has no Status")
+
+	_, err = DecodeResponse(strings.NewReader(`<plist><dict><key>Status</key><true/></dict></plist>`))
+	assert.EqualError(t, err, "mdm: cannot unmarshal bool into Go value of type string at Status")
+}
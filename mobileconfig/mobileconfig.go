@@ -0,0 +1,215 @@
+/*
+Package mobileconfig builds configuration profiles (.mobileconfig files), as
+installed manually or pushed by an MDM server.
+
+A Profile holds a list of payloads, each of which configures one thing, e.g. a
+Wi-Fi network or a trusted certificate. Payloads that aren't covered by the
+types in this package can be written with Generic.
+
+Encode fills in any missing PayloadUUIDs and PayloadIdentifiers, checks the
+required keys are set, and writes the profile. EncodeSigned also wraps it in a
+CMS signature, which devices show as "Verified" if they trust the signer.
+*/
+package mobileconfig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"io"
+
+	"github.com/zach-klippenstein/goplist/internal/cms"
+	"github.com/zach-klippenstein/goplist/internal/plistutil"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// PayloadHeader holds the keys common to profiles and all payloads.
+type PayloadHeader struct {
+	// PayloadIdentifier is a reverse-DNS identifier. It's required for
+	// profiles, and generated for payloads if not set.
+	PayloadIdentifier string
+	// PayloadUUID is generated if not set.
+	PayloadUUID string
+	// PayloadVersion defaults to 1.
+	PayloadVersion      int
+	PayloadDisplayName  string
+	PayloadDescription  string
+	PayloadOrganization string
+}
+
+func (h *PayloadHeader) header() *PayloadHeader {
+	return h
+}
+
+func (h *PayloadHeader) encode(w *plistutil.DictWriter, payloadType string) {
+	w.String("PayloadDescription", h.PayloadDescription)
+	w.String("PayloadDisplayName", h.PayloadDisplayName)
+	w.String("PayloadIdentifier", h.PayloadIdentifier)
+	w.String("PayloadOrganization", h.PayloadOrganization)
+	w.String("PayloadType", payloadType)
+	w.String("PayloadUUID", h.PayloadUUID)
+	w.Int("PayloadVersion", h.PayloadVersion)
+}
+
+// fillDefaults generates a UUID and, if defaultIdentifierPrefix isn't empty, an
+// identifier, and sets the version if they're not set.
+func (h *PayloadHeader) fillDefaults(defaultIdentifierPrefix string) error {
+	if h.PayloadUUID == "" {
+		uuid, err := newUUID()
+		if err != nil {
+			return err
+		}
+		h.PayloadUUID = uuid
+	}
+	if h.PayloadIdentifier == "" && defaultIdentifierPrefix != "" {
+		h.PayloadIdentifier = defaultIdentifierPrefix + "." + h.PayloadUUID
+	}
+	if h.PayloadVersion == 0 {
+		h.PayloadVersion = 1
+	}
+	return nil
+}
+
+// Payload is one of the payload types in this package, e.g. *WiFi.
+type Payload interface {
+	// PayloadType returns the value of the PayloadType key, e.g. "com.apple.wifi.managed".
+	PayloadType() string
+
+	header() *PayloadHeader
+	encode(w *plistutil.DictWriter)
+	validate(v *plistutil.Validator, key string)
+}
+
+// Profile is a configuration profile.
+type Profile struct {
+	PayloadHeader
+	PayloadRemovalDisallowed bool
+	// PayloadScope is "System" or "User", for macOS profiles.
+	PayloadScope string
+
+	// Payloads are written as the PayloadContent array.
+	Payloads []Payload
+}
+
+/*
+Validate returns the problems with the profile that would stop a device
+installing it, in the order the keys are written. Missing PayloadUUIDs and
+payload PayloadIdentifiers aren't problems, since Encode generates them.
+*/
+func (p *Profile) Validate() []error {
+	v := plistutil.NewValidator(newValidationError)
+
+	if p.PayloadIdentifier == "" {
+		v.Errorf("PayloadIdentifier", "is required")
+	}
+	v.OneOf("PayloadScope", p.PayloadScope, []string{"System", "User"})
+
+	uuids := map[string]bool{p.PayloadUUID: true}
+	identifiers := map[string]bool{p.PayloadIdentifier: true}
+	for i, payload := range p.Payloads {
+		key := fmt.Sprintf("PayloadContent[%d]", i)
+		if payload == nil {
+			v.Errorf(key, "is nil")
+			continue
+		}
+
+		h := payload.header()
+		if h.PayloadUUID != "" {
+			if uuids[h.PayloadUUID] {
+				v.Errorf(key+".PayloadUUID", "duplicate UUID %q", h.PayloadUUID)
+			}
+			uuids[h.PayloadUUID] = true
+		}
+		if h.PayloadIdentifier != "" {
+			if identifiers[h.PayloadIdentifier] {
+				v.Errorf(key+".PayloadIdentifier", "duplicate identifier %q", h.PayloadIdentifier)
+			}
+			identifiers[h.PayloadIdentifier] = true
+		}
+
+		payload.validate(v, key)
+	}
+
+	return v.Errs()
+}
+
+/*
+Encode fills in the profile's missing PayloadUUIDs, payload PayloadIdentifiers
+and PayloadVersions, then validates it and writes it as an XML plist to w.
+If the profile is invalid, the first problem Validate found is returned.
+*/
+func (p *Profile) Encode(w io.Writer) error {
+	if err := p.fillDefaults(""); err != nil {
+		return err
+	}
+	for _, payload := range p.Payloads {
+		if payload == nil {
+			continue
+		}
+		if err := payload.header().fillDefaults(payload.PayloadType()); err != nil {
+			return err
+		}
+	}
+
+	if errs := p.Validate(); len(errs) > 0 {
+		return errs[0]
+	}
+
+	return xml.EncodeDictPlist(w, func(e *xml.DictEncoder) error {
+		w := plistutil.NewDictWriter(e)
+		w.Array("PayloadContent", func(e *xml.ArrayEncoder) error {
+			for _, payload := range p.Payloads {
+				if err := e.WriteDict(func(e *xml.DictEncoder) error {
+					w := plistutil.NewDictWriter(e)
+					payload.encode(w)
+					payload.header().encode(w, payload.PayloadType())
+					return w.Err()
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		w.Bool("PayloadRemovalDisallowed", p.PayloadRemovalDisallowed)
+		w.String("PayloadScope", p.PayloadScope)
+		p.PayloadHeader.encode(w, "Configuration")
+		return w.Err()
+	})
+}
+
+/*
+EncodeSigned encodes the profile like Encode, and writes it to w wrapped in a
+CMS signature made with key, which must be the private key for cert.
+intermediates are included so devices can build a chain to a trusted root.
+*/
+func (p *Profile) EncodeSigned(w io.Writer, cert *x509.Certificate, key crypto.Signer, intermediates []*x509.Certificate) error {
+	var profile bytes.Buffer
+	if err := p.Encode(&profile); err != nil {
+		return err
+	}
+
+	signed, err := cms.Sign(profile.Bytes(), cert, key, intermediates)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(signed)
+	return err
+}
+
+// newUUID returns a random (version 4) UUID, in upper case like Apple's tools write them.
+func newUUID() (string, error) {
+	var uuid [16]byte
+	if _, err := io.ReadFull(rand.Reader, uuid[:]); err != nil {
+		return "", err
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16]), nil
+}
+
+// Bool returns a pointer to b, for optional payload fields.
+func Bool(b bool) *bool {
+	return &b
+}
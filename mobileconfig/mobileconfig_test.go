@@ -0,0 +1,171 @@
+package mobileconfig
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/internal/cms"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+func TestEncode(t *testing.T) {
+	profile := Profile{
+		PayloadHeader: PayloadHeader{
+			PayloadIdentifier:  "com.example.profile",
+			PayloadUUID:        "2F6D3C4E-6C5B-4A8E-9F1D-3B2A1C0D9E8F",
+			PayloadDisplayName: "Example",
+		},
+		Payloads: []Payload{
+			&WiFi{
+				PayloadHeader: PayloadHeader{
+					PayloadIdentifier: "com.example.profile.wifi",
+					PayloadUUID:       "0A1B2C3D-4E5F-4071-8293-A4B5C6D7E8F9",
+				},
+				SSID:           "Example",
+				EncryptionType: "WPA2",
+				Password:       "hunter2",
+			},
+		},
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, profile.Encode(&buffer))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+	<dict>
+		<key>PayloadContent</key>
+		<array>
+			<dict>
+				<key>SSID_STR</key>
+				<string>Example</string>
+				<key>EncryptionType</key>
+				<string>WPA2</string>
+				<key>Password</key>
+				<string>hunter2</string>
+				<key>PayloadIdentifier</key>
+				<string>com.example.profile.wifi</string>
+				<key>PayloadType</key>
+				<string>com.apple.wifi.managed</string>
+				<key>PayloadUUID</key>
+				<string>0A1B2C3D-4E5F-4071-8293-A4B5C6D7E8F9</string>
+				<key>PayloadVersion</key>
+				<integer>1</integer>
+			</dict>
+		</array>
+		<key>PayloadDisplayName</key>
+		<string>Example</string>
+		<key>PayloadIdentifier</key>
+		<string>com.example.profile</string>
+		<key>PayloadType</key>
+		<string>Configuration</string>
+		<key>PayloadUUID</key>
+		<string>2F6D3C4E-6C5B-4A8E-9F1D-3B2A1C0D9E8F</string>
+		<key>PayloadVersion</key>
+		<integer>1</integer>
+	</dict>
+</plist>`, buffer.String())
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9A-F]{8}-[0-9A-F]{4}-4[0-9A-F]{3}-[89AB][0-9A-F]{3}-[0-9A-F]{12}$`)
+
+func TestEncodeGeneratesUUIDs(t *testing.T) {
+	wifi := &WiFi{SSID: "Example"}
+	profile := Profile{
+		PayloadHeader: PayloadHeader{PayloadIdentifier: "com.example.profile"},
+		Payloads:      []Payload{wifi},
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, profile.Encode(&buffer))
+	assert.Regexp(t, uuidPattern, profile.PayloadUUID)
+	assert.Regexp(t, uuidPattern, wifi.PayloadUUID)
+	assert.NotEqual(t, profile.PayloadUUID, wifi.PayloadUUID)
+	assert.Equal(t, "com.apple.wifi.managed."+wifi.PayloadUUID, wifi.PayloadIdentifier)
+	assert.Equal(t, 1, wifi.PayloadVersion)
+
+	// Encoding again keeps the generated values.
+	uuid := profile.PayloadUUID
+	assert.NoError(t, profile.Encode(&buffer))
+	assert.Equal(t, uuid, profile.PayloadUUID)
+}
+
+func TestEncodeInvalid(t *testing.T) {
+	profile := Profile{Payloads: []Payload{&WiFi{}}}
+	var buffer bytes.Buffer
+	assert.EqualError(t, profile.Encode(&buffer), "mobileconfig: PayloadIdentifier: is required")
+	assert.Empty(t, buffer.String())
+}
+
+func TestValidate(t *testing.T) {
+	profile := Profile{
+		PayloadHeader: PayloadHeader{PayloadIdentifier: "com.example.profile"},
+		PayloadScope:  "Device",
+		Payloads: []Payload{
+			&WiFi{PayloadHeader: PayloadHeader{PayloadUUID: "A"}, SSID: "a"},
+			&WiFi{PayloadHeader: PayloadHeader{PayloadUUID: "A", PayloadIdentifier: "com.example.profile"}, SSID: "b"},
+			nil,
+		},
+	}
+
+	var messages []string
+	for _, err := range profile.Validate() {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		`mobileconfig: PayloadScope: must be one of System, User, found "Device"`,
+		`mobileconfig: PayloadContent[1].PayloadUUID: duplicate UUID "A"`,
+		`mobileconfig: PayloadContent[1].PayloadIdentifier: duplicate identifier "com.example.profile"`,
+		"mobileconfig: PayloadContent[2]: is nil",
+	}, messages)
+}
+
+func TestEncodeSigned(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Profile Signer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	profile := Profile{
+		PayloadHeader: PayloadHeader{PayloadIdentifier: "com.example.profile"},
+		Payloads:      []Payload{NewCertificate(cert)},
+	}
+	var buffer bytes.Buffer
+	assert.NoError(t, profile.EncodeSigned(&buffer, cert, key, nil))
+
+	sd, err := cms.Parse(buffer.Bytes())
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	assert.NoError(t, sd.Verify(x509.VerifyOptions{Roots: roots}))
+
+	value, err := xml.NewDecoder(bytes.NewReader(sd.Content)).DecodeValue()
+	assert.NoError(t, err)
+	content := value.(map[string]interface{})["PayloadContent"].([]interface{})
+	payload := content[0].(map[string]interface{})
+	assert.Equal(t, "com.apple.security.root", payload["PayloadType"])
+	assert.Equal(t, "Profile Signer.cer", payload["PayloadCertificateFileName"])
+	assert.Equal(t, cert.Raw, payload["PayloadContent"])
+	assert.True(t, strings.HasPrefix(payload["PayloadIdentifier"].(string), "com.apple.security.root."))
+}
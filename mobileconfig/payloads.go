@@ -0,0 +1,387 @@
+package mobileconfig
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/zach-klippenstein/goplist/internal/plistutil"
+)
+
+// WiFi configures a Wi-Fi network (com.apple.wifi.managed).
+type WiFi struct {
+	PayloadHeader
+
+	// SSID is written as SSID_STR, and is required.
+	SSID string
+	// HiddenNetwork is written as HIDDEN_NETWORK.
+	HiddenNetwork bool
+	// AutoJoin defaults to true.
+	AutoJoin *bool
+	// EncryptionType is "WEP", "WPA", "WPA2", "WPA3", "Any" or "None".
+	EncryptionType string
+	Password       string
+	IsHotspot      bool
+
+	// ProxyType is "None", "Manual" or "Auto".
+	ProxyType       string
+	ProxyServer     string
+	ProxyServerPort int
+	ProxyPACURL     string
+}
+
+func (p *WiFi) PayloadType() string {
+	return "com.apple.wifi.managed"
+}
+
+func (p *WiFi) encode(w *plistutil.DictWriter) {
+	w.String("SSID_STR", p.SSID)
+	w.Bool("HIDDEN_NETWORK", p.HiddenNetwork)
+	w.BoolPtr("AutoJoin", p.AutoJoin)
+	w.String("EncryptionType", p.EncryptionType)
+	w.String("Password", p.Password)
+	w.Bool("IsHotspot", p.IsHotspot)
+	w.String("ProxyType", p.ProxyType)
+	w.String("ProxyServer", p.ProxyServer)
+	w.Int("ProxyServerPort", p.ProxyServerPort)
+	w.String("ProxyPACURL", p.ProxyPACURL)
+}
+
+func (p *WiFi) validate(v *plistutil.Validator, key string) {
+	v.Required(key+".SSID_STR", p.SSID)
+	v.OneOf(key+".EncryptionType", p.EncryptionType, []string{"WEP", "WPA", "WPA2", "WPA3", "Any", "None"})
+	if p.Password != "" && p.EncryptionType == "None" {
+		v.Errorf(key+".Password", "cannot be set for an open network")
+	}
+	v.OneOf(key+".ProxyType", p.ProxyType, []string{"None", "Manual", "Auto"})
+	if p.ProxyType == "Manual" {
+		v.Required(key+".ProxyServer", p.ProxyServer)
+		if p.ProxyServerPort <= 0 || p.ProxyServerPort > 65535 {
+			v.Errorf(key+".ProxyServerPort", "must be between 1 and 65535, found %d", p.ProxyServerPort)
+		}
+	}
+}
+
+/*
+VPN configures a VPN connection (com.apple.vpn.managed).
+
+RemoteAddress, AuthenticationMethod and the IKEv2 identifiers are written to
+the dict for VPNType: PPP for L2TP and PPTP, and IPSec, IKEv2 or VPN for the
+others. Any other keys for that dict can be added to Settings.
+*/
+type VPN struct {
+	PayloadHeader
+
+	UserDefinedName string
+	// VPNType is "L2TP", "PPTP", "IPSec", "IKEv2", "AlwaysOn" or "VPN".
+	VPNType string
+	// VPNSubType identifies the VPN app's provider, and is required for VPNType "VPN".
+	VPNSubType string
+
+	RemoteAddress string
+	// AuthenticationMethod is "SharedSecret", "Certificate" or "None".
+	AuthenticationMethod string
+	// RemoteIdentifier and LocalIdentifier are only used by IKEv2, which
+	// requires RemoteIdentifier.
+	RemoteIdentifier string
+	LocalIdentifier  string
+
+	// Settings holds any other keys for the VPNType dict.
+	Settings map[string]interface{}
+	// VendorConfig is passed to the VPN app for VPNType "VPN".
+	VendorConfig    map[string]interface{}
+	OnDemandEnabled bool
+	OnDemandRules   []map[string]interface{}
+}
+
+var vpnTypes = []string{"L2TP", "PPTP", "IPSec", "IKEv2", "AlwaysOn", "VPN"}
+
+func (p *VPN) PayloadType() string {
+	return "com.apple.vpn.managed"
+}
+
+// settingsKey returns the key of the dict that holds the VPNType's settings.
+func (p *VPN) settingsKey() string {
+	switch p.VPNType {
+	case "L2TP", "PPTP":
+		return "PPP"
+	}
+	return p.VPNType
+}
+
+func (p *VPN) settings() map[string]interface{} {
+	settings := map[string]interface{}{}
+	for key, value := range p.Settings {
+		settings[key] = value
+	}
+	if p.RemoteAddress != "" {
+		if p.settingsKey() == "PPP" {
+			settings["CommRemoteAddress"] = p.RemoteAddress
+		} else {
+			settings["RemoteAddress"] = p.RemoteAddress
+		}
+	}
+	if p.AuthenticationMethod != "" {
+		settings["AuthenticationMethod"] = p.AuthenticationMethod
+	}
+	if p.RemoteIdentifier != "" {
+		settings["RemoteIdentifier"] = p.RemoteIdentifier
+	}
+	if p.LocalIdentifier != "" {
+		settings["LocalIdentifier"] = p.LocalIdentifier
+	}
+	return settings
+}
+
+func (p *VPN) encode(w *plistutil.DictWriter) {
+	w.String("UserDefinedName", p.UserDefinedName)
+	w.String("VPNType", p.VPNType)
+	w.String("VPNSubType", p.VPNSubType)
+	if settings := p.settings(); len(settings) > 0 {
+		w.Value(p.settingsKey(), settings)
+	}
+	if len(p.VendorConfig) > 0 {
+		w.Value("VendorConfig", p.VendorConfig)
+	}
+	w.Bool("OnDemandEnabled", p.OnDemandEnabled)
+	if len(p.OnDemandRules) > 0 {
+		w.Value("OnDemandRules", p.OnDemandRules)
+	}
+}
+
+func (p *VPN) validate(v *plistutil.Validator, key string) {
+	v.Required(key+".UserDefinedName", p.UserDefinedName)
+	v.Required(key+".VPNType", p.VPNType)
+	v.OneOf(key+".VPNType", p.VPNType, vpnTypes)
+	if p.VPNType == "VPN" {
+		v.Required(key+".VPNSubType", p.VPNSubType)
+	}
+	v.OneOf(key+".AuthenticationMethod", p.AuthenticationMethod, []string{"SharedSecret", "Certificate", "None"})
+
+	settings := p.settings()
+	switch p.VPNType {
+	case "L2TP", "PPTP":
+		if settings["CommRemoteAddress"] == nil {
+			v.Errorf(key+".PPP.CommRemoteAddress", "is required")
+		}
+	case "IPSec", "IKEv2":
+		if settings["RemoteAddress"] == nil {
+			v.Errorf(key+"."+p.VPNType+".RemoteAddress", "is required")
+		}
+	}
+	if p.VPNType == "IKEv2" && settings["RemoteIdentifier"] == nil {
+		v.Errorf(key+".IKEv2.RemoteIdentifier", "is required")
+	}
+}
+
+// CertificateFormat is the PayloadType of a Certificate payload.
+type CertificateFormat string
+
+const (
+	// CertificateDER is a DER-encoded certificate.
+	CertificateDER CertificateFormat = "com.apple.security.pkcs1"
+	// CertificateRoot is a DER-encoded root certificate, which the device can be asked to trust.
+	CertificateRoot CertificateFormat = "com.apple.security.root"
+	// CertificatePEM is a PEM-encoded certificate.
+	CertificatePEM CertificateFormat = "com.apple.security.pem"
+	// CertificatePKCS12 is a password-protected certificate and private key.
+	CertificatePKCS12 CertificateFormat = "com.apple.security.pkcs12"
+)
+
+// Certificate installs a certificate, or a certificate and private key.
+type Certificate struct {
+	PayloadHeader
+
+	Format                     CertificateFormat
+	PayloadCertificateFileName string
+	// Data is written as PayloadContent.
+	Data []byte
+	// Password decrypts PKCS #12 data.
+	Password string
+}
+
+/*
+NewCertificate returns a payload that installs cert. Self-signed certificates
+are installed as roots, and other certificates as DER. The file name shown
+on the device is the certificate's common name.
+*/
+func NewCertificate(cert *x509.Certificate) *Certificate {
+	format := CertificateDER
+	if bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil {
+		format = CertificateRoot
+	}
+	return &Certificate{
+		Format:                     format,
+		PayloadCertificateFileName: cert.Subject.CommonName + ".cer",
+		Data:                       cert.Raw,
+	}
+}
+
+func (p *Certificate) PayloadType() string {
+	return string(p.Format)
+}
+
+func (p *Certificate) encode(w *plistutil.DictWriter) {
+	w.String("PayloadCertificateFileName", p.PayloadCertificateFileName)
+	w.Data("PayloadContent", p.Data)
+	w.String("Password", p.Password)
+}
+
+func (p *Certificate) validate(v *plistutil.Validator, key string) {
+	switch p.Format {
+	case CertificateDER, CertificateRoot:
+		if _, err := x509.ParseCertificate(p.Data); err != nil {
+			v.Errorf(key+".PayloadContent", "invalid DER certificate: %s", err)
+		}
+	case CertificatePEM:
+		if block, _ := pem.Decode(p.Data); block == nil || block.Type != "CERTIFICATE" {
+			v.Errorf(key+".PayloadContent", "must be a PEM certificate")
+		}
+	case CertificatePKCS12:
+		if len(p.Data) == 0 {
+			v.Errorf(key+".PayloadContent", "is required")
+		}
+	default:
+		v.Errorf(key+".PayloadType", "unknown certificate format %q", string(p.Format))
+	}
+	if p.Password != "" && p.Format != CertificatePKCS12 {
+		v.Errorf(key+".Password", "is only used for PKCS #12 certificates")
+	}
+}
+
+/*
+Restrictions restricts device features (com.apple.applicationaccess).
+
+Only the most common restrictions have fields. Any others can be set in
+Settings, which must not repeat keys that have fields. Nil fields are left
+at the device's default.
+*/
+type Restrictions struct {
+	PayloadHeader
+
+	AllowAppInstallation         *bool
+	AllowAppRemoval              *bool
+	AllowCamera                  *bool
+	AllowCloudBackup             *bool
+	AllowInAppPurchases          *bool
+	AllowSafari                  *bool
+	AllowScreenShot              *bool
+	ForceEncryptedBackup         *bool
+	AllowExplicitContent         *bool
+	AllowEraseContentAndSettings *bool
+
+	Settings map[string]interface{}
+}
+
+func (p *Restrictions) PayloadType() string {
+	return "com.apple.applicationaccess"
+}
+
+func (p *Restrictions) fields() map[string]*bool {
+	return map[string]*bool{
+		"allowAppInstallation":         p.AllowAppInstallation,
+		"allowAppRemoval":              p.AllowAppRemoval,
+		"allowCamera":                  p.AllowCamera,
+		"allowCloudBackup":             p.AllowCloudBackup,
+		"allowInAppPurchases":          p.AllowInAppPurchases,
+		"allowSafari":                  p.AllowSafari,
+		"allowScreenShot":              p.AllowScreenShot,
+		"forceEncryptedBackup":         p.ForceEncryptedBackup,
+		"allowExplicitContent":         p.AllowExplicitContent,
+		"allowEraseContentAndSettings": p.AllowEraseContentAndSettings,
+	}
+}
+
+func (p *Restrictions) encode(w *plistutil.DictWriter) {
+	settings := map[string]interface{}{}
+	for key, value := range p.Settings {
+		settings[key] = value
+	}
+	for key, value := range p.fields() {
+		if value != nil {
+			settings[key] = *value
+		}
+	}
+	w.Entries(settings)
+}
+
+func (p *Restrictions) validate(v *plistutil.Validator, key string) {
+	fields := p.fields()
+	for _, settingKey := range plistutil.SortedKeys(p.Settings) {
+		if _, ok := fields[settingKey]; ok {
+			v.Errorf(key+"."+settingKey, "is set in both a field and Settings")
+		}
+		if isHeaderKey(settingKey) {
+			v.Errorf(key+"."+settingKey, "must be set in the PayloadHeader")
+		}
+	}
+}
+
+/*
+CustomSettings sets managed preferences for an app or system domain
+(com.apple.ManagedClient.preferences), as if they were written with
+`defaults write Domain ...` and locked.
+*/
+type CustomSettings struct {
+	PayloadHeader
+
+	// Domain is the preference domain, usually an app's bundle identifier.
+	Domain   string
+	Settings map[string]interface{}
+}
+
+func (p *CustomSettings) PayloadType() string {
+	return "com.apple.ManagedClient.preferences"
+}
+
+func (p *CustomSettings) encode(w *plistutil.DictWriter) {
+	w.Value("PayloadContent", map[string]interface{}{
+		p.Domain: map[string]interface{}{
+			"Forced": []interface{}{
+				map[string]interface{}{"mcx_preference_settings": p.Settings},
+			},
+		},
+	})
+}
+
+func (p *CustomSettings) validate(v *plistutil.Validator, key string) {
+	v.Required(key+".Domain", p.Domain)
+	if len(p.Settings) == 0 {
+		v.Errorf(key+".Settings", "is required")
+	}
+}
+
+// Generic is a payload of any type, whose keys are set directly.
+type Generic struct {
+	PayloadHeader
+
+	Type    string
+	Content map[string]interface{}
+}
+
+func (p *Generic) PayloadType() string {
+	return p.Type
+}
+
+func (p *Generic) encode(w *plistutil.DictWriter) {
+	w.Entries(p.Content)
+}
+
+func (p *Generic) validate(v *plistutil.Validator, key string) {
+	v.Required(key+".PayloadType", p.Type)
+	for _, contentKey := range plistutil.SortedKeys(p.Content) {
+		if isHeaderKey(contentKey) {
+			v.Errorf(key+"."+contentKey, "must be set in the PayloadHeader")
+		}
+	}
+}
+
+func isHeaderKey(key string) bool {
+	switch key {
+	case "PayloadIdentifier", "PayloadUUID", "PayloadVersion", "PayloadType",
+		"PayloadDisplayName", "PayloadDescription", "PayloadOrganization":
+		return true
+	}
+	return false
+}
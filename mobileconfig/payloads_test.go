@@ -0,0 +1,162 @@
+package mobileconfig
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/internal/plistutil"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// encodePayload encodes a profile containing just p, and returns p's decoded dict.
+func encodePayload(t *testing.T, p Payload) map[string]interface{} {
+	profile := Profile{
+		PayloadHeader: PayloadHeader{PayloadIdentifier: "com.example.profile"},
+		Payloads:      []Payload{p},
+	}
+	var buffer bytes.Buffer
+	assert.NoError(t, profile.Encode(&buffer))
+
+	value, err := xml.NewDecoder(&buffer).DecodeValue()
+	assert.NoError(t, err)
+	payload := value.(map[string]interface{})["PayloadContent"].([]interface{})[0].(map[string]interface{})
+	for _, key := range []string{"PayloadIdentifier", "PayloadUUID", "PayloadVersion"} {
+		delete(payload, key)
+	}
+	return payload
+}
+
+func validatePayload(p Payload) []string {
+	v := plistutil.NewValidator(newValidationError)
+	p.validate(v, "p")
+	var messages []string
+	for _, err := range v.Errs() {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+func TestVPN(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"PayloadType":     "com.apple.vpn.managed",
+		"UserDefinedName": "Office",
+		"VPNType":         "IKEv2",
+		"IKEv2": map[string]interface{}{
+			"RemoteAddress":         "vpn.example.com",
+			"RemoteIdentifier":      "vpn.example.com",
+			"AuthenticationMethod":  "Certificate",
+			"DeadPeerDetectionRate": "Medium",
+		},
+		"OnDemandEnabled": true,
+	}, encodePayload(t, &VPN{
+		UserDefinedName:      "Office",
+		VPNType:              "IKEv2",
+		RemoteAddress:        "vpn.example.com",
+		RemoteIdentifier:     "vpn.example.com",
+		AuthenticationMethod: "Certificate",
+		Settings:             map[string]interface{}{"DeadPeerDetectionRate": "Medium"},
+		OnDemandEnabled:      true,
+	}))
+
+	assert.Equal(t, map[string]interface{}{
+		"PayloadType":     "com.apple.vpn.managed",
+		"UserDefinedName": "Legacy",
+		"VPNType":         "L2TP",
+		"PPP":             map[string]interface{}{"CommRemoteAddress": "vpn.example.com"},
+	}, encodePayload(t, &VPN{UserDefinedName: "Legacy", VPNType: "L2TP", RemoteAddress: "vpn.example.com"}))
+
+	assert.Equal(t, []string{
+		"mobileconfig: p.UserDefinedName: is required",
+		"mobileconfig: p.IKEv2.RemoteAddress: is required",
+		"mobileconfig: p.IKEv2.RemoteIdentifier: is required",
+	}, validatePayload(&VPN{VPNType: "IKEv2"}))
+	assert.Equal(t, []string{
+		"mobileconfig: p.VPNSubType: is required",
+	}, validatePayload(&VPN{UserDefinedName: "a", VPNType: "VPN"}))
+	assert.Equal(t, []string{
+		`mobileconfig: p.VPNType: must be one of L2TP, PPTP, IPSec, IKEv2, AlwaysOn, VPN, found "SSL"`,
+	}, validatePayload(&VPN{UserDefinedName: "a", VPNType: "SSL"}))
+}
+
+func TestWiFiValidate(t *testing.T) {
+	assert.Equal(t, []string{
+		"mobileconfig: p.SSID_STR: is required",
+		"mobileconfig: p.Password: cannot be set for an open network",
+		"mobileconfig: p.ProxyServer: is required",
+		"mobileconfig: p.ProxyServerPort: must be between 1 and 65535, found 0",
+	}, validatePayload(&WiFi{EncryptionType: "None", Password: "a", ProxyType: "Manual"}))
+}
+
+func TestCertificateValidate(t *testing.T) {
+	assert.Len(t, validatePayload(&Certificate{Format: CertificateDER, Data: []byte("junk")}), 1)
+	assert.Equal(t, []string{
+		"mobileconfig: p.PayloadContent: must be a PEM certificate",
+		"mobileconfig: p.Password: is only used for PKCS #12 certificates",
+	}, validatePayload(&Certificate{Format: CertificatePEM, Data: []byte("junk"), Password: "a"}))
+	assert.Equal(t, []string{
+		`mobileconfig: p.PayloadType: unknown certificate format ""`,
+	}, validatePayload(&Certificate{}))
+	assert.Empty(t, validatePayload(&Certificate{Format: CertificatePKCS12, Data: []byte{1}, Password: "a"}))
+}
+
+func TestRestrictions(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"PayloadType":          "com.apple.applicationaccess",
+		"allowCamera":          false,
+		"forceEncryptedBackup": true,
+		"allowAirDrop":         false,
+	}, encodePayload(t, &Restrictions{
+		AllowCamera:          Bool(false),
+		ForceEncryptedBackup: Bool(true),
+		Settings:             map[string]interface{}{"allowAirDrop": false},
+	}))
+
+	assert.Equal(t, []string{
+		"mobileconfig: p.PayloadType: must be set in the PayloadHeader",
+		"mobileconfig: p.allowCamera: is set in both a field and Settings",
+	}, validatePayload(&Restrictions{
+		AllowCamera: Bool(false),
+		Settings:    map[string]interface{}{"allowCamera": true, "PayloadType": "a"},
+	}))
+}
+
+func TestCustomSettings(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"PayloadType": "com.apple.ManagedClient.preferences",
+		"PayloadContent": map[string]interface{}{
+			"com.example.app": map[string]interface{}{
+				"Forced": []interface{}{
+					map[string]interface{}{
+						"mcx_preference_settings": map[string]interface{}{"ServerURL": "https://example.com"},
+					},
+				},
+			},
+		},
+	}, encodePayload(t, &CustomSettings{
+		Domain:   "com.example.app",
+		Settings: map[string]interface{}{"ServerURL": "https://example.com"},
+	}))
+
+	assert.Equal(t, []string{
+		"mobileconfig: p.Domain: is required",
+		"mobileconfig: p.Settings: is required",
+	}, validatePayload(&CustomSettings{}))
+}
+
+func TestGeneric(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"PayloadType": "com.apple.dnsSettings.managed",
+		"DNSSettings": map[string]interface{}{"DNSProtocol": "HTTPS", "ServerURL": "https://dns.example.com/query"},
+	}, encodePayload(t, &Generic{
+		Type: "com.apple.dnsSettings.managed",
+		Content: map[string]interface{}{
+			"DNSSettings": map[string]interface{}{"DNSProtocol": "HTTPS", "ServerURL": "https://dns.example.com/query"},
+		},
+	}))
+
+	assert.Equal(t, []string{
+		"mobileconfig: p.PayloadType: is required",
+		"mobileconfig: p.PayloadUUID: must be set in the PayloadHeader",
+	}, validatePayload(&Generic{Content: map[string]interface{}{"PayloadUUID": "a"}}))
+}
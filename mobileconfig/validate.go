@@ -0,0 +1,18 @@
+package mobileconfig
+
+import "fmt"
+
+// ValidationError describes a problem with a profile.
+type ValidationError struct {
+	// Key is the path to the offending key, e.g. "PayloadContent[1].SSID_STR".
+	Key string
+	Msg string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("mobileconfig: %s: %s", e.Key, e.Msg)
+}
+
+func newValidationError(key, msg string) error {
+	return &ValidationError{key, msg}
+}
@@ -0,0 +1,154 @@
+/*
+Package mobileprovision reads provisioning profiles (.mobileprovision and
+.provisionprofile files).
+
+A profile is an XML plist wrapped in a CMS signed data envelope. Decode unwraps
+the envelope and decodes the plist without checking the signature, and
+DecodeAndVerify also checks that the envelope was signed by a certificate that
+chains to the given roots, e.g. the Apple Root CA. Neither needs network access
+or the system keychain.
+*/
+package mobileprovision
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/zach-klippenstein/goplist/entitlements"
+	"github.com/zach-klippenstein/goplist/internal/cms"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Profile is the contents of a provisioning profile.
+type Profile struct {
+	AppIDName                   string    `plist:"AppIDName"`
+	ApplicationIdentifierPrefix []string  `plist:"ApplicationIdentifierPrefix"`
+	CreationDate                time.Time `plist:"CreationDate"`
+	ExpirationDate              time.Time `plist:"ExpirationDate"`
+	IsXcodeManaged              bool      `plist:"IsXcodeManaged"`
+	Name                        string    `plist:"Name"`
+	Platform                    []string  `plist:"Platform"`
+	ProvisionedDevices          []string  `plist:"ProvisionedDevices"`
+	ProvisionsAllDevices        bool      `plist:"ProvisionsAllDevices"`
+	TeamIdentifier              []string  `plist:"TeamIdentifier"`
+	TeamName                    string    `plist:"TeamName"`
+	TimeToLive                  int       `plist:"TimeToLive"`
+	UUID                        string    `plist:"UUID"`
+	Version                     int       `plist:"Version"`
+
+	// Entitlements are the entitlements granted to apps signed with the profile.
+	Entitlements *entitlements.Entitlements `plist:"-"`
+	// DeveloperCertificates are the certificates that may sign apps using the profile.
+	DeveloperCertificates []*x509.Certificate `plist:"-"`
+
+	// Unknown holds any keys that don't have a field above.
+	Unknown map[string]interface{} `plist:",unknown"`
+}
+
+// MaxProfileSize is the size of the largest profile Decode and DecodeAndVerify
+// read. Profiles listing every device a team can register are about 100 KB.
+const MaxProfileSize = 4 << 20
+
+// Decode reads a provisioning profile from r without verifying its signature.
+func Decode(r io.Reader) (*Profile, error) {
+	sd, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeContent(sd.Content)
+}
+
+/*
+DecodeAndVerify reads a provisioning profile from r, and returns an error if
+its signature is invalid or the signing certificate doesn't chain to
+options.Roots. Set options.CurrentTime to verify an expired profile as of a
+date it was valid.
+*/
+func DecodeAndVerify(r io.Reader, options x509.VerifyOptions) (*Profile, error) {
+	sd, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := sd.Verify(options); err != nil {
+		return nil, fmt.Errorf("mobileprovision: invalid signature: %s", err)
+	}
+	return decodeContent(sd.Content)
+}
+
+func parse(r io.Reader) (*cms.SignedData, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, MaxProfileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxProfileSize {
+		return nil, fmt.Errorf("mobileprovision: profile is larger than %d bytes", MaxProfileSize)
+	}
+	sd, err := cms.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("mobileprovision: not a signed profile: %s", err)
+	}
+	return sd, nil
+}
+
+func decodeContent(content []byte) (*Profile, error) {
+	value, err := xml.NewDecoder(bytes.NewReader(content)).DecodeValue()
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mobileprovision: profile must be a dict, found %T", value)
+	}
+
+	var profile Profile
+	if err := xml.UnmarshalValue(dict, &profile); err != nil {
+		return nil, err
+	}
+	delete(profile.Unknown, "Entitlements")
+	delete(profile.Unknown, "DeveloperCertificates")
+
+	if value, ok := dict["Entitlements"]; ok {
+		if profile.Entitlements, err = entitlements.FromValue(value); err != nil {
+			return nil, err
+		}
+	}
+
+	var rawCertificates [][]byte
+	if value, ok := dict["DeveloperCertificates"]; ok {
+		if err := xml.UnmarshalValue(value, &rawCertificates); err != nil {
+			return nil, err
+		}
+	}
+	for _, raw := range rawCertificates {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, err
+		}
+		profile.DeveloperCertificates = append(profile.DeveloperCertificates, cert)
+	}
+
+	return &profile, nil
+}
+
+// Expired returns true if the profile has expired as of now.
+func (p *Profile) Expired(now time.Time) bool {
+	return !now.Before(p.ExpirationDate)
+}
+
+// ProvisionsDevice returns true if an app signed with the profile can be
+// installed on the device with the given UDID.
+func (p *Profile) ProvisionsDevice(udid string) bool {
+	if p.ProvisionsAllDevices {
+		return true
+	}
+	for _, device := range p.ProvisionedDevices {
+		if device == udid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package mobileprovision
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/internal/cms"
+	"github.com/zach-klippenstein/goplist/internal/cms/cmstest"
+)
+
+const profileTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>AppIDName</key>
+	<string>Example</string>
+	<key>ApplicationIdentifierPrefix</key>
+	<array>
+		<string>ABCDE12345</string>
+	</array>
+	<key>CreationDate</key>
+	<date>2020-01-01T00:00:00Z</date>
+	<key>Platform</key>
+	<array>
+		<string>iOS</string>
+	</array>
+	<key>IsXcodeManaged</key>
+	<false/>
+	<key>DeveloperCertificates</key>
+	<array>
+		<data>%s</data>
+	</array>
+	<key>DER-Encoded-Profile</key>
+	<data>AAAA</data>
+	<key>Entitlements</key>
+	<dict>
+		<key>application-identifier</key>
+		<string>ABCDE12345.com.example.app</string>
+		<key>get-task-allow</key>
+		<true/>
+	</dict>
+	<key>ExpirationDate</key>
+	<date>2021-01-01T00:00:00Z</date>
+	<key>Name</key>
+	<string>Example Development</string>
+	<key>ProvisionedDevices</key>
+	<array>
+		<string>00008030-000000000000002E</string>
+	</array>
+	<key>TeamIdentifier</key>
+	<array>
+		<string>ABCDE12345</string>
+	</array>
+	<key>TeamName</key>
+	<string>Example Inc.</string>
+	<key>TimeToLive</key>
+	<integer>366</integer>
+	<key>UUID</key>
+	<string>9b6a8e9e-1f0a-4a4b-8d3c-1d2e3f4a5b6c</string>
+	<key>Version</key>
+	<integer>1</integer>
+</dict>
+</plist>`
+
+type testCA struct {
+	root    *x509.Certificate
+	rootKey *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	return testCA{cmstest.NewCertificate(t, "Root CA", key, nil, nil), key}
+}
+
+func (ca testCA) signProfile(t *testing.T, developerCert *x509.Certificate) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	signer := cmstest.NewCertificate(t, "Profile Signing", key, ca.root, ca.rootKey)
+
+	content := fmt.Sprintf(profileTemplate, base64.StdEncoding.EncodeToString(developerCert.Raw))
+	signed, err := cms.Sign([]byte(content), signer, key, nil)
+	assert.NoError(t, err)
+	return signed
+}
+
+func (ca testCA) roots() *x509.CertPool {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.root)
+	return roots
+}
+
+func TestDecode(t *testing.T) {
+	ca := newTestCA(t)
+	developerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	developerCert := cmstest.NewCertificate(t, "Apple Development: Example", developerKey, ca.root, ca.rootKey)
+
+	profile, err := Decode(bytes.NewReader(ca.signProfile(t, developerCert)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "9b6a8e9e-1f0a-4a4b-8d3c-1d2e3f4a5b6c", profile.UUID)
+	assert.Equal(t, []string{"ABCDE12345"}, profile.TeamIdentifier)
+	assert.Equal(t, "Example Development", profile.Name)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), profile.ExpirationDate.UTC())
+	assert.Equal(t, 366, profile.TimeToLive)
+	assert.Equal(t, []string{"00008030-000000000000002E"}, profile.ProvisionedDevices)
+	assert.Equal(t, "ABCDE12345.com.example.app", profile.Entitlements.ApplicationIdentifier)
+	assert.True(t, profile.Entitlements.GetTaskAllow)
+	assert.Len(t, profile.DeveloperCertificates, 1)
+	assert.Equal(t, "Apple Development: Example", profile.DeveloperCertificates[0].Subject.CommonName)
+	assert.Equal(t, map[string]interface{}{"DER-Encoded-Profile": []byte{0, 0, 0}}, profile.Unknown)
+
+	assert.True(t, profile.Expired(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, profile.Expired(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, profile.ProvisionsDevice("00008030-000000000000002E"))
+	assert.False(t, profile.ProvisionsDevice("00008030-000000000000002F"))
+}
+
+func TestDecodeAndVerify(t *testing.T) {
+	ca := newTestCA(t)
+	signed := ca.signProfile(t, ca.root)
+
+	profile, err := DecodeAndVerify(bytes.NewReader(signed), x509.VerifyOptions{Roots: ca.roots()})
+	assert.NoError(t, err)
+	assert.Equal(t, "9b6a8e9e-1f0a-4a4b-8d3c-1d2e3f4a5b6c", profile.UUID)
+
+	_, err = DecodeAndVerify(bytes.NewReader(signed), x509.VerifyOptions{Roots: newTestCA(t).roots()})
+	assert.Error(t, err)
+}
+
+func TestDecodeNotSigned(t *testing.T) {
+	_, err := Decode(bytes.NewReader([]byte(profileTemplate)))
+	assert.Error(t, err)
+}
+
+func TestDecodeTooLarge(t *testing.T) {
+	_, err := Decode(zeros{})
+	assert.EqualError(t, err, "mobileprovision: profile is larger than 4194304 bytes")
+}
+
+// zeros is an endless stream of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,139 @@
+package openstep
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+/*
+Quote returns s as it should be written in a plist: as is if it only contains
+characters that are allowed in unquoted strings, and otherwise in double quotes
+with quotes, backslashes and control characters escaped. Other non-ASCII
+characters are written as UTF-8.
+*/
+func Quote(s string) string {
+	if !needsQuotes(s) {
+		return s
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\U%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func needsQuotes(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		if !isUnquotedChar(s[i]) {
+			return true
+		}
+		if s[i] == '/' && i+1 < len(s) && (s[i+1] == '/' || s[i+1] == '*') {
+			return true
+		}
+	}
+	return !utf8.ValidString(s)
+}
+
+/*
+Marshal returns v in OpenStep format, indented with tabs. v can be a string,
+[]byte, []interface{}, []string, map[string]interface{} or map[string]string,
+nested to any depth. Dict keys are sorted.
+*/
+func Marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := marshalValue(&b, v, 0); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func marshalValue(b *bytes.Buffer, v interface{}, depth int) error {
+	switch v := v.(type) {
+	case string:
+		b.WriteString(Quote(v))
+	case []byte:
+		b.WriteByte('<')
+		b.WriteString(hex.EncodeToString(v))
+		b.WriteByte('>')
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return marshalValue(b, items, depth)
+	case []interface{}:
+		b.WriteString("(\n")
+		for i, item := range v {
+			writeIndent(b, depth+1)
+			if err := marshalValue(b, item, depth+1); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		writeIndent(b, depth)
+		b.WriteByte(')')
+	case map[string]string:
+		dict := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			dict[key] = value
+		}
+		return marshalValue(b, dict, depth)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("{\n")
+		for _, key := range keys {
+			writeIndent(b, depth+1)
+			b.WriteString(Quote(key))
+			b.WriteString(" = ")
+			if err := marshalValue(b, v[key], depth+1); err != nil {
+				return err
+			}
+			b.WriteString(";\n")
+		}
+		writeIndent(b, depth)
+		b.WriteByte('}')
+	default:
+		return fmt.Errorf("openstep: cannot marshal value of type %T", v)
+	}
+	return nil
+}
+
+func writeIndent(b *bytes.Buffer, depth int) {
+	for i := 0; i < depth; i++ {
+		b.WriteByte('\t')
+	}
+}
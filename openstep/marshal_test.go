@@ -0,0 +1,62 @@
+package openstep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote(t *testing.T) {
+	for _, test := range []struct {
+		s, expected string
+	}{
+		{"abc", "abc"},
+		{"path/to/file.m", "path/to/file.m"},
+		{"$(SRCROOT)", `"$(SRCROOT)"`},
+		{"", `""`},
+		{"a b", `"a b"`},
+		{"a//b", `"a//b"`},
+		{"a/*b", `"a/*b"`},
+		{`say "hi"\`, `"say \"hi\"\\"`},
+		{"line\nbreak\ttab", `"line\nbreak\ttab"`},
+		{"bell\a", `"bell\U0007"`},
+		{"héllo", `"héllo"`},
+	} {
+		assert.Equal(t, test.expected, Quote(test.s), test.s)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(map[string]interface{}{
+		"name":  "My App",
+		"items": []interface{}{"a", map[string]string{"b": "c"}},
+		"empty": []string{},
+		"icon":  []byte{0x89, 'P'},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+	empty = (
+	);
+	icon = <8950>;
+	items = (
+		a,
+		{
+			b = c;
+		}
+	);
+	name = "My App";
+}
+`, string(data))
+
+	value, err := Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":  "My App",
+		"items": []interface{}{"a", map[string]interface{}{"b": "c"}},
+		"empty": []interface{}{},
+		"icon":  []byte{0x89, 'P'},
+	}, value)
+
+	_, err = Marshal(42)
+	assert.EqualError(t, err, "openstep: cannot marshal value of type int")
+}
@@ -0,0 +1,227 @@
+/*
+Package openstep reads and writes plists in the OpenStep (old-style ASCII)
+format, used by .strings files and Xcode projects.
+
+Values are strings, data in <hex>, arrays in parentheses and dicts in braces:
+
+	{
+		name = "Hello, world";
+		items = (a, b);
+		data = <0fbd77>;
+	}
+
+Unmarshal converts strings to string, data to []byte, arrays to []interface{}
+and dicts to map[string]interface{}. As in .strings files, the top-level dict's
+braces may be left out. Scanner exposes the tokens, including comments, for
+callers that need to preserve them.
+*/
+package openstep
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Decode reads a plist from r. See Unmarshal.
+func Decode(r io.Reader) (interface{}, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal parses a plist, which may be in UTF-8 or UTF-16 with a byte order mark.
+func Unmarshal(data []byte) (interface{}, error) {
+	text, _, err := DecodeText(data)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{scanner: NewScanner(text)}
+	return p.parsePlist()
+}
+
+type parser struct {
+	scanner *Scanner
+	peeked  *Token
+}
+
+// next returns the next token that isn't a comment.
+func (p *parser) next() (Token, error) {
+	if p.peeked != nil {
+		token := *p.peeked
+		p.peeked = nil
+		return token, nil
+	}
+	for {
+		token, err := p.scanner.Next()
+		if err != nil || token.Kind != TokenComment {
+			return token, err
+		}
+	}
+}
+
+func (p *parser) peek() (Token, error) {
+	token, err := p.next()
+	if err == nil {
+		p.peeked = &token
+	}
+	return token, err
+}
+
+func (p *parser) errorf(token Token, format string, args ...interface{}) error {
+	return &SyntaxError{token.Line, fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parsePlist() (interface{}, error) {
+	token, err := p.peek()
+	if err == io.EOF {
+		// An empty .strings file.
+		return map[string]interface{}{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if token.Kind == TokenString {
+		// A string followed by = is the first entry of a dict without braces.
+		p.next()
+		next, err := p.peek()
+		if err == io.EOF {
+			return token.Value, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if next.Kind != TokenEquals {
+			return nil, p.errorf(next, "unexpected %q after value", next.Value)
+		}
+
+		p.next()
+		dict := map[string]interface{}{}
+		if err := p.parseEntry(dict, token); err != nil {
+			return nil, err
+		}
+		return dict, p.parseEntries(dict, true)
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if token, err := p.next(); err != io.EOF {
+		if err != nil {
+			return nil, err
+		}
+		return nil, p.errorf(token, "unexpected %q after value", token.Value)
+	}
+	return value, nil
+}
+
+func (p *parser) parseValue() (interface{}, error) {
+	token, err := p.next()
+	if err == io.EOF {
+		return nil, &SyntaxError{p.scanner.Line(), "unexpected end of input"}
+	} else if err != nil {
+		return nil, err
+	}
+
+	switch token.Kind {
+	case TokenString:
+		return token.Value, nil
+	case TokenData:
+		return token.Data, nil
+	case TokenBeginArray:
+		return p.parseArray()
+	case TokenBeginDict:
+		dict := map[string]interface{}{}
+		return dict, p.parseEntries(dict, false)
+	}
+	return nil, p.errorf(token, "unexpected %q", token.Value)
+}
+
+func (p *parser) parseArray() ([]interface{}, error) {
+	array := []interface{}{}
+	for {
+		token, err := p.peek()
+		if err != nil {
+			return nil, p.eofError(err)
+		}
+		if token.Kind == TokenEndArray {
+			p.next()
+			return array, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+
+		token, err = p.next()
+		if err != nil {
+			return nil, p.eofError(err)
+		}
+		switch token.Kind {
+		case TokenComma:
+		case TokenEndArray:
+			return array, nil
+		default:
+			return nil, p.errorf(token, "expected , or ) in array, found %q", token.Value)
+		}
+	}
+}
+
+// parseEntries parses "key = value;" entries into dict until the closing
+// brace, or the end of input if topLevel is true.
+func (p *parser) parseEntries(dict map[string]interface{}, topLevel bool) error {
+	for {
+		token, err := p.next()
+		if err == io.EOF && topLevel {
+			return nil
+		} else if err != nil {
+			return p.eofError(err)
+		}
+
+		switch {
+		case token.Kind == TokenEndDict && !topLevel:
+			return nil
+		case token.Kind == TokenString:
+			if err := p.expect(TokenEquals, "="); err != nil {
+				return err
+			}
+			if err := p.parseEntry(dict, token); err != nil {
+				return err
+			}
+		default:
+			return p.errorf(token, "expected key, found %q", token.Value)
+		}
+	}
+}
+
+// parseEntry parses the value and semicolon after "key =".
+func (p *parser) parseEntry(dict map[string]interface{}, key Token) error {
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	dict[key.Value] = value
+	return p.expect(TokenSemicolon, ";")
+}
+
+func (p *parser) expect(kind TokenKind, what string) error {
+	token, err := p.next()
+	if err != nil {
+		return p.eofError(err)
+	}
+	if token.Kind != kind {
+		return p.errorf(token, "expected %s, found %q", what, token.Value)
+	}
+	return nil
+}
+
+func (p *parser) eofError(err error) error {
+	if err == io.EOF {
+		return &SyntaxError{p.scanner.Line(), "unexpected end of input"}
+	}
+	return err
+}
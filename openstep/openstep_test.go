@@ -0,0 +1,87 @@
+package openstep
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+func TestUnmarshal(t *testing.T) {
+	value, err := Unmarshal([]byte(`// !$*UTF8*$!
+{
+	archiveVersion = 1;
+	classes = {
+	};
+	objects = {
+		13B07F961A680F5B00A75B9A /* App */ = {
+			isa = PBXNativeTarget;
+			buildPhases = (
+				13B07F871A680F5B00A75B9A /* Sources */,
+				"Embed Frameworks",
+			);
+			name = "My App";
+			icon = <89504e47>;
+		};
+	};
+}
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"archiveVersion": "1",
+		"classes":        map[string]interface{}{},
+		"objects": map[string]interface{}{
+			"13B07F961A680F5B00A75B9A": map[string]interface{}{
+				"isa":         "PBXNativeTarget",
+				"buildPhases": []interface{}{"13B07F871A680F5B00A75B9A", "Embed Frameworks"},
+				"name":        "My App",
+				"icon":        []byte{0x89, 'P', 'N', 'G'},
+			},
+		},
+	}, value)
+}
+
+func TestUnmarshalTopLevel(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		expected interface{}
+	}{
+		{"", map[string]interface{}{}},
+		{"/* nothing */", map[string]interface{}{}},
+		{`"a" = "b"; c = d;`, map[string]interface{}{"a": "b", "c": "d"}},
+		{"string", "string"},
+		{"(a, b,)", []interface{}{"a", "b"}},
+		{"()", []interface{}{}},
+		{"<>", []byte{}},
+	} {
+		value, err := Unmarshal([]byte(test.text))
+		assert.NoError(t, err, test.text)
+		assert.Equal(t, test.expected, value, test.text)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for _, test := range []struct {
+		text, expected string
+	}{
+		{"{a = b}", `openstep: line 1: expected ;, found "}"`},
+		{"{a b;}", `openstep: line 1: expected =, found "b"`},
+		{"{(a) = b;}", `openstep: line 1: expected key, found "("`},
+		{"(a b)", `openstep: line 1: expected , or ) in array, found "b"`},
+		{"{\na = (\n", "openstep: line 3: unexpected end of input"},
+		{`a = b`, "openstep: line 1: unexpected end of input"},
+		{"a b", `openstep: line 1: unexpected "b" after value`},
+		{"(a) b", `openstep: line 1: unexpected "b" after value`},
+		{"=", `openstep: line 1: unexpected "="`},
+	} {
+		_, err := Unmarshal([]byte(test.text))
+		assert.EqualError(t, err, test.expected, test.text)
+	}
+}
+
+func TestDecodeUTF16(t *testing.T) {
+	value, err := Decode(strings.NewReader(string(EncodeText(`"key" = "välue";`, xml.UTF16LittleEndian))))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"key": "välue"}, value)
+}
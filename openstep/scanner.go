@@ -0,0 +1,332 @@
+package openstep
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// TokenKind is the type of a Token.
+type TokenKind int
+
+const (
+	// TokenString is a quoted or unquoted string. Its Value is unescaped.
+	TokenString TokenKind = iota
+	// TokenData is a <hex> data literal. Its Data is decoded.
+	TokenData
+	// TokenComment is a // or /* */ comment. Its Value excludes the delimiters.
+	TokenComment
+	TokenBeginDict
+	TokenEndDict
+	TokenBeginArray
+	TokenEndArray
+	TokenEquals
+	TokenSemicolon
+	TokenComma
+)
+
+var punctuation = map[byte]TokenKind{
+	'{': TokenBeginDict,
+	'}': TokenEndDict,
+	'(': TokenBeginArray,
+	')': TokenEndArray,
+	'=': TokenEquals,
+	';': TokenSemicolon,
+	',': TokenComma,
+}
+
+// Token is a lexical token of an OpenStep plist.
+type Token struct {
+	Kind  TokenKind
+	Value string
+	Data  []byte
+	// Quoted is true for strings written in quotes.
+	Quoted bool
+	// BlockComment is true for /* */ comments.
+	BlockComment bool
+	// Offset is the byte offset of the start of the token.
+	Offset int
+	// Line is the 1-based line number of the start of the token.
+	Line int
+}
+
+// SyntaxError is returned for malformed input.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("openstep: line %d: %s", e.Line, e.Msg)
+}
+
+/*
+Scanner splits OpenStep plist text into tokens, including comments, so
+callers that need to preserve comments, like .strings and .pbxproj editors,
+can see them.
+*/
+type Scanner struct {
+	text   string
+	offset int
+	line   int
+}
+
+// NewScanner returns a Scanner that reads text, which must be UTF-8. Use
+// DecodeText to convert files in other encodings.
+func NewScanner(text string) *Scanner {
+	return &Scanner{text: text, line: 1}
+}
+
+// Line returns the 1-based line number the scanner has reached.
+func (s *Scanner) Line() int {
+	return s.line
+}
+
+// Next returns the next token, or io.EOF at the end of the text.
+func (s *Scanner) Next() (Token, error) {
+	s.skipSpace()
+	if s.offset >= len(s.text) {
+		return Token{}, io.EOF
+	}
+
+	token := Token{Offset: s.offset, Line: s.line}
+	c := s.text[s.offset]
+	switch {
+	case c == '/' && s.peek(1) == '/':
+		end := s.indexFrom(s.offset, "\n")
+		if end < 0 {
+			end = len(s.text)
+		}
+		token.Kind = TokenComment
+		token.Value = s.text[s.offset+2 : end]
+		s.offset = end
+		return token, nil
+
+	case c == '/' && s.peek(1) == '*':
+		end := s.indexFrom(s.offset+2, "*/")
+		if end < 0 {
+			return Token{}, s.errorf("unterminated comment")
+		}
+		token.Kind = TokenComment
+		token.BlockComment = true
+		token.Value = s.text[s.offset+2 : end]
+		s.advance(end + 2 - s.offset)
+		return token, nil
+
+	case c == '"' || c == '\'':
+		value, err := s.scanQuoted(c)
+		if err != nil {
+			return Token{}, err
+		}
+		token.Kind = TokenString
+		token.Value = value
+		token.Quoted = true
+		return token, nil
+
+	case c == '<':
+		data, err := s.scanData()
+		if err != nil {
+			return Token{}, err
+		}
+		token.Kind = TokenData
+		token.Data = data
+		return token, nil
+
+	case isUnquotedChar(c):
+		start := s.offset
+		for s.offset < len(s.text) && isUnquotedChar(s.text[s.offset]) {
+			if s.text[s.offset] == '/' && (s.peek(1) == '/' || s.peek(1) == '*') {
+				break
+			}
+			s.offset++
+		}
+		token.Kind = TokenString
+		token.Value = s.text[start:s.offset]
+		return token, nil
+	}
+
+	if kind, ok := punctuation[c]; ok {
+		token.Kind = kind
+		token.Value = string(c)
+		s.offset++
+		return token, nil
+	}
+	r, _ := utf8.DecodeRuneInString(s.text[s.offset:])
+	return Token{}, s.errorf("unexpected character %q", r)
+}
+
+// isUnquotedChar returns true for the characters that can appear in a string
+// without quotes.
+func isUnquotedChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '_' || c == '$' || c == '/' || c == ':' || c == '.' || c == '-'
+}
+
+func (s *Scanner) skipSpace() {
+	for s.offset < len(s.text) {
+		switch s.text[s.offset] {
+		case '\n':
+			s.line++
+		case ' ', '\t', '\r', '\f', '\v':
+		default:
+			return
+		}
+		s.offset++
+	}
+}
+
+func (s *Scanner) peek(n int) byte {
+	if s.offset+n < len(s.text) {
+		return s.text[s.offset+n]
+	}
+	return 0
+}
+
+func (s *Scanner) indexFrom(from int, substr string) int {
+	for i := from; i+len(substr) <= len(s.text); i++ {
+		if s.text[i:i+len(substr)] == substr {
+			return i
+		}
+	}
+	return -1
+}
+
+// advance moves forward n bytes, counting lines.
+func (s *Scanner) advance(n int) {
+	for _, c := range []byte(s.text[s.offset : s.offset+n]) {
+		if c == '\n' {
+			s.line++
+		}
+	}
+	s.offset += n
+}
+
+func (s *Scanner) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{s.line, fmt.Sprintf(format, args...)}
+}
+
+func (s *Scanner) scanQuoted(quote byte) (string, error) {
+	s.offset++
+	var value []byte
+	for {
+		if s.offset >= len(s.text) {
+			return "", s.errorf("unterminated string")
+		}
+		c := s.text[s.offset]
+		switch c {
+		case quote:
+			s.offset++
+			return string(value), nil
+		case '\\':
+			escaped, err := s.scanEscape()
+			if err != nil {
+				return "", err
+			}
+			value = append(value, escaped...)
+		default:
+			if c == '\n' {
+				s.line++
+			}
+			value = append(value, c)
+			s.offset++
+		}
+	}
+}
+
+// scanEscape decodes the escape sequence at the current offset.
+func (s *Scanner) scanEscape() (string, error) {
+	s.offset++
+	if s.offset >= len(s.text) {
+		return "", s.errorf("unterminated string")
+	}
+	c := s.text[s.offset]
+	s.offset++
+
+	switch c {
+	case 'a':
+		return "\a", nil
+	case 'b':
+		return "\b", nil
+	case 'f':
+		return "\f", nil
+	case 'n':
+		return "\n", nil
+	case 'r':
+		return "\r", nil
+	case 't':
+		return "\t", nil
+	case 'v':
+		return "\v", nil
+	case 'U', 'u':
+		end := s.offset
+		for end < len(s.text) && end < s.offset+4 && isHexDigit(s.text[end]) {
+			end++
+		}
+		if end == s.offset {
+			return "", s.errorf("invalid \\%c escape", c)
+		}
+		code, _ := strconv.ParseUint(s.text[s.offset:end], 16, 16)
+		s.offset = end
+		return s.surrogatePair(rune(code))
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		end := s.offset
+		for end < len(s.text) && end < s.offset+2 && '0' <= s.text[end] && s.text[end] <= '7' {
+			end++
+		}
+		code, _ := strconv.ParseUint(s.text[s.offset-1:end], 8, 16)
+		s.offset = end
+		return string(rune(code)), nil
+	case '\n':
+		s.line++
+	}
+	// Any other character, including quotes and backslashes, stands for itself.
+	return string(c), nil
+}
+
+// surrogatePair combines a high surrogate from a \U escape with an escaped
+// low surrogate that follows it.
+func (s *Scanner) surrogatePair(high rune) (string, error) {
+	if high < 0xD800 || high > 0xDBFF {
+		return string(high), nil
+	}
+	rest := s.text[s.offset:]
+	if len(rest) >= 6 && rest[0] == '\\' && (rest[1] == 'U' || rest[1] == 'u') {
+		if low, err := strconv.ParseUint(rest[2:6], 16, 16); err == nil && low >= 0xDC00 && low <= 0xDFFF {
+			s.offset += 6
+			return string((high-0xD800)<<10 | (rune(low) - 0xDC00) + 0x10000), nil
+		}
+	}
+	return string(utf8.RuneError), nil
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func (s *Scanner) scanData() ([]byte, error) {
+	end := s.indexFrom(s.offset, ">")
+	if end < 0 {
+		return nil, s.errorf("unterminated data")
+	}
+
+	var digits []byte
+	for _, c := range []byte(s.text[s.offset+1 : end]) {
+		switch {
+		case isHexDigit(c):
+			digits = append(digits, c)
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			return nil, s.errorf("invalid character %q in data", c)
+		}
+	}
+	if len(digits)%2 != 0 {
+		return nil, s.errorf("data has an odd number of hex digits")
+	}
+
+	data := make([]byte, len(digits)/2)
+	hex.Decode(data, digits)
+	s.advance(end + 1 - s.offset)
+	return data, nil
+}
@@ -0,0 +1,86 @@
+package openstep
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scanAll(t *testing.T, text string) []Token {
+	var tokens []Token
+	s := NewScanner(text)
+	for {
+		token, err := s.Next()
+		if err == io.EOF {
+			return tokens
+		}
+		assert.NoError(t, err)
+		if err != nil {
+			return tokens
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+func TestScanner(t *testing.T) {
+	tokens := scanAll(t, `/* Title */
+"a b" = value.1/x; // trailing
+list = (<0a 0B>, 'single');`)
+
+	assert.Equal(t, []Token{
+		{Kind: TokenComment, Value: " Title ", BlockComment: true, Offset: 0, Line: 1},
+		{Kind: TokenString, Value: "a b", Quoted: true, Offset: 12, Line: 2},
+		{Kind: TokenEquals, Value: "=", Offset: 18, Line: 2},
+		{Kind: TokenString, Value: "value.1/x", Offset: 20, Line: 2},
+		{Kind: TokenSemicolon, Value: ";", Offset: 29, Line: 2},
+		{Kind: TokenComment, Value: " trailing", Offset: 31, Line: 2},
+		{Kind: TokenString, Value: "list", Offset: 43, Line: 3},
+		{Kind: TokenEquals, Value: "=", Offset: 48, Line: 3},
+		{Kind: TokenBeginArray, Value: "(", Offset: 50, Line: 3},
+		{Kind: TokenData, Data: []byte{0x0a, 0x0b}, Offset: 51, Line: 3},
+		{Kind: TokenComma, Value: ",", Offset: 58, Line: 3},
+		{Kind: TokenString, Value: "single", Quoted: true, Offset: 60, Line: 3},
+		{Kind: TokenEndArray, Value: ")", Offset: 68, Line: 3},
+		{Kind: TokenSemicolon, Value: ";", Offset: 69, Line: 3},
+	}, tokens)
+}
+
+func TestScannerEscapes(t *testing.T) {
+	for _, test := range []struct {
+		quoted, expected string
+	}{
+		{`"a\"b"`, `a"b`},
+		{`"a\\b"`, `a\b`},
+		{`"\n\t\r"`, "\n\t\r"},
+		{`"\U00e9"`, "é"},
+		{`"été"`, "été"},
+		{`"\UD83D\UDE00"`, "😀"},
+		{`"\101\60"`, "A0"},
+		{`"caf\351"`, "café"},
+		{`"line
+break"`, "line\nbreak"},
+		{`"\q"`, "q"},
+	} {
+		tokens := scanAll(t, test.quoted)
+		if assert.Len(t, tokens, 1) {
+			assert.Equal(t, test.expected, tokens[0].Value, test.quoted)
+		}
+	}
+}
+
+func TestScannerErrors(t *testing.T) {
+	for _, test := range []struct {
+		text, expected string
+	}{
+		{`"abc`, "openstep: line 1: unterminated string"},
+		{"\n/* abc", "openstep: line 2: unterminated comment"},
+		{"<0a", "openstep: line 1: unterminated data"},
+		{"<0ax>", `openstep: line 1: invalid character 'x' in data`},
+		{"<0a1>", "openstep: line 1: data has an odd number of hex digits"},
+		{"@", `openstep: line 1: unexpected character '@'`},
+	} {
+		_, err := NewScanner(test.text).Next()
+		assert.EqualError(t, err, test.expected)
+	}
+}
@@ -0,0 +1,64 @@
+package openstep
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+/*
+DecodeText converts the contents of a file to a string, and returns the
+encoding it was in. UTF-16 is detected by its byte order mark, or by zero bytes
+in the first two characters, since .strings files are often UTF-16 without a
+mark. Anything else must be UTF-8, with or without a mark.
+*/
+func DecodeText(data []byte) (string, xml.Encoding, error) {
+	switch {
+	case len(data) >= 3 && data[0] == 0xEF && data[1] == 0xBB && data[2] == 0xBF:
+		data = data[3:]
+	case len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF:
+		return decodeUTF16(data[2:], binary.BigEndian), xml.UTF16BigEndian, nil
+	case len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE:
+		return decodeUTF16(data[2:], binary.LittleEndian), xml.UTF16LittleEndian, nil
+	case len(data) >= 4 && data[0] == 0 && data[1] != 0 && data[2] == 0 && data[3] != 0:
+		return decodeUTF16(data, binary.BigEndian), xml.UTF16BigEndian, nil
+	case len(data) >= 4 && data[0] != 0 && data[1] == 0 && data[2] != 0 && data[3] == 0:
+		return decodeUTF16(data, binary.LittleEndian), xml.UTF16LittleEndian, nil
+	}
+
+	if !utf8.Valid(data) {
+		return "", xml.UTF8, errors.New("openstep: text is not valid UTF-8 or UTF-16")
+	}
+	return string(data), xml.UTF8, nil
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// EncodeText converts text to encoding. UTF-16 starts with a byte order mark.
+func EncodeText(text string, encoding xml.Encoding) []byte {
+	var order binary.ByteOrder
+	switch encoding {
+	case xml.UTF16BigEndian:
+		order = binary.BigEndian
+	case xml.UTF16LittleEndian:
+		order = binary.LittleEndian
+	default:
+		return []byte(text)
+	}
+
+	units := utf16.Encode([]rune("\uFEFF" + text))
+	data := make([]byte, 2*len(units))
+	for i, unit := range units {
+		order.PutUint16(data[2*i:], unit)
+	}
+	return data
+}
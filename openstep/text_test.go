@@ -0,0 +1,36 @@
+package openstep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+func TestDecodeText(t *testing.T) {
+	for _, test := range []struct {
+		data     []byte
+		encoding xml.Encoding
+	}{
+		{[]byte("a = \"é\";"), xml.UTF8},
+		{[]byte("\xEF\xBB\xBFa = \"é\";"), xml.UTF8},
+		{[]byte("\xFE\xFF\x00a\x00 \x00=\x00 \x00\"\x00\xE9\x00\"\x00;"), xml.UTF16BigEndian},
+		{[]byte("\xFF\xFEa\x00 \x00=\x00 \x00\"\x00\xE9\x00\"\x00;\x00"), xml.UTF16LittleEndian},
+		{[]byte("\x00a\x00 \x00=\x00 \x00\"\x00\xE9\x00\"\x00;"), xml.UTF16BigEndian},
+		{[]byte("a\x00 \x00=\x00 \x00\"\x00\xE9\x00\"\x00;\x00"), xml.UTF16LittleEndian},
+	} {
+		text, encoding, err := DecodeText(test.data)
+		assert.NoError(t, err)
+		assert.Equal(t, `a = "é";`, text)
+		assert.Equal(t, test.encoding, encoding)
+	}
+
+	_, _, err := DecodeText([]byte("caf\xE9"))
+	assert.Error(t, err)
+}
+
+func TestEncodeText(t *testing.T) {
+	assert.Equal(t, []byte("é"), EncodeText("é", xml.UTF8))
+	assert.Equal(t, []byte("\xFE\xFF\x00\xE9\xD8\x3D\xDE\x00"), EncodeText("é😀", xml.UTF16BigEndian))
+	assert.Equal(t, []byte("\xFF\xFE\xE9\x00\x3D\xD8\x00\xDE"), EncodeText("é😀", xml.UTF16LittleEndian))
+}
@@ -0,0 +1,303 @@
+package pbxproj
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/zach-klippenstein/goplist/openstep"
+)
+
+// singleLineISAs are the classes Xcode writes on one line.
+var singleLineISAs = map[string]bool{
+	"PBXBuildFile":     true,
+	"PBXFileReference": true,
+}
+
+// defaultPhaseNames are the comments for build phases without a name.
+var defaultPhaseNames = map[string]string{
+	"PBXSourcesBuildPhase":     "Sources",
+	"PBXFrameworksBuildPhase":  "Frameworks",
+	"PBXResourcesBuildPhase":   "Resources",
+	"PBXHeadersBuildPhase":     "Headers",
+	"PBXCopyFilesBuildPhase":   "CopyFiles",
+	"PBXShellScriptBuildPhase": "ShellScript",
+	"PBXRezBuildPhase":         "Rez",
+}
+
+/*
+referenceKeys are the keys whose values, or whose arrays' elements, are
+references to other objects, and so are annotated. Other values are never
+annotated, even if they're equal to an object's ID, e.g. TestTargetID in a
+project's TargetAttributes, or remoteGlobalIDString in a container item proxy.
+*/
+var referenceKeys = map[string]bool{
+	"baseConfigurationReference":       true,
+	"baseConfigurationReferenceAnchor": true,
+	"buildConfigurationList":           true,
+	"buildConfigurations":              true,
+	"buildPhases":                      true,
+	"buildRules":                       true,
+	"children":                         true,
+	"containerPortal":                  true,
+	"currentVersion":                   true,
+	"dependencies":                     true,
+	"exceptions":                       true,
+	"fileRef":                          true,
+	"fileSystemSynchronizedGroups":     true,
+	"files":                            true,
+	"mainGroup":                        true,
+	"package":                          true,
+	"packageProductDependencies":       true,
+	"packageReferences":                true,
+	"productRef":                       true,
+	"productRefGroup":                  true,
+	"productReference":                 true,
+	"remoteRef":                        true,
+	"rootObject":                       true,
+	"target":                           true,
+	"targetProxy":                      true,
+	"targets":                          true,
+	"ProductGroup":                     true,
+	"ProjectRef":                       true,
+}
+
+// Encode writes f to w in Xcode's format.
+func (f *File) Encode(w io.Writer) error {
+	e := newEncoder(f)
+	e.WriteString("// !$*UTF8*$!\n{\n")
+	e.writeEntry("archiveVersion", f.ArchiveVersion, 1, false)
+	e.writeEntry("classes", f.Classes, 1, false)
+	e.writeEntry("objectVersion", f.ObjectVersion, 1, false)
+	e.WriteString("\tobjects = {\n")
+	e.writeObjects()
+	e.WriteString("\t};\n")
+	e.writeEntry("rootObject", f.RootObject, 1, false)
+	e.WriteString("}\n")
+
+	_, err := w.Write(e.Bytes())
+	return err
+}
+
+type encoder struct {
+	bytes.Buffer
+	file *File
+	// phases maps build file IDs to the build phases they're in.
+	phases map[string]*Object
+	// listOwners maps configuration list IDs to the objects that use them.
+	listOwners map[string]*Object
+}
+
+func newEncoder(f *File) *encoder {
+	e := &encoder{
+		file:       f,
+		phases:     map[string]*Object{},
+		listOwners: map[string]*Object{},
+	}
+	for _, o := range f.Objects {
+		for _, id := range idValues(o.Fields["files"]) {
+			e.phases[id] = o
+		}
+		if id := o.String("buildConfigurationList"); id != "" {
+			e.listOwners[id] = o
+		}
+	}
+	return e
+}
+
+func (e *encoder) writeObjects() {
+	sections := map[string][]*Object{}
+	for _, o := range e.file.Objects {
+		sections[o.ISA()] = append(sections[o.ISA()], o)
+	}
+	isas := make([]string, 0, len(sections))
+	for isa := range sections {
+		isas = append(isas, isa)
+	}
+	sort.Strings(isas)
+
+	for _, isa := range isas {
+		objects := sections[isa]
+		sort.Sort(byID(objects))
+
+		fmt.Fprintf(e, "\n/* Begin %s section */\n", isa)
+		for _, o := range objects {
+			e.WriteString("\t\t")
+			e.writeString(o.ID, true)
+			e.WriteString(" = ")
+			e.writeDict(o.Fields, 2, singleLineISAs[isa], true)
+			e.WriteString(";\n")
+		}
+		fmt.Fprintf(e, "/* End %s section */\n", isa)
+	}
+}
+
+// writeEntry writes a dict entry, on its own line at indent unless singleLine.
+func (e *encoder) writeEntry(key string, value interface{}, indent int, singleLine bool) {
+	if !singleLine {
+		e.WriteString(strings.Repeat("\t", indent))
+	}
+	e.writeString(key, false)
+	e.WriteString(" = ")
+	e.writeValue(key, value, indent, singleLine)
+	e.WriteByte(';')
+	if singleLine {
+		e.WriteByte(' ')
+	} else {
+		e.WriteByte('\n')
+	}
+}
+
+func (e *encoder) writeValue(key string, value interface{}, indent int, singleLine bool) {
+	switch value := value.(type) {
+	case string:
+		e.writeString(value, referenceKeys[key])
+	case []byte:
+		fmt.Fprintf(e, "<%s>", hex.EncodeToString(value))
+	case []interface{}:
+		e.writeArray(key, value, indent, singleLine)
+	case map[string]interface{}:
+		e.writeDict(value, indent, singleLine, false)
+	default:
+		e.writeString(fmt.Sprint(value), false)
+	}
+}
+
+func (e *encoder) writeArray(key string, array []interface{}, indent int, singleLine bool) {
+	e.WriteByte('(')
+	if !singleLine {
+		e.WriteByte('\n')
+	}
+	for _, value := range array {
+		if !singleLine {
+			e.WriteString(strings.Repeat("\t", indent+1))
+		}
+		e.writeValue(key, value, indent+1, singleLine)
+		e.WriteByte(',')
+		if singleLine {
+			e.WriteByte(' ')
+		} else {
+			e.WriteByte('\n')
+		}
+	}
+	if !singleLine {
+		e.WriteString(strings.Repeat("\t", indent))
+	}
+	e.WriteByte(')')
+}
+
+// writeDict writes a dict with its keys sorted, except that objects start with isa.
+func (e *encoder) writeDict(dict map[string]interface{}, indent int, singleLine, isObject bool) {
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		if !isObject || key != "isa" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if isObject {
+		keys = append([]string{"isa"}, keys...)
+	}
+
+	e.WriteByte('{')
+	if !singleLine {
+		e.WriteByte('\n')
+	}
+	for _, key := range keys {
+		e.writeEntry(key, dict[key], indent+1, singleLine)
+	}
+	if !singleLine {
+		e.WriteString(strings.Repeat("\t", indent))
+	}
+	e.WriteByte('}')
+}
+
+// writeString writes s, quoted if Xcode would quote it, followed by a comment
+// if annotate is true and it's the ID of an object.
+func (e *encoder) writeString(s string, annotate bool) {
+	e.WriteString(quote(s))
+	if !annotate {
+		return
+	}
+	if o := e.file.Object(s); o != nil {
+		if comment := e.comment(o); comment != "" {
+			fmt.Fprintf(e, " /* %s */", comment)
+		}
+	}
+}
+
+// quote quotes s unless it's made of letters, digits, _, . and /, since Xcode
+// quotes more than openstep.Quote does.
+func quote(s string) string {
+	unquoted := s != "" && !strings.Contains(s, "___") && !strings.Contains(s, "//")
+	for i := 0; i < len(s) && unquoted; i++ {
+		c := s[i]
+		unquoted = c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			c == '_' || c == '.' || c == '/'
+	}
+	if unquoted {
+		return s
+	}
+
+	quoted := openstep.Quote(s)
+	if quoted[0] != '"' {
+		return `"` + quoted + `"`
+	}
+	return quoted
+}
+
+// comment returns the comment Xcode writes after references to o.
+func (e *encoder) comment(o *Object) string {
+	switch o.ISA() {
+	case "PBXProject":
+		return "Project object"
+	case "PBXBuildFile":
+		var name string
+		if ref := o.Ref("fileRef"); ref != nil {
+			name = e.comment(ref)
+		} else if ref := o.Ref("productRef"); ref != nil {
+			name = e.comment(ref)
+		}
+		if phase := e.phases[o.ID]; phase != nil {
+			return name + " in " + e.comment(phase)
+		}
+		return name
+	case "XCConfigurationList":
+		owner := e.listOwners[o.ID]
+		if owner == nil {
+			return "Build configuration list"
+		}
+		name := owner.String("name")
+		if owner.ISA() == "PBXProject" {
+			name = e.file.Name
+		}
+		return fmt.Sprintf("Build configuration list for %s %q", owner.ISA(), name)
+	case "PBXContainerItemProxy", "PBXTargetDependency":
+		return o.ISA()
+	case "XCRemoteSwiftPackageReference":
+		name := strings.TrimSuffix(path.Base(o.String("repositoryURL")), ".git")
+		return fmt.Sprintf("XCRemoteSwiftPackageReference %q", name)
+	case "XCSwiftPackageProductDependency":
+		return o.String("productName")
+	}
+
+	if name := displayName(o); name != "" {
+		return name
+	}
+	return defaultPhaseNames[o.ISA()]
+}
+
+func idValues(value interface{}) []string {
+	values, _ := value.([]interface{})
+	var ids []string
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,66 @@
+package pbxproj
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeUnchanged(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, decodeHelloWorld(t).Encode(&b))
+	assert.Equal(t, helloWorld, b.String())
+}
+
+// testdata/project.pbxproj is an iOS app with a unit test target, as Xcode
+// 15 writes it. TestTargetID and remoteGlobalIDString hold IDs, but Xcode
+// doesn't annotate them.
+func TestEncodeXcodeProject(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/project.pbxproj")
+	assert.NoError(t, err)
+	f, err := Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	var b bytes.Buffer
+	assert.NoError(t, f.Encode(&b))
+	assert.Equal(t, string(data), b.String())
+}
+
+func TestEncodeComments(t *testing.T) {
+	f := decodeHelloWorld(t)
+	f.Name = "Renamed"
+	f.Object("1A2B3C4D5E6F708192A3B4E1").Set("name", "Link Frameworks")
+	f.Object("1A2B3C4D5E6F708192A3B4D2").Set("name", "Images")
+	f.Project().Target("HelloWorld").SetBuildSetting("SOME_ID", "1A2B3C4D5E6F708192A3B500")
+
+	var b bytes.Buffer
+	assert.NoError(t, f.Encode(&b))
+	for _, line := range []string{
+		`buildConfigurationList = 1A2B3C4D5E6F708192A3B530 /* Build configuration list for PBXProject "Renamed" */;`,
+		`1A2B3C4D5E6F708192A3B4E1 /* Link Frameworks */,`,
+		`1A2B3C4D5E6F708192A3B4C2 /* Images in Resources */ = {isa = PBXBuildFile; fileRef = 1A2B3C4D5E6F708192A3B4D2 /* Images */; };`,
+		"SOME_ID = 1A2B3C4D5E6F708192A3B500;\n",
+	} {
+		assert.Contains(t, b.String(), line)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for _, test := range []struct {
+		s, expected string
+	}{
+		{"HelloWorld/Info.plist", "HelloWorld/Info.plist"},
+		{"15.0", "15.0"},
+		{"", `""`},
+		{"<group>", `"<group>"`},
+		{"$(TARGET_NAME)", `"$(TARGET_NAME)"`},
+		{"com.example.hello-world", `"com.example.hello-world"`},
+		{"___PROJECTNAME___", `"___PROJECTNAME___"`},
+		{"a//b", `"a//b"`},
+		{`say "hi"`, `"say \"hi\""`},
+	} {
+		assert.Equal(t, test.expected, quote(test.s), test.s)
+	}
+}
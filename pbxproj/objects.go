@@ -0,0 +1,395 @@
+package pbxproj
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Product types of targets.
+const (
+	ProductTypeApplication   = "com.apple.product-type.application"
+	ProductTypeFramework     = "com.apple.product-type.framework"
+	ProductTypeStaticLibrary = "com.apple.product-type.library.static"
+	ProductTypeAppExtension  = "com.apple.product-type.app-extension"
+	ProductTypeUnitTests     = "com.apple.product-type.bundle.unit-test"
+	ProductTypeUITests       = "com.apple.product-type.bundle.ui-testing"
+)
+
+// products maps product types to the file name format and file type of the
+// target's product.
+var products = map[string]struct{ format, fileType string }{
+	ProductTypeApplication:   {"%s.app", "wrapper.application"},
+	ProductTypeFramework:     {"%s.framework", "wrapper.framework"},
+	ProductTypeStaticLibrary: {"lib%s.a", "archive.ar"},
+	ProductTypeAppExtension:  {"%s.appex", "wrapper.app-extension"},
+	ProductTypeUnitTests:     {"%s.xctest", "wrapper.cfbundle"},
+	ProductTypeUITests:       {"%s.xctest", "wrapper.cfbundle"},
+}
+
+// fileTypes maps file extensions to the lastKnownFileType Xcode gives them.
+var fileTypes = map[string]string{
+	".a":            "archive.ar",
+	".c":            "sourcecode.c.c",
+	".cpp":          "sourcecode.cpp.cpp",
+	".entitlements": "text.plist.entitlements",
+	".framework":    "wrapper.framework",
+	".h":            "sourcecode.c.h",
+	".jpg":          "image.jpeg",
+	".json":         "text.json",
+	".m":            "sourcecode.c.objc",
+	".mm":           "sourcecode.cpp.objcpp",
+	".plist":        "text.plist.xml",
+	".png":          "image.png",
+	".storyboard":   "file.storyboard",
+	".strings":      "text.plist.strings",
+	".swift":        "sourcecode.swift",
+	".xcassets":     "folder.assetcatalog",
+	".xcconfig":     "text.xcconfig",
+	".xcframework":  "wrapper.xcframework",
+	".xib":          "file.xib",
+}
+
+// Build phase classes.
+const (
+	SourcesBuildPhase    = "PBXSourcesBuildPhase"
+	FrameworksBuildPhase = "PBXFrameworksBuildPhase"
+	ResourcesBuildPhase  = "PBXResourcesBuildPhase"
+	HeadersBuildPhase    = "PBXHeadersBuildPhase"
+)
+
+// PBXProject is the root object of a project.
+type PBXProject struct{ *Object }
+
+// MainGroup returns the group at the top of the project navigator.
+func (p *PBXProject) MainGroup() *PBXGroup {
+	return asGroup(p.Ref("mainGroup"))
+}
+
+// ProductsGroup returns the group holding the targets' products.
+func (p *PBXProject) ProductsGroup() *PBXGroup {
+	return asGroup(p.Ref("productRefGroup"))
+}
+
+// BuildConfigurationList returns the project-level build configurations.
+func (p *PBXProject) BuildConfigurationList() *XCConfigurationList {
+	return asConfigurationList(p.Ref("buildConfigurationList"))
+}
+
+// Targets returns the project's native targets, in order.
+func (p *PBXProject) Targets() []*PBXNativeTarget {
+	var targets []*PBXNativeTarget
+	for _, o := range p.Refs("targets") {
+		if o.ISA() == "PBXNativeTarget" {
+			targets = append(targets, &PBXNativeTarget{o})
+		}
+	}
+	return targets
+}
+
+// Target returns the native target with name, or nil.
+func (p *PBXProject) Target(name string) *PBXNativeTarget {
+	for _, target := range p.Targets() {
+		if target.Name() == name {
+			return target
+		}
+	}
+	return nil
+}
+
+/*
+AddTarget adds a native target with empty sources, frameworks and resources
+build phases, and a product in the products group. It gets a build
+configuration for each of the project's configurations, setting only
+PRODUCT_NAME.
+*/
+func (p *PBXProject) AddTarget(name, productType string) (*PBXNativeTarget, error) {
+	product, ok := products[productType]
+	if !ok {
+		return nil, fmt.Errorf("pbxproj: unsupported product type %q", productType)
+	}
+	if p.Target(name) != nil {
+		return nil, fmt.Errorf("pbxproj: target %q already exists", name)
+	}
+	f := p.file
+
+	productName := fmt.Sprintf(product.format, name)
+	productRef := f.AddObject("PBXFileReference", name, map[string]interface{}{
+		"explicitFileType": product.fileType,
+		"includeInIndex":   "0",
+		"path":             productName,
+		"sourceTree":       "BUILT_PRODUCTS_DIR",
+	})
+	if products := p.ProductsGroup(); products != nil {
+		products.AddRef("children", productRef)
+	}
+
+	var phases []interface{}
+	for _, isa := range []string{SourcesBuildPhase, FrameworksBuildPhase, ResourcesBuildPhase} {
+		phases = append(phases, addBuildPhase(f, isa, name).ID)
+	}
+
+	var configurations []interface{}
+	defaultConfiguration := "Release"
+	if list := p.BuildConfigurationList(); list != nil {
+		defaultConfiguration = list.String("defaultConfigurationName")
+		for _, projectConfiguration := range list.BuildConfigurations() {
+			configuration := f.AddObject("XCBuildConfiguration", name+" "+projectConfiguration.Name(), map[string]interface{}{
+				"buildSettings": map[string]interface{}{"PRODUCT_NAME": "$(TARGET_NAME)"},
+				"name":          projectConfiguration.Name(),
+			})
+			configurations = append(configurations, configuration.ID)
+		}
+	}
+	list := f.AddObject("XCConfigurationList", name, map[string]interface{}{
+		"buildConfigurations":           configurations,
+		"defaultConfigurationIsVisible": "0",
+		"defaultConfigurationName":      defaultConfiguration,
+	})
+
+	target := f.AddObject("PBXNativeTarget", name, map[string]interface{}{
+		"buildConfigurationList": list.ID,
+		"buildPhases":            phases,
+		"buildRules":             []interface{}{},
+		"dependencies":           []interface{}{},
+		"name":                   name,
+		"productName":            name,
+		"productReference":       productRef.ID,
+		"productType":            productType,
+	})
+	p.AddRef("targets", target)
+	return &PBXNativeTarget{target}, nil
+}
+
+// PBXNativeTarget is a target that builds a product.
+type PBXNativeTarget struct{ *Object }
+
+func (t *PBXNativeTarget) Name() string        { return t.String("name") }
+func (t *PBXNativeTarget) ProductType() string { return t.String("productType") }
+
+// BuildConfigurationList returns the target's build configurations.
+func (t *PBXNativeTarget) BuildConfigurationList() *XCConfigurationList {
+	return asConfigurationList(t.Ref("buildConfigurationList"))
+}
+
+// BuildPhase returns the target's first build phase with isa, or nil.
+func (t *PBXNativeTarget) BuildPhase(isa string) *Object {
+	for _, phase := range t.Refs("buildPhases") {
+		if phase.ISA() == isa {
+			return phase
+		}
+	}
+	return nil
+}
+
+/*
+AddBuildFile adds file to the target's build phase with isa, e.g.
+SourcesBuildPhase, adding the phase if the target doesn't have one. It returns
+the PBXBuildFile, which is the existing one if file is already in the phase.
+*/
+func (t *PBXNativeTarget) AddBuildFile(isa string, file *PBXFileReference) *Object {
+	f := t.file
+	phase := t.BuildPhase(isa)
+	if phase == nil {
+		phase = addBuildPhase(f, isa, t.Name())
+		t.AddRef("buildPhases", phase)
+	}
+
+	for _, buildFile := range phase.Refs("files") {
+		if buildFile.String("fileRef") == file.ID {
+			return buildFile
+		}
+	}
+	buildFile := f.AddObject("PBXBuildFile", phase.ID+" "+file.ID, map[string]interface{}{
+		"fileRef": file.ID,
+	})
+	phase.AddRef("files", buildFile)
+	return buildFile
+}
+
+func addBuildPhase(f *File, isa, targetName string) *Object {
+	return f.AddObject(isa, targetName, map[string]interface{}{
+		"buildActionMask":                    "2147483647",
+		"files":                              []interface{}{},
+		"runOnlyForDeploymentPostprocessing": "0",
+	})
+}
+
+// SetBuildSetting sets a build setting in all of the target's configurations.
+// See XCBuildConfiguration.SetBuildSetting.
+func (t *PBXNativeTarget) SetBuildSetting(key string, value interface{}) {
+	if list := t.BuildConfigurationList(); list != nil {
+		for _, configuration := range list.BuildConfigurations() {
+			configuration.SetBuildSetting(key, value)
+		}
+	}
+}
+
+// XCConfigurationList is the list of build configurations of a project or target.
+type XCConfigurationList struct{ *Object }
+
+func asConfigurationList(o *Object) *XCConfigurationList {
+	if o == nil || o.ISA() != "XCConfigurationList" {
+		return nil
+	}
+	return &XCConfigurationList{o}
+}
+
+// BuildConfigurations returns the configurations in the list, in order.
+func (l *XCConfigurationList) BuildConfigurations() []*XCBuildConfiguration {
+	var configurations []*XCBuildConfiguration
+	for _, o := range l.Refs("buildConfigurations") {
+		configurations = append(configurations, &XCBuildConfiguration{o})
+	}
+	return configurations
+}
+
+// BuildConfiguration returns the configuration with name, e.g. "Debug", or nil.
+func (l *XCConfigurationList) BuildConfiguration(name string) *XCBuildConfiguration {
+	for _, configuration := range l.BuildConfigurations() {
+		if configuration.Name() == name {
+			return configuration
+		}
+	}
+	return nil
+}
+
+// XCBuildConfiguration is a named set of build settings.
+type XCBuildConfiguration struct{ *Object }
+
+func (c *XCBuildConfiguration) Name() string { return c.String("name") }
+
+// BuildSettings returns the configuration's build settings. Changes to the map
+// change the configuration.
+func (c *XCBuildConfiguration) BuildSettings() map[string]interface{} {
+	settings, ok := c.Fields["buildSettings"].(map[string]interface{})
+	if !ok {
+		settings = map[string]interface{}{}
+		c.Fields["buildSettings"] = settings
+	}
+	return settings
+}
+
+// BuildSetting returns the build setting with key, which is a string or a
+// []interface{} of strings, or nil.
+func (c *XCBuildConfiguration) BuildSetting(key string) interface{} {
+	return c.BuildSettings()[key]
+}
+
+// SetBuildSetting sets a build setting to a string, or to a list given as
+// []string or []interface{}. A nil value removes the setting.
+func (c *XCBuildConfiguration) SetBuildSetting(key string, value interface{}) {
+	settings := c.BuildSettings()
+	switch v := value.(type) {
+	case nil:
+		delete(settings, key)
+		return
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, s := range v {
+			values[i] = s
+		}
+		value = values
+	}
+	settings[key] = value
+}
+
+// PBXGroup is a group of files in the project navigator.
+type PBXGroup struct{ *Object }
+
+func asGroup(o *Object) *PBXGroup {
+	if o == nil || o.ISA() != "PBXGroup" {
+		return nil
+	}
+	return &PBXGroup{o}
+}
+
+// Name returns the name Xcode shows for the group: its name, or the last
+// element of its path.
+func (g *PBXGroup) Name() string { return displayName(g.Object) }
+func (g *PBXGroup) Path() string { return g.String("path") }
+
+func displayName(o *Object) string {
+	if name := o.String("name"); name != "" {
+		return name
+	}
+	if p := o.String("path"); p != "" {
+		return path.Base(p)
+	}
+	return ""
+}
+
+// Children returns the group's files and groups, in order.
+func (g *PBXGroup) Children() []*Object {
+	return g.Refs("children")
+}
+
+// Group returns the child group shown as name, or nil.
+func (g *PBXGroup) Group(name string) *PBXGroup {
+	for _, child := range g.Children() {
+		if child.ISA() == "PBXGroup" && displayName(child) == name {
+			return &PBXGroup{child}
+		}
+	}
+	return nil
+}
+
+// File returns the file reference with path, relative to the group, or nil.
+func (g *PBXGroup) File(path string) *PBXFileReference {
+	for _, child := range g.Children() {
+		if child.ISA() == "PBXFileReference" && child.String("path") == path {
+			return &PBXFileReference{child}
+		}
+	}
+	return nil
+}
+
+// AddGroup adds a group for the directory at path, relative to g, or returns
+// the existing one.
+func (g *PBXGroup) AddGroup(path string) *PBXGroup {
+	for _, child := range g.Children() {
+		if child.ISA() == "PBXGroup" && child.String("path") == path {
+			return &PBXGroup{child}
+		}
+	}
+	group := g.file.AddObject("PBXGroup", g.ID+" "+path, map[string]interface{}{
+		"children":   []interface{}{},
+		"path":       path,
+		"sourceTree": "<group>",
+	})
+	g.AddRef("children", group)
+	return &PBXGroup{group}
+}
+
+// AddFile adds a reference to the file at path, relative to g, or returns the
+// existing one. Its file type is guessed from its extension.
+func (g *PBXGroup) AddFile(filePath string) *PBXFileReference {
+	if file := g.File(filePath); file != nil {
+		return file
+	}
+
+	fileType, ok := fileTypes[strings.ToLower(path.Ext(filePath))]
+	if !ok {
+		fileType = "file"
+	}
+	fields := map[string]interface{}{
+		"lastKnownFileType": fileType,
+		"path":              filePath,
+		"sourceTree":        "<group>",
+	}
+	if strings.Contains(filePath, "/") {
+		fields["name"] = path.Base(filePath)
+	}
+	file := g.file.AddObject("PBXFileReference", g.ID+" "+filePath, fields)
+	g.AddRef("children", file)
+	return &PBXFileReference{file}
+}
+
+// PBXFileReference is a file in the project navigator.
+type PBXFileReference struct{ *Object }
+
+// Name returns the name Xcode shows for the file: its name, or the last
+// element of its path.
+func (r *PBXFileReference) Name() string       { return displayName(r.Object) }
+func (r *PBXFileReference) Path() string       { return r.String("path") }
+func (r *PBXFileReference) SourceTree() string { return r.String("sourceTree") }
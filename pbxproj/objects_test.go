@@ -0,0 +1,132 @@
+package pbxproj
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNavigate(t *testing.T) {
+	p := decodeHelloWorld(t).Project()
+
+	main := p.MainGroup()
+	assert.Equal(t, "1A2B3C4D5E6F708192A3B4F0", main.ID)
+	assert.Equal(t, "", main.Name())
+	group := main.Group("HelloWorld")
+	assert.Equal(t, "HelloWorld", group.Path())
+	assert.Nil(t, main.Group("Missing"))
+	assert.Equal(t, "Info.plist", group.File("Info.plist").Name())
+	assert.Nil(t, group.File("Missing.swift"))
+	assert.Equal(t, "Products", p.ProductsGroup().Name())
+
+	targets := p.Targets()
+	assert.Len(t, targets, 1)
+	target := p.Target("HelloWorld")
+	assert.Equal(t, targets[0], target)
+	assert.Equal(t, ProductTypeApplication, target.ProductType())
+	assert.Equal(t, "PBXSourcesBuildPhase", target.BuildPhase(SourcesBuildPhase).ISA())
+	assert.Nil(t, target.BuildPhase(HeadersBuildPhase))
+	assert.Nil(t, p.Target("Missing"))
+
+	debug := target.BuildConfigurationList().BuildConfiguration("Debug")
+	assert.Equal(t, "com.example.hello-world", debug.BuildSetting("PRODUCT_BUNDLE_IDENTIFIER"))
+	assert.Equal(t, []interface{}{"DEBUG=1", "$(inherited)"},
+		p.BuildConfigurationList().BuildConfiguration("Debug").BuildSetting("GCC_PREPROCESSOR_DEFINITIONS"))
+	assert.Nil(t, target.BuildConfigurationList().BuildConfiguration("Profile"))
+}
+
+func TestAddFile(t *testing.T) {
+	f := decodeHelloWorld(t)
+	p := f.Project()
+	group := p.MainGroup().Group("HelloWorld")
+	target := p.Target("HelloWorld")
+
+	views := group.AddGroup("Views")
+	file := views.AddFile("Main/ContentView.swift")
+	buildFile := target.AddBuildFile(SourcesBuildPhase, file)
+	header := target.AddBuildFile(HeadersBuildPhase, group.AddFile("Bridging.h"))
+
+	// Doing it again finds the same objects.
+	assert.Equal(t, views, group.AddGroup("Views"))
+	assert.Equal(t, file, views.AddFile("Main/ContentView.swift"))
+	assert.Equal(t, buildFile, target.AddBuildFile(SourcesBuildPhase, file))
+	assert.Len(t, f.Objects, 26)
+
+	var b bytes.Buffer
+	assert.NoError(t, f.Encode(&b))
+	for _, line := range []string{
+		buildFile.ID + ` /* ContentView.swift in Sources */ = {isa = PBXBuildFile; fileRef = ` + file.ID + ` /* ContentView.swift */; };`,
+		file.ID + ` /* ContentView.swift */ = {isa = PBXFileReference; lastKnownFileType = sourcecode.swift; name = ContentView.swift; path = Main/ContentView.swift; sourceTree = "<group>"; };`,
+		header.ID + ` /* Bridging.h in Headers */,`,
+		`				1A2B3C4D5E6F708192A3B4D3 /* Info.plist */,
+				` + views.ID + ` /* Views */,
+				` + group.File("Bridging.h").ID + ` /* Bridging.h */,
+			);`,
+		`				1A2B3C4D5E6F708192A3B4E3 /* Resources */,
+				` + target.BuildPhase(HeadersBuildPhase).ID + ` /* Headers */,
+			);`,
+	} {
+		assert.Contains(t, b.String(), line)
+	}
+
+	// The same edits to another copy of the project give the same file.
+	f2 := decodeHelloWorld(t)
+	group2 := f2.Project().MainGroup().Group("HelloWorld")
+	target2 := f2.Project().Target("HelloWorld")
+	target2.AddBuildFile(SourcesBuildPhase, group2.AddGroup("Views").AddFile("Main/ContentView.swift"))
+	target2.AddBuildFile(HeadersBuildPhase, group2.AddFile("Bridging.h"))
+	var b2 bytes.Buffer
+	assert.NoError(t, f2.Encode(&b2))
+	assert.Equal(t, b.String(), b2.String())
+}
+
+func TestAddTarget(t *testing.T) {
+	f := decodeHelloWorld(t)
+	p := f.Project()
+
+	target, err := p.AddTarget("HelloWorldTests", ProductTypeUnitTests)
+	assert.NoError(t, err)
+	assert.Equal(t, target, p.Target("HelloWorldTests"))
+	assert.Equal(t, "HelloWorldTests.xctest", target.Ref("productReference").String("path"))
+	assert.Equal(t, target.Ref("productReference"), p.ProductsGroup().Children()[1])
+	for _, isa := range []string{SourcesBuildPhase, FrameworksBuildPhase, ResourcesBuildPhase} {
+		assert.NotNil(t, target.BuildPhase(isa), isa)
+	}
+
+	list := target.BuildConfigurationList()
+	assert.Equal(t, "Release", list.String("defaultConfigurationName"))
+	var names []string
+	for _, configuration := range list.BuildConfigurations() {
+		names = append(names, configuration.Name())
+		assert.Equal(t, map[string]interface{}{"PRODUCT_NAME": "$(TARGET_NAME)"}, configuration.BuildSettings())
+	}
+	assert.Equal(t, []string{"Debug", "Release"}, names)
+
+	var b bytes.Buffer
+	assert.NoError(t, f.Encode(&b))
+	assert.Contains(t, b.String(), "\t\t"+list.ID+` /* Build configuration list for PBXNativeTarget "HelloWorldTests" */ = {`)
+	assert.Contains(t, b.String(), `productType = "com.apple.product-type.bundle.unit-test";`)
+
+	_, err = p.AddTarget("HelloWorldTests", ProductTypeUnitTests)
+	assert.EqualError(t, err, `pbxproj: target "HelloWorldTests" already exists`)
+	_, err = p.AddTarget("Tool", "com.apple.product-type.tool")
+	assert.EqualError(t, err, `pbxproj: unsupported product type "com.apple.product-type.tool"`)
+}
+
+func TestSetBuildSetting(t *testing.T) {
+	target := decodeHelloWorld(t).Project().Target("HelloWorld")
+	target.SetBuildSetting("OTHER_LDFLAGS", []string{"-ObjC", "$(inherited)"})
+	target.SetBuildSetting("SWIFT_VERSION", "5.0")
+	target.SetBuildSetting("INFOPLIST_FILE", nil)
+
+	for _, configuration := range target.BuildConfigurationList().BuildConfigurations() {
+		assert.Equal(t, map[string]interface{}{
+			"CODE_SIGN_IDENTITY[sdk=iphoneos*]": "iPhone Developer",
+			"OTHER_LDFLAGS":                     []interface{}{"-ObjC", "$(inherited)"},
+			"PRODUCT_BUNDLE_IDENTIFIER":         "com.example.hello-world",
+			"PRODUCT_NAME":                      "$(TARGET_NAME)",
+			"SWIFT_VERSION":                     "5.0",
+		}, configuration.BuildSettings())
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Package pbxproj reads, edits and writes Xcode project files (the project.pbxproj
+file inside an .xcodeproj directory).
+
+A project file is a graph of objects in OpenStep format, each identified by 24
+hex digits and referring to others by ID. File holds the objects, and typed
+views like PBXProject, PBXNativeTarget and PBXGroup navigate and edit the graph.
+Objects keep all of their fields, including ones this package doesn't know
+about, so editing a project only changes what was edited.
+
+Encode writes files the way Xcode does: objects grouped into sections by isa,
+sorted by ID, with references annotated by comments naming what they refer to.
+Writing a file Xcode saved without editing it gives back the same bytes, so
+diffs stay small.
+
+Objects added by this package get IDs derived from where they were added, e.g.
+a file reference's ID depends on its group and path, so running the same edit
+twice on the same project gives the same result.
+*/
+package pbxproj
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/zach-klippenstein/goplist/openstep"
+)
+
+// File is a project.pbxproj file.
+type File struct {
+	// Name is the name of the project, i.e. of the .xcodeproj directory without
+	// its extension. Xcode only uses it in a comment. Decode recovers it from
+	// that comment.
+	Name string
+
+	ArchiveVersion string
+	ObjectVersion  string
+	Classes        map[string]interface{}
+	Objects        map[string]*Object
+	// RootObject is the ID of the PBXProject.
+	RootObject string
+}
+
+/*
+Object is an object in a project. Fields holds all of its fields, including
+isa, as decoded by openstep.Unmarshal: values are strings, []interface{} or
+map[string]interface{}. References to other objects are their IDs.
+*/
+type Object struct {
+	ID     string
+	Fields map[string]interface{}
+
+	file *File
+}
+
+// Decode reads a project file from r.
+func Decode(r io.Reader) (*File, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	value, err := openstep.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	plist, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("pbxproj: project must be a dict, found %T", value)
+	}
+
+	f := &File{
+		Classes: map[string]interface{}{},
+		Objects: map[string]*Object{},
+	}
+	for key, field := range map[string]*string{
+		"archiveVersion": &f.ArchiveVersion,
+		"objectVersion":  &f.ObjectVersion,
+		"rootObject":     &f.RootObject,
+	} {
+		value, ok := plist[key].(string)
+		if !ok {
+			return nil, fmt.Errorf("pbxproj: %s must be a string, found %T", key, plist[key])
+		}
+		*field = value
+	}
+	if classes, ok := plist["classes"].(map[string]interface{}); ok {
+		f.Classes = classes
+	}
+
+	objects, ok := plist["objects"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("pbxproj: objects must be a dict, found %T", plist["objects"])
+	}
+	for id, value := range objects {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("pbxproj: object %s must be a dict, found %T", id, value)
+		}
+		if _, ok := fields["isa"].(string); !ok {
+			return nil, fmt.Errorf("pbxproj: object %s has no isa", id)
+		}
+		f.Objects[id] = &Object{ID: id, Fields: fields, file: f}
+	}
+	if f.Project() == nil {
+		return nil, fmt.Errorf("pbxproj: root object %s is not a PBXProject", f.RootObject)
+	}
+
+	f.Name = projectName(data, f.Project().String("buildConfigurationList"))
+	return f, nil
+}
+
+// projectName finds the project's name in the comment after the ID of its
+// configuration list.
+func projectName(data []byte, listID string) string {
+	const prefix = `Build configuration list for PBXProject "`
+
+	text, _, err := openstep.DecodeText(data)
+	if err != nil {
+		return ""
+	}
+	s := openstep.NewScanner(text)
+	var previous openstep.Token
+	for {
+		token, err := s.Next()
+		if err != nil {
+			return ""
+		}
+		if token.Kind == openstep.TokenComment && previous.Kind == openstep.TokenString && previous.Value == listID {
+			comment := strings.TrimSpace(token.Value)
+			if strings.HasPrefix(comment, prefix) && strings.HasSuffix(comment, `"`) {
+				return comment[len(prefix) : len(comment)-1]
+			}
+		}
+		previous = token
+	}
+}
+
+// Object returns the object with id, or nil.
+func (f *File) Object(id string) *Object {
+	return f.Objects[id]
+}
+
+// Project returns the root object.
+func (f *File) Project() *PBXProject {
+	o := f.Object(f.RootObject)
+	if o == nil || o.ISA() != "PBXProject" {
+		return nil
+	}
+	return &PBXProject{o}
+}
+
+// ObjectsWithISA returns the objects with isa, sorted by ID.
+func (f *File) ObjectsWithISA(isa string) []*Object {
+	var objects []*Object
+	for _, o := range f.Objects {
+		if o.ISA() == isa {
+			objects = append(objects, o)
+		}
+	}
+	sort.Sort(byID(objects))
+	return objects
+}
+
+type byID []*Object
+
+func (s byID) Len() int           { return len(s) }
+func (s byID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s byID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+/*
+AddObject adds an object with isa and fields to the project. Its ID is derived
+from isa and seed, which should identify the object among the others of its
+type, e.g. by its parent's ID and its name, so the same edit gives the same ID
+every time. If the ID is taken, another one is derived.
+*/
+func (f *File) AddObject(isa, seed string, fields map[string]interface{}) *Object {
+	o := &Object{ID: f.newID(isa + " " + seed), Fields: map[string]interface{}{"isa": isa}, file: f}
+	for key, value := range fields {
+		o.Fields[key] = value
+	}
+	f.Objects[o.ID] = o
+	return o
+}
+
+func (f *File) newID(seed string) string {
+	for i := 0; ; i++ {
+		input := seed
+		if i > 0 {
+			input = fmt.Sprintf("%s %d", seed, i)
+		}
+		sum := sha1.Sum([]byte(input))
+		id := strings.ToUpper(hex.EncodeToString(sum[:12]))
+		if _, ok := f.Objects[id]; !ok {
+			return id
+		}
+	}
+}
+
+// ISA returns the object's class.
+func (o *Object) ISA() string {
+	return o.String("isa")
+}
+
+// String returns the field with key if it's a string, or "".
+func (o *Object) String(key string) string {
+	value, _ := o.Fields[key].(string)
+	return value
+}
+
+// Set sets the field with key. Values must be strings, []interface{} or
+// map[string]interface{}.
+func (o *Object) Set(key string, value interface{}) {
+	o.Fields[key] = value
+}
+
+// Ref returns the object the field with key refers to, or nil.
+func (o *Object) Ref(key string) *Object {
+	return o.file.Object(o.String(key))
+}
+
+// Refs returns the objects the array field with key refers to. IDs of objects
+// that don't exist are skipped.
+func (o *Object) Refs(key string) []*Object {
+	values, _ := o.Fields[key].([]interface{})
+	var objects []*Object
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			if ref := o.file.Object(id); ref != nil {
+				objects = append(objects, ref)
+			}
+		}
+	}
+	return objects
+}
+
+// AddRef appends the ID of ref to the array field with key, creating the array
+// if needed.
+func (o *Object) AddRef(key string, ref *Object) {
+	values, _ := o.Fields[key].([]interface{})
+	o.Fields[key] = append(values, ref.ID)
+}
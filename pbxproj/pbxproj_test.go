@@ -0,0 +1,270 @@
+package pbxproj
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const helloWorld = `// !$*UTF8*$!
+{
+	archiveVersion = 1;
+	classes = {
+	};
+	objectVersion = 56;
+	objects = {
+
+/* Begin PBXBuildFile section */
+		1A2B3C4D5E6F708192A3B4C1 /* AppDelegate.swift in Sources */ = {isa = PBXBuildFile; fileRef = 1A2B3C4D5E6F708192A3B4D1 /* AppDelegate.swift */; };
+		1A2B3C4D5E6F708192A3B4C2 /* Assets.xcassets in Resources */ = {isa = PBXBuildFile; fileRef = 1A2B3C4D5E6F708192A3B4D2 /* Assets.xcassets */; };
+/* End PBXBuildFile section */
+
+/* Begin PBXFileReference section */
+		1A2B3C4D5E6F708192A3B4D0 /* HelloWorld.app */ = {isa = PBXFileReference; explicitFileType = wrapper.application; includeInIndex = 0; path = HelloWorld.app; sourceTree = BUILT_PRODUCTS_DIR; };
+		1A2B3C4D5E6F708192A3B4D1 /* AppDelegate.swift */ = {isa = PBXFileReference; lastKnownFileType = sourcecode.swift; path = AppDelegate.swift; sourceTree = "<group>"; };
+		1A2B3C4D5E6F708192A3B4D2 /* Assets.xcassets */ = {isa = PBXFileReference; lastKnownFileType = folder.assetcatalog; path = Assets.xcassets; sourceTree = "<group>"; };
+		1A2B3C4D5E6F708192A3B4D3 /* Info.plist */ = {isa = PBXFileReference; lastKnownFileType = text.plist.xml; path = Info.plist; sourceTree = "<group>"; };
+/* End PBXFileReference section */
+
+/* Begin PBXFrameworksBuildPhase section */
+		1A2B3C4D5E6F708192A3B4E1 /* Frameworks */ = {
+			isa = PBXFrameworksBuildPhase;
+			buildActionMask = 2147483647;
+			files = (
+			);
+			runOnlyForDeploymentPostprocessing = 0;
+		};
+/* End PBXFrameworksBuildPhase section */
+
+/* Begin PBXGroup section */
+		1A2B3C4D5E6F708192A3B4F0 = {
+			isa = PBXGroup;
+			children = (
+				1A2B3C4D5E6F708192A3B4F1 /* HelloWorld */,
+				1A2B3C4D5E6F708192A3B4F2 /* Products */,
+			);
+			sourceTree = "<group>";
+		};
+		1A2B3C4D5E6F708192A3B4F1 /* HelloWorld */ = {
+			isa = PBXGroup;
+			children = (
+				1A2B3C4D5E6F708192A3B4D1 /* AppDelegate.swift */,
+				1A2B3C4D5E6F708192A3B4D2 /* Assets.xcassets */,
+				1A2B3C4D5E6F708192A3B4D3 /* Info.plist */,
+			);
+			path = HelloWorld;
+			sourceTree = "<group>";
+		};
+		1A2B3C4D5E6F708192A3B4F2 /* Products */ = {
+			isa = PBXGroup;
+			children = (
+				1A2B3C4D5E6F708192A3B4D0 /* HelloWorld.app */,
+			);
+			name = Products;
+			sourceTree = "<group>";
+		};
+/* End PBXGroup section */
+
+/* Begin PBXNativeTarget section */
+		1A2B3C4D5E6F708192A3B500 /* HelloWorld */ = {
+			isa = PBXNativeTarget;
+			buildConfigurationList = 1A2B3C4D5E6F708192A3B531 /* Build configuration list for PBXNativeTarget "HelloWorld" */;
+			buildPhases = (
+				1A2B3C4D5E6F708192A3B4E2 /* Sources */,
+				1A2B3C4D5E6F708192A3B4E1 /* Frameworks */,
+				1A2B3C4D5E6F708192A3B4E3 /* Resources */,
+			);
+			buildRules = (
+			);
+			dependencies = (
+			);
+			name = HelloWorld;
+			productName = HelloWorld;
+			productReference = 1A2B3C4D5E6F708192A3B4D0 /* HelloWorld.app */;
+			productType = "com.apple.product-type.application";
+		};
+/* End PBXNativeTarget section */
+
+/* Begin PBXProject section */
+		1A2B3C4D5E6F708192A3B510 /* Project object */ = {
+			isa = PBXProject;
+			attributes = {
+				BuildIndependentTargetsInParallel = 1;
+				LastSwiftUpdateCheck = 1500;
+				LastUpgradeCheck = 1500;
+				TargetAttributes = {
+					1A2B3C4D5E6F708192A3B500 = {
+						CreatedOnToolsVersion = 15.0;
+					};
+				};
+			};
+			buildConfigurationList = 1A2B3C4D5E6F708192A3B530 /* Build configuration list for PBXProject "HelloWorld" */;
+			compatibilityVersion = "Xcode 14.0";
+			developmentRegion = en;
+			hasScannedForEncodings = 0;
+			knownRegions = (
+				en,
+				Base,
+			);
+			mainGroup = 1A2B3C4D5E6F708192A3B4F0;
+			productRefGroup = 1A2B3C4D5E6F708192A3B4F2 /* Products */;
+			projectDirPath = "";
+			projectRoot = "";
+			targets = (
+				1A2B3C4D5E6F708192A3B500 /* HelloWorld */,
+			);
+		};
+/* End PBXProject section */
+
+/* Begin PBXResourcesBuildPhase section */
+		1A2B3C4D5E6F708192A3B4E3 /* Resources */ = {
+			isa = PBXResourcesBuildPhase;
+			buildActionMask = 2147483647;
+			files = (
+				1A2B3C4D5E6F708192A3B4C2 /* Assets.xcassets in Resources */,
+			);
+			runOnlyForDeploymentPostprocessing = 0;
+		};
+/* End PBXResourcesBuildPhase section */
+
+/* Begin PBXSourcesBuildPhase section */
+		1A2B3C4D5E6F708192A3B4E2 /* Sources */ = {
+			isa = PBXSourcesBuildPhase;
+			buildActionMask = 2147483647;
+			files = (
+				1A2B3C4D5E6F708192A3B4C1 /* AppDelegate.swift in Sources */,
+			);
+			runOnlyForDeploymentPostprocessing = 0;
+		};
+/* End PBXSourcesBuildPhase section */
+
+/* Begin XCBuildConfiguration section */
+		1A2B3C4D5E6F708192A3B520 /* Debug */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				ALWAYS_SEARCH_USER_PATHS = NO;
+				GCC_PREPROCESSOR_DEFINITIONS = (
+					"DEBUG=1",
+					"$(inherited)",
+				);
+				SDKROOT = iphoneos;
+				SWIFT_ACTIVE_COMPILATION_CONDITIONS = "DEBUG $(inherited)";
+			};
+			name = Debug;
+		};
+		1A2B3C4D5E6F708192A3B521 /* Release */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				ALWAYS_SEARCH_USER_PATHS = NO;
+				SDKROOT = iphoneos;
+				VALIDATE_PRODUCT = YES;
+			};
+			name = Release;
+		};
+		1A2B3C4D5E6F708192A3B522 /* Debug */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				"CODE_SIGN_IDENTITY[sdk=iphoneos*]" = "iPhone Developer";
+				INFOPLIST_FILE = HelloWorld/Info.plist;
+				PRODUCT_BUNDLE_IDENTIFIER = "com.example.hello-world";
+				PRODUCT_NAME = "$(TARGET_NAME)";
+			};
+			name = Debug;
+		};
+		1A2B3C4D5E6F708192A3B523 /* Release */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				"CODE_SIGN_IDENTITY[sdk=iphoneos*]" = "iPhone Developer";
+				INFOPLIST_FILE = HelloWorld/Info.plist;
+				PRODUCT_BUNDLE_IDENTIFIER = "com.example.hello-world";
+				PRODUCT_NAME = "$(TARGET_NAME)";
+			};
+			name = Release;
+		};
+/* End XCBuildConfiguration section */
+
+/* Begin XCConfigurationList section */
+		1A2B3C4D5E6F708192A3B530 /* Build configuration list for PBXProject "HelloWorld" */ = {
+			isa = XCConfigurationList;
+			buildConfigurations = (
+				1A2B3C4D5E6F708192A3B520 /* Debug */,
+				1A2B3C4D5E6F708192A3B521 /* Release */,
+			);
+			defaultConfigurationIsVisible = 0;
+			defaultConfigurationName = Release;
+		};
+		1A2B3C4D5E6F708192A3B531 /* Build configuration list for PBXNativeTarget "HelloWorld" */ = {
+			isa = XCConfigurationList;
+			buildConfigurations = (
+				1A2B3C4D5E6F708192A3B522 /* Debug */,
+				1A2B3C4D5E6F708192A3B523 /* Release */,
+			);
+			defaultConfigurationIsVisible = 0;
+			defaultConfigurationName = Release;
+		};
+/* End XCConfigurationList section */
+	};
+	rootObject = 1A2B3C4D5E6F708192A3B510 /* Project object */;
+}
+`
+
+func decodeHelloWorld(t *testing.T) *File {
+	f, err := Decode(strings.NewReader(helloWorld))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return f
+}
+
+func TestDecode(t *testing.T) {
+	f := decodeHelloWorld(t)
+	assert.Equal(t, "HelloWorld", f.Name)
+	assert.Equal(t, "1", f.ArchiveVersion)
+	assert.Equal(t, "56", f.ObjectVersion)
+	assert.Equal(t, map[string]interface{}{}, f.Classes)
+	assert.Len(t, f.Objects, 20)
+
+	target := f.Object("1A2B3C4D5E6F708192A3B500")
+	assert.Equal(t, "PBXNativeTarget", target.ISA())
+	assert.Equal(t, "HelloWorld", target.String("name"))
+	assert.Equal(t, "1A2B3C4D5E6F708192A3B4D0", target.Ref("productReference").ID)
+
+	var phases []string
+	for _, phase := range target.Refs("buildPhases") {
+		phases = append(phases, phase.ISA())
+	}
+	assert.Equal(t, []string{"PBXSourcesBuildPhase", "PBXFrameworksBuildPhase", "PBXResourcesBuildPhase"}, phases)
+
+	assert.Len(t, f.ObjectsWithISA("XCBuildConfiguration"), 4)
+	assert.Equal(t, "1A2B3C4D5E6F708192A3B520", f.ObjectsWithISA("XCBuildConfiguration")[0].ID)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, test := range []struct {
+		text, expected string
+	}{
+		{"(a)", "pbxproj: project must be a dict, found []interface {}"},
+		{"{archiveVersion = 1; objectVersion = 56; objects = {}; }", "pbxproj: rootObject must be a string, found <nil>"},
+		{"{archiveVersion = 1; objectVersion = 56; rootObject = A; objects = (); }", "pbxproj: objects must be a dict, found []interface {}"},
+		{"{archiveVersion = 1; objectVersion = 56; rootObject = A; objects = {A = {name = x;};}; }", "pbxproj: object A has no isa"},
+		{"{archiveVersion = 1; objectVersion = 56; rootObject = A; objects = {A = {isa = PBXGroup;};}; }", "pbxproj: root object A is not a PBXProject"},
+		{"{archiveVersion = 1", "openstep: line 1: unexpected end of input"},
+	} {
+		_, err := Decode(strings.NewReader(test.text))
+		assert.EqualError(t, err, test.expected, test.text)
+	}
+}
+
+func TestAddObject(t *testing.T) {
+	f := decodeHelloWorld(t)
+	o := f.AddObject("PBXGroup", "seed", map[string]interface{}{"name": "Group"})
+	assert.Regexp(t, "^[0-9A-F]{24}$", o.ID)
+	assert.Equal(t, map[string]interface{}{"isa": "PBXGroup", "name": "Group"}, o.Fields)
+	assert.Equal(t, o, f.Object(o.ID))
+
+	// The same seed in another copy of the project gives the same ID, and a
+	// different ID if it's taken.
+	assert.Equal(t, o.ID, decodeHelloWorld(t).AddObject("PBXGroup", "seed", nil).ID)
+	assert.NotEqual(t, o.ID, f.AddObject("PBXGroup", "seed", nil).ID)
+}
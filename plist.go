@@ -1,4 +1,107 @@
 /*
 Package plist implements Apple's plist format.
+
+The xml and binary subpackages read and write the two formats plists are stored
+in. Unmarshal and Marshal in this package work with either, for callers that
+read plists without knowing which format they're in and write them back the
+same way. With Go 1.18 or later, As and Get convert decoded values, and the
+values at paths in them, to Go types.
 */
 package plist
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/zach-klippenstein/goplist/binary"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Format is the format a plist is stored in.
+type Format int
+
+const (
+	XMLFormat Format = iota
+	BinaryFormat
+)
+
+func (f Format) String() string {
+	switch f {
+	case XMLFormat:
+		return "XML"
+	case BinaryFormat:
+		return "binary"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Unmarshal parses a plist in XML or binary format, and returns its root value
+// and the format it was in. Values have the types xml.PlistDecoder.DecodeValue
+// returns.
+func Unmarshal(data []byte) (interface{}, Format, error) {
+	if binary.IsBinary(data) {
+		value, err := binary.Unmarshal(data)
+		return value, BinaryFormat, err
+	}
+	value, err := xml.NewDecoder(bytes.NewReader(data)).DecodeValue()
+	return value, XMLFormat, err
+}
+
+/*
+Marshal returns value as a plist in format. It accepts the values
+xml.DictEncoder.WriteValue does, but XML plists must have a dict or an array at
+the root.
+*/
+func Marshal(value interface{}, format Format) ([]byte, error) {
+	switch format {
+	case BinaryFormat:
+		return binary.Marshal(value)
+	case XMLFormat:
+		var b bytes.Buffer
+		if err := encodeXML(&b, value); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	return nil, fmt.Errorf("plist: unknown format %s", format)
+}
+
+func encodeXML(b *bytes.Buffer, value interface{}) error {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return fmt.Errorf("plist: cannot encode nil value")
+	}
+
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		return xml.EncodeDictPlist(b, func(e *xml.DictEncoder) error {
+			for _, key := range keys {
+				entry := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+				if err := e.WriteValue(key, entry.Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8:
+		return xml.EncodeArrayPlist(b, func(e *xml.ArrayEncoder) error {
+			for i := 0; i < v.Len(); i++ {
+				if err := e.WriteValue(v.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return fmt.Errorf("plist: XML plists must have a dict or an array at the root, not %s", v.Type())
+}
@@ -0,0 +1,47 @@
+package plist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	value := map[string]interface{}{
+		"Name":  "Example",
+		"Count": int64(3),
+		"Items": []interface{}{"a", true},
+	}
+
+	for _, format := range []Format{XMLFormat, BinaryFormat} {
+		data, err := Marshal(value, format)
+		assert.NoError(t, err, format.String())
+
+		decoded, decodedFormat, err := Unmarshal(data)
+		assert.NoError(t, err, format.String())
+		assert.Equal(t, format, decodedFormat)
+		assert.Equal(t, value, decoded, format.String())
+	}
+}
+
+func TestMarshalXMLArray(t *testing.T) {
+	data, err := Marshal([]string{"a"}, XMLFormat)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "\t<array>\n\t\t<string>a</string>\n\t</array>")
+}
+
+func TestMarshalErrors(t *testing.T) {
+	_, err := Marshal("root", XMLFormat)
+	assert.EqualError(t, err, "plist: XML plists must have a dict or an array at the root, not string")
+
+	_, err = Marshal(nil, XMLFormat)
+	assert.EqualError(t, err, "plist: cannot encode nil value")
+
+	_, err = Marshal(map[string]interface{}{}, Format(5))
+	assert.EqualError(t, err, "plist: unknown format Format(5)")
+}
+
+func TestFormatString(t *testing.T) {
+	assert.Equal(t, "XML", XMLFormat.String())
+	assert.Equal(t, "binary", BinaryFormat.String())
+}
@@ -0,0 +1,175 @@
+/*
+Package schema validates plists against schemas that describe the keys and
+values they must have.
+
+Schemas are plists themselves, modeled on JSON Schema but with plist types.
+A schema is a dict with any of these keys:
+
+	type                  the type of the value: string, integer, real, number
+	                      (an integer or a real), boolean, date, data, array or
+	                      dict. Values of any type match if it's missing.
+	enum                  an array of the values the value must be one of.
+	pattern               a regular expression that strings must contain a
+	                      match for. Use ^ and $ to match the whole string.
+	items                 the schema of an array's items.
+	properties            a dict of the schemas of a dict's values, by key.
+	required              an array of the keys a dict must have.
+	additionalProperties  false if a dict must not have keys other than those
+	                      in properties, or the schema of their values.
+	description           ignored, for documenting the schema.
+
+For example, this schema requires a dict with a CFBundleIdentifier string, and
+an optional array of integer ports:
+
+	<dict>
+		<key>type</key><string>dict</string>
+		<key>required</key><array><string>CFBundleIdentifier</string></array>
+		<key>properties</key>
+		<dict>
+			<key>CFBundleIdentifier</key>
+			<dict>
+				<key>type</key><string>string</string>
+				<key>pattern</key><string>^[A-Za-z0-9.-]+$</string>
+			</dict>
+			<key>Ports</key>
+			<dict>
+				<key>type</key><string>array</string>
+				<key>items</key><dict><key>type</key><string>integer</string></dict>
+			</dict>
+		</dict>
+	</dict>
+*/
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	plist "github.com/zach-klippenstein/goplist"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Schema describes the values a plist, or a value in one, may have.
+type Schema struct {
+	Type        string             `plist:"type"`
+	Enum        []interface{}      `plist:"enum"`
+	Pattern     string             `plist:"pattern"`
+	Items       *Schema            `plist:"items"`
+	Properties  map[string]*Schema `plist:"properties"`
+	Required    []string           `plist:"required"`
+	Additional  *Additional        `plist:"additionalProperties"`
+	Description string             `plist:"description"`
+
+	Unknown map[string]interface{} `plist:",unknown"`
+
+	pattern *regexp.Regexp
+}
+
+/*
+Additional is the value of a schema's additionalProperties key. Keys that
+aren't in the schema's properties are not allowed if Allowed is false, and
+their values must match Schema if it's not nil.
+*/
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalPlist implements xml.Unmarshaler for a boolean or a schema.
+func (a *Additional) UnmarshalPlist(value interface{}) error {
+	switch value := value.(type) {
+	case bool:
+		*a = Additional{Allowed: value}
+		return nil
+	case map[string]interface{}:
+		a.Allowed = true
+		a.Schema = new(Schema)
+		return xml.UnmarshalValue(value, a.Schema)
+	}
+	return fmt.Errorf("additionalProperties must be a boolean or a dict")
+}
+
+var types = map[string]bool{
+	"string": true, "integer": true, "real": true, "number": true, "boolean": true,
+	"date": true, "data": true, "array": true, "dict": true,
+}
+
+// Parse parses a schema from a plist in XML or binary format.
+func Parse(data []byte) (*Schema, error) {
+	value, _, err := plist.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("schema: %v", err)
+	}
+	return ParseValue(value)
+}
+
+// ParseValue parses a schema from a value as returned by xml.PlistDecoder.DecodeValue.
+func ParseValue(value interface{}) (*Schema, error) {
+	if _, ok := value.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("schema: must be a dict")
+	}
+	s := new(Schema)
+	if err := xml.UnmarshalValue(value, s); err != nil {
+		return nil, fmt.Errorf("schema: %v", err)
+	}
+	if err := s.compile(""); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// compile checks the schema at path and its subschemas, and compiles their patterns.
+func (s *Schema) compile(path string) error {
+	if len(s.Unknown) > 0 {
+		keys := make([]string, 0, len(s.Unknown))
+		for key := range s.Unknown {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return s.errorf(path, "unknown key %q", keys[0])
+	}
+	if s.Type != "" && !types[s.Type] {
+		return s.errorf(path, "unknown type %q", s.Type)
+	}
+	if s.Pattern != "" {
+		var err error
+		if s.pattern, err = regexp.Compile(s.Pattern); err != nil {
+			return s.errorf(path, "invalid pattern: %v", err)
+		}
+	}
+
+	if s.Items != nil {
+		if err := s.Items.compile(joinPath(path, "items")); err != nil {
+			return err
+		}
+	}
+	keys := make([]string, 0, len(s.Properties))
+	for key := range s.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := s.Properties[key].compile(joinPath(path, "properties."+key)); err != nil {
+			return err
+		}
+	}
+	if s.Additional != nil && s.Additional.Schema != nil {
+		return s.Additional.Schema.compile(joinPath(path, "additionalProperties"))
+	}
+	return nil
+}
+
+func (s *Schema) errorf(path, format string, args ...interface{}) error {
+	if path == "" {
+		return fmt.Errorf("schema: "+format, args...)
+	}
+	return fmt.Errorf("schema: %s: "+format, append([]interface{}{path}, args...)...)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const bundleSchema = `<plist><dict>
+	<key>type</key><string>dict</string>
+	<key>description</key><string>A bundle's Info.plist.</string>
+	<key>required</key><array><string>CFBundleIdentifier</string><string>CFBundleVersion</string></array>
+	<key>properties</key>
+	<dict>
+		<key>CFBundleIdentifier</key>
+		<dict><key>type</key><string>string</string><key>pattern</key><string>^[A-Za-z0-9.-]+$</string></dict>
+		<key>CFBundleVersion</key>
+		<dict><key>type</key><string>number</string></dict>
+		<key>LSApplicationCategoryType</key>
+		<dict><key>enum</key><array><string>public.app-category.games</string><integer>1</integer></array></dict>
+		<key>URLTypes</key>
+		<dict>
+			<key>type</key><string>array</string>
+			<key>items</key>
+			<dict>
+				<key>type</key><string>dict</string>
+				<key>required</key><array><string>Name</string></array>
+				<key>additionalProperties</key><false/>
+				<key>properties</key><dict><key>Name</key><dict/></dict>
+			</dict>
+		</dict>
+	</dict>
+	<key>additionalProperties</key><dict><key>type</key><string>date</string></dict>
+</dict></plist>`
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(bundleSchema))
+	assert.NoError(t, err)
+	assert.Equal(t, "dict", s.Type)
+	assert.Equal(t, []string{"CFBundleIdentifier", "CFBundleVersion"}, s.Required)
+	assert.Equal(t, "^[A-Za-z0-9.-]+$", s.Properties["CFBundleIdentifier"].Pattern)
+	assert.NotNil(t, s.Properties["CFBundleIdentifier"].pattern)
+	assert.Equal(t, []interface{}{"public.app-category.games", int64(1)}, s.Properties["LSApplicationCategoryType"].Enum)
+	assert.Equal(t, &Additional{Allowed: false}, s.Properties["URLTypes"].Items.Additional)
+	assert.Equal(t, &Additional{Allowed: true, Schema: &Schema{Type: "date"}}, s.Additional)
+}
+
+func TestParseErrors(t *testing.T) {
+	for schema, expected := range map[string]string{
+		`not a plist`:             "schema: ",
+		`<plist><array/></plist>`: "schema: must be a dict",
+		`<plist><dict><key>type</key><string>float</string></dict></plist>`:                             `schema: unknown type "float"`,
+		`<plist><dict><key>requird</key><array/></dict></plist>`:                                        `schema: unknown key "requird"`,
+		`<plist><dict><key>required</key><string>a</string></dict></plist>`:                             "schema: cannot unmarshal string",
+		`<plist><dict><key>additionalProperties</key><string>a</string></dict></plist>`:                 "schema: additionalProperties must be a boolean or a dict",
+		`<plist><dict><key>items</key><dict><key>pattern</key><string>(</string></dict></dict></plist>`: "schema: items: invalid pattern: ",
+		`<plist><dict><key>properties</key><dict><key>a</key><dict>
+			<key>additionalProperties</key><dict><key>type</key><string>x</string></dict>
+		</dict></dict></dict></plist>`: `schema: properties.a.additionalProperties: unknown type "x"`,
+	} {
+		_, err := Parse([]byte(schema))
+		if assert.Error(t, err, schema) {
+			assert.Contains(t, err.Error(), expected, schema)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// ValidationError describes a value that doesn't match its schema.
+type ValidationError struct {
+	// Key is the path to the offending value, e.g. "URLTypes[1].Name", or
+	// empty for the root value.
+	Key string
+	Msg string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Key == "" {
+		return "schema: " + e.Msg
+	}
+	return fmt.Sprintf("schema: %s: %s", e.Key, e.Msg)
+}
+
+/*
+Validate returns every way value doesn't match the schema, or nil if it does.
+value is a value as returned by xml.PlistDecoder.DecodeValue. Dict keys are
+checked in sorted order, and the values in a dict or array aren't checked
+against their schemas if the dict or array is the wrong type.
+*/
+func (s *Schema) Validate(value interface{}) []error {
+	var v validator
+	v.validate(s, value, "")
+	return v.errs
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) errorf(key, format string, args ...interface{}) {
+	v.errs = append(v.errs, &ValidationError{key, fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(s *Schema, value interface{}, key string) {
+	if s.Type != "" && !hasType(value, s.Type) {
+		v.errorf(key, "must be of type %s, found %s", s.Type, typeName(value))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			allowed[i] = formatValue(e)
+		}
+		v.errorf(key, "must be one of %s, found %s", strings.Join(allowed, ", "), formatValue(value))
+	}
+
+	switch value := value.(type) {
+	case string:
+		if s.pattern != nil && !s.pattern.MatchString(value) {
+			v.errorf(key, "must match %q, found %q", s.Pattern, value)
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range value {
+				v.validate(s.Items, item, fmt.Sprintf("%s[%d]", key, i))
+			}
+		}
+	case map[string]interface{}:
+		v.validateDict(s, value, key)
+	}
+}
+
+func (v *validator) validateDict(s *Schema, dict map[string]interface{}, key string) {
+	for _, required := range s.Required {
+		if _, ok := dict[required]; !ok {
+			v.errorf(joinPath(key, required), "is required")
+		}
+	}
+
+	keys := make([]string, 0, len(dict))
+	for k := range dict {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if property, ok := s.Properties[k]; ok {
+			v.validate(property, dict[k], joinPath(key, k))
+		} else if s.Additional != nil {
+			if !s.Additional.Allowed {
+				v.errorf(joinPath(key, k), "is not allowed")
+			} else if s.Additional.Schema != nil {
+				v.validate(s.Additional.Schema, dict[k], joinPath(key, k))
+			}
+		}
+	}
+}
+
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "number":
+		return typeName(value) == "integer" || typeName(value) == "real"
+	}
+	return typeName(value) == t
+}
+
+// typeName returns the schema type of a decoded value.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int64, uint64, xml.Int128, big.Int:
+		return "integer"
+	case float64, big.Float:
+		return "real"
+	case time.Time:
+		return "date"
+	case []byte:
+		return "data"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "dict"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, e := range enum {
+		if equal(value, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal returns whether two decoded values are the same plist value. Integers
+// are equal regardless of the Go type they were decoded to, and so are reals.
+func equal(a, b interface{}) bool {
+	if x, ok := bigInt(a); ok {
+		y, ok := bigInt(b)
+		return ok && x.Cmp(y) == 0
+	}
+	if x, ok := bigFloat(a); ok {
+		y, ok := bigFloat(b)
+		return ok && x.Cmp(y) == 0
+	}
+	if x, ok := a.(time.Time); ok {
+		y, ok := b.(time.Time)
+		return ok && x.Equal(y)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func bigInt(value interface{}) (*big.Int, bool) {
+	switch value := value.(type) {
+	case int64:
+		return big.NewInt(value), true
+	case uint64:
+		return new(big.Int).SetUint64(value), true
+	case xml.Int128:
+		return value.BigInt(), true
+	case big.Int:
+		return &value, true
+	}
+	return nil, false
+}
+
+func bigFloat(value interface{}) (*big.Float, bool) {
+	switch value := value.(type) {
+	case float64:
+		if math.IsNaN(value) {
+			return nil, false
+		}
+		return big.NewFloat(value), true
+	case big.Float:
+		return &value, true
+	}
+	return nil, false
+}
+
+func formatValue(value interface{}) string {
+	if i, ok := bigInt(value); ok {
+		return i.String()
+	}
+	if f, ok := bigFloat(value); ok {
+		return f.Text('g', -1)
+	}
+	switch value := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", value)
+	case time.Time:
+		return value.UTC().Format(time.RFC3339)
+	case []interface{}, map[string]interface{}:
+		return typeName(value)
+	}
+	return fmt.Sprint(value)
+}
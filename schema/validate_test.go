@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(bundleSchema))
+	assert.NoError(t, err)
+
+	value, err := xml.NewDecoder(strings.NewReader(`<plist><dict>
+		<key>CFBundleIdentifier</key><string>com.example.app</string>
+		<key>CFBundleVersion</key><real>1.5</real>
+		<key>LSApplicationCategoryType</key><integer>0x1</integer>
+		<key>URLTypes</key><array><dict><key>Name</key><data>AQ==</data></dict></array>
+		<key>BuildDate</key><date>2024-01-02T03:04:05Z</date>
+	</dict></plist>`)).DecodeValue()
+	assert.NoError(t, err)
+	assert.Empty(t, s.Validate(value))
+
+	value, err = xml.NewDecoder(strings.NewReader(`<plist><dict>
+		<key>CFBundleIdentifier</key><string>com.example/app</string>
+		<key>LSApplicationCategoryType</key><string>games</string>
+		<key>URLTypes</key>
+		<array>
+			<dict><key>Name</key><string>a</string></dict>
+			<dict><key>Role</key><string>Editor</string></dict>
+			<string>c</string>
+		</array>
+		<key>BuildDate</key><string>today</string>
+	</dict></plist>`)).DecodeValue()
+	assert.NoError(t, err)
+	assert.Equal(t, []error{
+		&ValidationError{"CFBundleVersion", "is required"},
+		&ValidationError{"BuildDate", "must be of type date, found string"},
+		&ValidationError{"CFBundleIdentifier", `must match "^[A-Za-z0-9.-]+$", found "com.example/app"`},
+		&ValidationError{"LSApplicationCategoryType", `must be one of "public.app-category.games", 1, found "games"`},
+		&ValidationError{"URLTypes[1].Name", "is required"},
+		&ValidationError{"URLTypes[1].Role", "is not allowed"},
+		&ValidationError{"URLTypes[2]", "must be of type dict, found string"},
+	}, s.Validate(value))
+
+	assert.Equal(t, []error{&ValidationError{"", "must be of type dict, found array"}}, s.Validate([]interface{}{}))
+	assert.Equal(t, "schema: must be of type dict, found array", s.Validate([]interface{}{})[0].Error())
+	assert.Equal(t, "schema: a[1]: is required", (&ValidationError{"a[1]", "is required"}).Error())
+}
+
+func TestValidateTypes(t *testing.T) {
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for typ, values := range map[string][]interface{}{
+		"string":  {""},
+		"boolean": {true},
+		"integer": {int64(-1), uint64(1 << 63), xml.Int128{Hi: 1}, *big.NewInt(1)},
+		"real":    {1.5, *big.NewFloat(1.5)},
+		"number":  {int64(1), 1.5},
+		"date":    {date},
+		"data":    {[]byte{1}},
+		"array":   {[]interface{}{}},
+		"dict":    {map[string]interface{}{}},
+	} {
+		s := &Schema{Type: typ}
+		for _, value := range values {
+			assert.Empty(t, s.Validate(value), "%s %#v", typ, value)
+		}
+		for _, other := range []interface{}{"", true, int64(1), 1.5, date, []byte{1}, []interface{}{}, map[string]interface{}{}} {
+			if typ != typeName(other) && !(typ == "number" && (typeName(other) == "integer" || typeName(other) == "real")) {
+				assert.Len(t, s.Validate(other), 1, "%s %#v", typ, other)
+			}
+		}
+	}
+}
+
+func TestValidateEnum(t *testing.T) {
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := &Schema{Enum: []interface{}{int64(1), uint64(1 << 63), 2.5, date, []byte{1}}}
+	for _, value := range []interface{}{uint64(1), int64(1), *big.NewInt(1), *new(big.Int).SetUint64(1 << 63), *big.NewFloat(2.5), date.In(time.FixedZone("X", 3600)), []byte{1}} {
+		assert.Empty(t, s.Validate(value), "%#v", value)
+	}
+	for _, value := range []interface{}{1.0, int64(2), "1", []byte{2}, date.Add(time.Second)} {
+		assert.Len(t, s.Validate(value), 1, "%#v", value)
+	}
+	assert.Equal(t, []error{&ValidationError{"", "must be one of 1, 9223372036854775808, 2.5, 2024-01-02T03:04:05Z, [1], found 2"}}, s.Validate(int64(2)))
+}
@@ -0,0 +1,217 @@
+/*
+Package strings reads and writes localization files: old-style .strings
+files, and .stringsdict plists with plural rules.
+
+A .strings file is a list of key-value pairs in OpenStep syntax, each usually
+preceded by a comment for translators. Comments may be block comments, as
+genstrings writes them, or // lines:
+
+	// Title of the settings screen
+	"settings.title" = "Settings";
+
+Decode keeps each entry's comment and the file's encoding, so Encode writes the
+file back the way Xcode and translators expect it. .strings files are often
+UTF-16.
+*/
+package strings
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	gostrings "strings"
+
+	"github.com/zach-klippenstein/goplist/openstep"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// File is the contents of a .strings file.
+type File struct {
+	Entries []Entry
+	// Encoding is the encoding the file was read in, and is written in.
+	Encoding xml.Encoding
+	// TrailingComment holds any comments after the last entry.
+	TrailingComment string
+}
+
+// Entry is a localized string.
+type Entry struct {
+	Key   string
+	Value string
+	// Comment holds the comments between the previous entry and this one,
+	// without delimiters or surrounding whitespace, separated by newlines.
+	Comment string
+}
+
+/*
+Decode reads a .strings file from r, in UTF-8 or UTF-16. Entries can be written
+as "key" = "value"; or as "key"; which uses the key as the value.
+*/
+func Decode(r io.Reader) (*File, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	text, encoding, err := openstep.DecodeText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	d := decoder{scanner: openstep.NewScanner(text)}
+	f := &File{Encoding: encoding}
+	for {
+		entry, err := d.decodeEntry()
+		if err == io.EOF {
+			f.TrailingComment = d.takeComment()
+			return f, nil
+		} else if err != nil {
+			return nil, err
+		}
+		f.Entries = append(f.Entries, entry)
+	}
+}
+
+type decoder struct {
+	scanner  *openstep.Scanner
+	comments []string
+}
+
+// next returns the next token that isn't a comment, and saves comments for the
+// next entry.
+func (d *decoder) next() (openstep.Token, error) {
+	for {
+		token, err := d.scanner.Next()
+		if err != nil || token.Kind != openstep.TokenComment {
+			return token, err
+		}
+		d.comments = append(d.comments, gostrings.TrimSpace(token.Value))
+	}
+}
+
+func (d *decoder) takeComment() string {
+	comment := gostrings.Join(d.comments, "\n")
+	d.comments = nil
+	return comment
+}
+
+func (d *decoder) decodeEntry() (Entry, error) {
+	key, err := d.next()
+	if err != nil {
+		return Entry{}, err
+	}
+	if key.Kind != openstep.TokenString {
+		return Entry{}, errorf(key, "expected key, found %s", describe(key))
+	}
+
+	entry := Entry{Key: key.Value, Value: key.Value}
+	token, err := d.nextInEntry()
+	if err != nil {
+		return Entry{}, err
+	}
+	if token.Kind == openstep.TokenEquals {
+		value, err := d.nextInEntry()
+		if err != nil {
+			return Entry{}, err
+		}
+		if value.Kind != openstep.TokenString {
+			return Entry{}, errorf(value, "expected string value for %q, found %s", entry.Key, describe(value))
+		}
+		entry.Value = value.Value
+
+		if token, err = d.nextInEntry(); err != nil {
+			return Entry{}, err
+		}
+	}
+	if token.Kind != openstep.TokenSemicolon {
+		return Entry{}, errorf(token, "expected ; after %q, found %s", entry.Key, describe(token))
+	}
+
+	entry.Comment = d.takeComment()
+	return entry, nil
+}
+
+// nextInEntry is like next, but the end of the input is an error.
+func (d *decoder) nextInEntry() (openstep.Token, error) {
+	token, err := d.next()
+	if err == io.EOF {
+		return token, &openstep.SyntaxError{Line: d.scanner.Line(), Msg: "unexpected end of input"}
+	}
+	return token, err
+}
+
+func errorf(token openstep.Token, format string, args ...interface{}) error {
+	return &openstep.SyntaxError{Line: token.Line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func describe(token openstep.Token) string {
+	if token.Kind == openstep.TokenData {
+		return "data"
+	}
+	return fmt.Sprintf("%q", token.Value)
+}
+
+// Lookup returns the value of the last entry with key, as Foundation does.
+func (f *File) Lookup(key string) (string, bool) {
+	for i := len(f.Entries) - 1; i >= 0; i-- {
+		if f.Entries[i].Key == key {
+			return f.Entries[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// Map returns the entries as a map. Later entries replace earlier ones with the same key.
+func (f *File) Map() map[string]string {
+	m := make(map[string]string, len(f.Entries))
+	for _, entry := range f.Entries {
+		m[entry.Key] = entry.Value
+	}
+	return m
+}
+
+// Encode writes f to w in f.Encoding, with each entry's comment above it and a
+// blank line between entries. Comments are written as /* */ comments, or as //
+// lines if they contain "*/".
+func (f *File) Encode(w io.Writer) error {
+	var b bytes.Buffer
+	for i, entry := range f.Entries {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		writeComment(&b, entry.Comment)
+		fmt.Fprintf(&b, "%s = %s;\n", quote(entry.Key), quote(entry.Value))
+	}
+	if f.TrailingComment != "" {
+		if len(f.Entries) > 0 {
+			b.WriteByte('\n')
+		}
+		writeComment(&b, f.TrailingComment)
+	}
+
+	_, err := w.Write(openstep.EncodeText(b.String(), f.Encoding))
+	return err
+}
+
+func writeComment(b *bytes.Buffer, comment string) {
+	if comment == "" {
+		return
+	}
+	if !gostrings.Contains(comment, "*/") {
+		fmt.Fprintf(b, "/* %s */\n", comment)
+		return
+	}
+	for _, line := range gostrings.Split(comment, "\n") {
+		fmt.Fprintf(b, "// %s\n", line)
+	}
+}
+
+// quote quotes s even if it doesn't need it, since .strings files always quote.
+func quote(s string) string {
+	quoted := openstep.Quote(s)
+	if quoted[0] != '"' {
+		return `"` + quoted + `"`
+	}
+	return quoted
+}
@@ -0,0 +1,112 @@
+package strings
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goplist/openstep"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+const localizable = `/*
+  Localizable.strings
+  MyApp
+*/
+
+/* Title of the settings screen */
+"settings.title" = "Settings";
+
+// Shown when there are no files.
+// Keep it short.
+"files.empty" = "No \"files\"\nyet";
+
+"OK";
+/* Unused */
+`
+
+func TestDecode(t *testing.T) {
+	f, err := Decode(bytes.NewReader(openstep.EncodeText(localizable, xml.UTF16LittleEndian)))
+	assert.NoError(t, err)
+	assert.Equal(t, &File{
+		Entries: []Entry{
+			{Key: "settings.title", Value: "Settings", Comment: "Localizable.strings\n  MyApp\nTitle of the settings screen"},
+			{Key: "files.empty", Value: "No \"files\"\nyet", Comment: "Shown when there are no files.\nKeep it short."},
+			{Key: "OK", Value: "OK"},
+		},
+		Encoding:        xml.UTF16LittleEndian,
+		TrailingComment: "Unused",
+	}, f)
+
+	value, ok := f.Lookup("files.empty")
+	assert.True(t, ok)
+	assert.Equal(t, "No \"files\"\nyet", value)
+	_, ok = f.Lookup("missing")
+	assert.False(t, ok)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, test := range []struct {
+		text, expected string
+	}{
+		{`"a" = "b"`, "openstep: line 1: unexpected end of input"},
+		{`"a" = "b" "c" = "d";`, `openstep: line 1: expected ; after "a", found "c"`},
+		{`"a" = ("b");`, `openstep: line 1: expected string value for "a", found "("`},
+		{"\n<0a> = b;", "openstep: line 2: expected key, found data"},
+		{`"a" = "b;`, "openstep: line 1: unterminated string"},
+	} {
+		_, err := Decode(bytes.NewReader([]byte(test.text)))
+		assert.EqualError(t, err, test.expected, test.text)
+	}
+}
+
+func TestLookupLastEntry(t *testing.T) {
+	f, err := Decode(bytes.NewReader([]byte(`a = first; a = second;`)))
+	assert.NoError(t, err)
+	value, _ := f.Lookup("a")
+	assert.Equal(t, "second", value)
+	assert.Equal(t, map[string]string{"a": "second"}, f.Map())
+}
+
+func TestEncode(t *testing.T) {
+	f := &File{
+		Entries: []Entry{
+			{Key: "settings.title", Value: "Settings", Comment: "Title of the settings screen"},
+			{Key: "files.empty", Value: "No \"files\"\nyet", Comment: "Matches */ and more"},
+			{Key: "OK", Value: "OK"},
+		},
+		TrailingComment: "Unused",
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, f.Encode(&b))
+	assert.Equal(t, `/* Title of the settings screen */
+"settings.title" = "Settings";
+
+// Matches */ and more
+"files.empty" = "No \"files\"\nyet";
+
+"OK" = "OK";
+
+/* Unused */
+`, b.String())
+
+	decoded, err := Decode(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, f, decoded)
+}
+
+func TestEncodeUTF16(t *testing.T) {
+	f := &File{
+		Entries:  []Entry{{Key: "greeting", Value: "Grüß dich"}},
+		Encoding: xml.UTF16BigEndian,
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, f.Encode(&b))
+	assert.Equal(t, openstep.EncodeText("\"greeting\" = \"Grüß dich\";\n", xml.UTF16BigEndian), b.Bytes())
+
+	decoded, err := Decode(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, f, decoded)
+}
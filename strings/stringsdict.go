@@ -0,0 +1,197 @@
+package strings
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Keys used in .stringsdict files.
+const (
+	LocalizedFormatKey = "NSStringLocalizedFormatKey"
+	FormatSpecTypeKey  = "NSStringFormatSpecTypeKey"
+	FormatValueTypeKey = "NSStringFormatValueTypeKey"
+	PluralRuleType     = "NSStringPluralRuleType"
+)
+
+// Plural categories, in the order CLDR lists them.
+var Categories = []string{"zero", "one", "two", "few", "many", "other"}
+
+/*
+StringsDict is the contents of a .stringsdict file: a plural rule for each
+localized string key.
+
+	<key>%d files</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@</string>
+		<key>files</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+			<key>one</key>
+			<string>%d file</string>
+			<key>other</key>
+			<string>%d files</string>
+		</dict>
+	</dict>
+*/
+type StringsDict map[string]PluralRule
+
+/*
+PluralRule is the localized format of a string. FormatKey is a format string
+that refers to variables as %#@name@, and Variables holds the variants of each
+variable.
+*/
+type PluralRule struct {
+	FormatKey string
+	Variables map[string]PluralVariable
+}
+
+func (r *PluralRule) UnmarshalPlist(value interface{}) error {
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("strings: plural rule must be a dict, found %T", value)
+	}
+
+	*r = PluralRule{Variables: make(map[string]PluralVariable)}
+	for key, value := range dict {
+		if key == LocalizedFormatKey {
+			if err := xml.UnmarshalValue(value, &r.FormatKey); err != nil {
+				return fmt.Errorf("strings: %s: %v", key, err)
+			}
+			continue
+		}
+
+		var variable PluralVariable
+		if err := xml.UnmarshalValue(value, &variable); err != nil {
+			return fmt.Errorf("strings: variable %s: %v", key, err)
+		}
+		r.Variables[key] = variable
+	}
+	return nil
+}
+
+// PluralVariable holds the variants of a number in a PluralRule, one for each
+// plural category the language uses.
+type PluralVariable struct {
+	// SpecType is NSStringPluralRuleType.
+	SpecType string `plist:"NSStringFormatSpecTypeKey"`
+	// ValueType is the format verb of the number, e.g. "d" or "lu".
+	ValueType string `plist:"NSStringFormatValueTypeKey"`
+
+	Zero  string `plist:"zero"`
+	One   string `plist:"one"`
+	Two   string `plist:"two"`
+	Few   string `plist:"few"`
+	Many  string `plist:"many"`
+	Other string `plist:"other"`
+}
+
+// Variants returns the variants that are set, by plural category.
+func (v PluralVariable) Variants() map[string]string {
+	variants := make(map[string]string)
+	for _, category := range Categories {
+		if variant := v.Variant(category); variant != "" {
+			variants[category] = variant
+		}
+	}
+	return variants
+}
+
+// Variant returns the variant for a plural category, without falling back to Other.
+func (v PluralVariable) Variant(category string) string {
+	switch category {
+	case "zero":
+		return v.Zero
+	case "one":
+		return v.One
+	case "two":
+		return v.Two
+	case "few":
+		return v.Few
+	case "many":
+		return v.Many
+	case "other":
+		return v.Other
+	}
+	return ""
+}
+
+// DecodeStringsDict reads a .stringsdict file from r.
+func DecodeStringsDict(r io.Reader) (StringsDict, error) {
+	var d StringsDict
+	if err := xml.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+/*
+Encode writes d to w as an XML plist, with keys sorted and plural categories in
+CLDR order. Variables without a SpecType are written as NSStringPluralRuleType.
+*/
+func (d StringsDict) Encode(w io.Writer) error {
+	return xml.EncodeDictPlist(w, func(e *xml.DictEncoder) error {
+		for _, key := range sortedKeys(d) {
+			if err := e.WriteDict(key, d[key].encode); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r PluralRule) encode(e *xml.DictEncoder) error {
+	if err := e.WriteString(LocalizedFormatKey, r.FormatKey); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(r.Variables))
+	for name := range r.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := e.WriteDict(name, r.Variables[name].encode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v PluralVariable) encode(e *xml.DictEncoder) error {
+	specType := v.SpecType
+	if specType == "" {
+		specType = PluralRuleType
+	}
+	if err := e.WriteString(FormatSpecTypeKey, specType); err != nil {
+		return err
+	}
+	if v.ValueType != "" {
+		if err := e.WriteString(FormatValueTypeKey, v.ValueType); err != nil {
+			return err
+		}
+	}
+	for _, category := range Categories {
+		if variant := v.Variant(category); variant != "" {
+			if err := e.WriteString(category, variant); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(d StringsDict) []string {
+	keys := make([]string, 0, len(d))
+	for key := range d {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,160 @@
+package strings
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const localizableStringsDict = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>%d files in %d folders</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@ in %#@folders@</string>
+		<key>files</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+			<key>one</key>
+			<string>%d file</string>
+			<key>other</key>
+			<string>%d files</string>
+		</dict>
+		<key>folders</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+			<key>zero</key>
+			<string>no folders</string>
+			<key>one</key>
+			<string>%d folder</string>
+			<key>other</key>
+			<string>%d folders</string>
+		</dict>
+	</dict>
+	<key>%lu minutes</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@minutes@</string>
+		<key>minutes</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>lu</string>
+			<key>one</key>
+			<string>%lu minuta</string>
+			<key>few</key>
+			<string>%lu minuty</string>
+			<key>many</key>
+			<string>%lu minut</string>
+			<key>other</key>
+			<string>%lu minuty</string>
+		</dict>
+	</dict>
+</dict>
+</plist>
+`
+
+func TestDecodeStringsDict(t *testing.T) {
+	d, err := DecodeStringsDict(bytes.NewReader([]byte(localizableStringsDict)))
+	assert.NoError(t, err)
+	assert.Equal(t, StringsDict{
+		"%d files in %d folders": {
+			FormatKey: "%#@files@ in %#@folders@",
+			Variables: map[string]PluralVariable{
+				"files": {
+					SpecType:  PluralRuleType,
+					ValueType: "d",
+					One:       "%d file",
+					Other:     "%d files",
+				},
+				"folders": {
+					SpecType:  PluralRuleType,
+					ValueType: "d",
+					Zero:      "no folders",
+					One:       "%d folder",
+					Other:     "%d folders",
+				},
+			},
+		},
+		"%lu minutes": {
+			FormatKey: "%#@minutes@",
+			Variables: map[string]PluralVariable{
+				"minutes": {
+					SpecType:  PluralRuleType,
+					ValueType: "lu",
+					One:       "%lu minuta",
+					Few:       "%lu minuty",
+					Many:      "%lu minut",
+					Other:     "%lu minuty",
+				},
+			},
+		},
+	}, d)
+
+	assert.Equal(t, map[string]string{
+		"zero":  "no folders",
+		"one":   "%d folder",
+		"other": "%d folders",
+	}, d["%d files in %d folders"].Variables["folders"].Variants())
+}
+
+func TestDecodeStringsDictErrors(t *testing.T) {
+	for _, test := range []struct {
+		body, expected string
+	}{
+		{`<key>a</key><string>b</string>`, "strings: plural rule must be a dict, found string"},
+		{`<key>a</key><dict><key>NSStringLocalizedFormatKey</key><integer>1</integer></dict>`,
+			"strings: NSStringLocalizedFormatKey: cannot unmarshal integer into Go value of type string"},
+		{`<key>a</key><dict><key>n</key><dict><key>one</key><true/></dict></dict>`,
+			"strings: variable n: cannot unmarshal bool into Go value of type string at one"},
+	} {
+		_, err := DecodeStringsDict(bytes.NewReader([]byte(`<plist version="1.0"><dict>` + test.body + `</dict></plist>`)))
+		assert.EqualError(t, err, test.expected, test.body)
+	}
+}
+
+func TestEncodeStringsDict(t *testing.T) {
+	d, err := DecodeStringsDict(bytes.NewReader([]byte(localizableStringsDict)))
+	assert.NoError(t, err)
+
+	var b bytes.Buffer
+	assert.NoError(t, d.Encode(&b))
+	assert.Contains(t, b.String(), `<key>one</key>
+				<string>%lu minuta</string>
+				<key>few</key>
+				<string>%lu minuty</string>
+				<key>many</key>
+				<string>%lu minut</string>
+				<key>other</key>`)
+
+	decoded, err := DecodeStringsDict(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, d, decoded)
+}
+
+func TestEncodeStringsDictDefaultSpecType(t *testing.T) {
+	d := StringsDict{
+		"%d items": {
+			FormatKey: "%#@n@",
+			Variables: map[string]PluralVariable{"n": {One: "one item", Other: "%d items"}},
+		},
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, d.Encode(&b))
+
+	decoded, err := DecodeStringsDict(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, PluralVariable{SpecType: PluralRuleType, One: "one item", Other: "%d items"},
+		decoded["%d items"].Variables["n"])
+}
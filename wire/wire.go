@@ -0,0 +1,197 @@
+/*
+Package wire exchanges plist messages over a connection, framed the way
+lockdownd and usbmuxd, the daemons that talk to iOS devices, frame them.
+
+lockdownd messages are a plist preceded by its length as a 4-byte big-endian
+integer. usbmuxd messages have a 16-byte header instead, of little-endian
+integers: the length of the whole message, the protocol version (1), the
+message type (8, a plist), and a tag that the reply to a request repeats.
+*/
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	plist "github.com/zach-klippenstein/goplist"
+	"github.com/zach-klippenstein/goplist/xml"
+)
+
+// Framing is how messages are delimited.
+type Framing int
+
+const (
+	// LockdownFraming prefixes messages with their length, as lockdownd does.
+	LockdownFraming Framing = iota
+	// UsbmuxFraming prefixes messages with a usbmuxd header.
+	UsbmuxFraming
+)
+
+func (f Framing) String() string {
+	switch f {
+	case LockdownFraming:
+		return "lockdown"
+	case UsbmuxFraming:
+		return "usbmux"
+	}
+	return fmt.Sprintf("Framing(%d)", int(f))
+}
+
+// DefaultMaxMessageSize is the largest message a Conn reads if its
+// MaxMessageSize is 0.
+const DefaultMaxMessageSize = 16 << 20
+
+const (
+	usbmuxHeaderSize = 16
+	usbmuxVersion    = 1
+	usbmuxPlistType  = 8
+)
+
+/*
+Conn reads and writes framed plist messages. Messages are written in Format,
+but read in either format.
+
+A Conn must only be used by one goroutine at a time.
+*/
+type Conn struct {
+	rw      io.ReadWriter
+	Framing Framing
+	Format  plist.Format
+	// MaxMessageSize is the largest message ReadMessage accepts, in bytes,
+	// so a corrupt length doesn't exhaust memory.
+	MaxMessageSize int
+
+	lastTag uint32
+}
+
+// NewConn returns a Conn that exchanges messages over rw.
+func NewConn(rw io.ReadWriter, framing Framing, format plist.Format) *Conn {
+	return &Conn{rw: rw, Framing: framing, Format: format}
+}
+
+// ResponseError is returned by Request when the response has an Error key,
+// which is how lockdownd reports a failed request.
+type ResponseError struct {
+	Msg string
+}
+
+func (e *ResponseError) Error() string {
+	return "wire: request failed: " + e.Msg
+}
+
+// WriteMessage writes a message holding value, of any type plist.Marshal
+// accepts. tag is only written with UsbmuxFraming.
+func (c *Conn) WriteMessage(tag uint32, value interface{}) error {
+	payload, err := plist.Marshal(value, c.Format)
+	if err != nil {
+		return err
+	}
+
+	var header []byte
+	switch c.Framing {
+	case LockdownFraming:
+		header = make([]byte, 4)
+		binary.BigEndian.PutUint32(header, uint32(len(payload)))
+	case UsbmuxFraming:
+		header = make([]byte, usbmuxHeaderSize)
+		binary.LittleEndian.PutUint32(header, uint32(usbmuxHeaderSize+len(payload)))
+		binary.LittleEndian.PutUint32(header[4:], usbmuxVersion)
+		binary.LittleEndian.PutUint32(header[8:], usbmuxPlistType)
+		binary.LittleEndian.PutUint32(header[12:], tag)
+	default:
+		return fmt.Errorf("wire: unknown framing %s", c.Framing)
+	}
+
+	// Write the message at once, so it isn't split across packets needlessly.
+	_, err = c.rw.Write(append(header, payload...))
+	return err
+}
+
+// ReadMessage reads a message and returns its tag, which is 0 with
+// LockdownFraming, and its value, with one of the types plist.Unmarshal returns.
+func (c *Conn) ReadMessage() (uint32, interface{}, error) {
+	var tag uint32
+	var length int
+	switch c.Framing {
+	case LockdownFraming:
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(c.rw, header); err != nil {
+			return 0, nil, err
+		}
+		length = int(binary.BigEndian.Uint32(header))
+	case UsbmuxFraming:
+		header := make([]byte, usbmuxHeaderSize)
+		if _, err := io.ReadFull(c.rw, header); err != nil {
+			return 0, nil, err
+		}
+		if version := binary.LittleEndian.Uint32(header[4:]); version != usbmuxVersion {
+			return 0, nil, fmt.Errorf("wire: unsupported usbmux version %d", version)
+		}
+		if messageType := binary.LittleEndian.Uint32(header[8:]); messageType != usbmuxPlistType {
+			return 0, nil, fmt.Errorf("wire: unsupported usbmux message type %d", messageType)
+		}
+		size := binary.LittleEndian.Uint32(header)
+		if size < usbmuxHeaderSize {
+			return 0, nil, fmt.Errorf("wire: usbmux message length %d is shorter than its header", size)
+		}
+		length = int(size - usbmuxHeaderSize)
+		tag = binary.LittleEndian.Uint32(header[12:])
+	default:
+		return 0, nil, fmt.Errorf("wire: unknown framing %s", c.Framing)
+	}
+
+	max := c.MaxMessageSize
+	if max == 0 {
+		max = DefaultMaxMessageSize
+	}
+	if length > max || length < 0 {
+		return 0, nil, fmt.Errorf("wire: message of %d bytes is larger than the maximum of %d", length, max)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	value, _, err := plist.Unmarshal(payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	return tag, value, nil
+}
+
+/*
+Request writes request and reads the response into the value pointed to by
+response, as xml.UnmarshalValue does. response may be nil to discard it.
+
+With UsbmuxFraming, each request is given the next tag, and the response must
+have the same one. If the response is a dict with an Error string, Request
+returns a *ResponseError.
+*/
+func (c *Conn) Request(request interface{}, response interface{}) error {
+	c.lastTag++
+	tag := c.lastTag
+	if err := c.WriteMessage(tag, request); err != nil {
+		return err
+	}
+
+	responseTag, value, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if c.Framing == UsbmuxFraming && responseTag != tag {
+		return fmt.Errorf("wire: response tag %d does not match request tag %d", responseTag, tag)
+	}
+	if dict, ok := value.(map[string]interface{}); ok {
+		if msg, ok := dict["Error"].(string); ok {
+			return &ResponseError{Msg: msg}
+		}
+	}
+	if response == nil {
+		return nil
+	}
+	return xml.UnmarshalValue(value, response)
+}
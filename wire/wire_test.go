@@ -0,0 +1,135 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	plist "github.com/zach-klippenstein/goplist"
+)
+
+// serve answers each request on conn with respond's result, under the same tag.
+func serve(conn *Conn, respond func(request interface{}) interface{}) {
+	for {
+		tag, request, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := conn.WriteMessage(tag, respond(request)); err != nil {
+			return
+		}
+	}
+}
+
+func TestLockdownRequest(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go serve(NewConn(server, LockdownFraming, plist.XMLFormat), func(request interface{}) interface{} {
+		req := request.(map[string]interface{})
+		if req["Key"] == "ProductVersion" {
+			return map[string]interface{}{"Request": "GetValue", "Key": "ProductVersion", "Value": "17.0"}
+		}
+		return map[string]interface{}{"Request": "GetValue", "Error": "MissingValue"}
+	})
+
+	conn := NewConn(client, LockdownFraming, plist.XMLFormat)
+	var response struct {
+		Request string
+		Value   string
+	}
+	err := conn.Request(map[string]interface{}{"Request": "GetValue", "Key": "ProductVersion"}, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "GetValue", response.Request)
+	assert.Equal(t, "17.0", response.Value)
+
+	err = conn.Request(map[string]interface{}{"Request": "GetValue", "Key": "Missing"}, nil)
+	assert.Equal(t, &ResponseError{Msg: "MissingValue"}, err)
+	assert.EqualError(t, err, "wire: request failed: MissingValue")
+}
+
+func TestUsbmuxRequest(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	var tags []uint32
+	serverConn := NewConn(server, UsbmuxFraming, plist.BinaryFormat)
+	go func() {
+		for {
+			tag, _, err := serverConn.ReadMessage()
+			if err != nil {
+				return
+			}
+			tags = append(tags, tag)
+			serverConn.WriteMessage(tag, map[string]interface{}{"MessageType": "Result", "Number": int64(0)})
+		}
+	}()
+
+	conn := NewConn(client, UsbmuxFraming, plist.XMLFormat)
+	var result map[string]interface{}
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, conn.Request(map[string]interface{}{"MessageType": "ListDevices"}, &result))
+	}
+	assert.Equal(t, map[string]interface{}{"MessageType": "Result", "Number": int64(0)}, result)
+	assert.Equal(t, []uint32{1, 2}, tags)
+}
+
+func TestUsbmuxTagMismatch(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	serverConn := NewConn(server, UsbmuxFraming, plist.XMLFormat)
+	go func() {
+		_, request, _ := serverConn.ReadMessage()
+		serverConn.WriteMessage(99, request)
+	}()
+
+	err := NewConn(client, UsbmuxFraming, plist.XMLFormat).Request(map[string]interface{}{}, nil)
+	assert.EqualError(t, err, "wire: response tag 99 does not match request tag 1")
+}
+
+func TestFraming(t *testing.T) {
+	var b bytes.Buffer
+	conn := NewConn(&b, LockdownFraming, plist.BinaryFormat)
+	assert.NoError(t, conn.WriteMessage(0, []interface{}{"a"}))
+	payload, _ := plist.Marshal([]interface{}{"a"}, plist.BinaryFormat)
+	assert.Equal(t, append([]byte{0, 0, 0, byte(len(payload))}, payload...), b.Bytes())
+
+	b.Reset()
+	conn = NewConn(&b, UsbmuxFraming, plist.BinaryFormat)
+	assert.NoError(t, conn.WriteMessage(7, []interface{}{"a"}))
+	assert.Equal(t, []byte{byte(16 + len(payload)), 0, 0, 0, 1, 0, 0, 0, 8, 0, 0, 0, 7, 0, 0, 0}, b.Bytes()[:16])
+
+	tag, value, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(7), tag)
+	assert.Equal(t, []interface{}{"a"}, value)
+
+	_, _, err = conn.ReadMessage()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadMessageErrors(t *testing.T) {
+	for _, test := range []struct {
+		framing  Framing
+		data     []byte
+		expected string
+	}{
+		{LockdownFraming, []byte{0, 0, 0, 10, '<'}, "unexpected EOF"},
+		{LockdownFraming, []byte{0, 0, 4, 1}, "wire: message of 1025 bytes is larger than the maximum of 1024"},
+		{UsbmuxFraming, []byte{16, 0, 0, 0, 0, 0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0}, "wire: unsupported usbmux version 0"},
+		{UsbmuxFraming, []byte{16, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}, "wire: unsupported usbmux message type 1"},
+		{UsbmuxFraming, []byte{4, 0, 0, 0, 1, 0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0}, "wire: usbmux message length 4 is shorter than its header"},
+		{Framing(5), nil, "wire: unknown framing Framing(5)"},
+	} {
+		conn := NewConn(bytes.NewBuffer(test.data), test.framing, plist.XMLFormat)
+		conn.MaxMessageSize = 1024
+		_, _, err := conn.ReadMessage()
+		assert.EqualError(t, err, test.expected, test.framing.String())
+	}
+}
@@ -1,13 +1,25 @@
 package xml
 
-import "encoding/xml"
-
 type arrayDecoder struct {
 	baseDecoder
 }
 
 var _ containerDecoder = &arrayDecoder{}
 
-func newArrayDecoder(parent containerDecoder, xmlDecoder *xml.Decoder) *arrayDecoder {
-	return &arrayDecoder{baseDecoder{parent, xmlDecoder}}
+func newArrayDecoder(base baseDecoder) *arrayDecoder {
+	return &arrayDecoder{base}
+}
+
+func (d *arrayDecoder) NextValue() (interface{}, error) {
+	value, err := d.nextValue(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := value.(EndDecodingContainer); !ok {
+		if err := d.countEntry(); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
 }
@@ -48,6 +48,11 @@ func (e *ArrayEncoder) WriteUint(val uint64) error {
 	return writeUint(e.xmlEncoder, val)
 }
 
+func (e *ArrayEncoder) WriteInt128(val Int128) error {
+	e.assertReady()
+	return writeInt128(e.xmlEncoder, val)
+}
+
 func (e *ArrayEncoder) WriteBigInt(val *big.Int) error {
 	e.assertReady()
 	return writeBigInt(e.xmlEncoder, val)
@@ -4,51 +4,111 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type baseDecoder struct {
 	parent     containerDecoder
 	xmlDecoder *xml.Decoder
+
+	// scanner is used instead of xmlDecoder if it's not nil.
+	scanner *scanner
+
+	// options may be nil, in which case no limits are enforced and
+	// validation is not strict.
+	options *DecoderOptions
+
+	// depth is the nesting level of the container being decoded, starting at 1
+	// for the root container.
+	depth int
+
+	// entries is the number of values read out of the container so far.
+	entries int
+
+	// warnings collects recoveries made when decoding leniently.
+	// May be nil, in which case they are dropped.
+	warnings *[]Warning
+
+	// violations collects violations of the plist DTD when decoding strictly.
+	// May be nil, in which case they are dropped.
+	violations *[]*ValidationError
 }
 
 func (d *baseDecoder) NextValue() (interface{}, error) {
-	token, err := nextStartOrEndElement(d.xmlDecoder)
+	return d.nextValue(d)
+}
+
+// nextValue reads the next value, and creates any container decoders with
+// container as their parent.
+func (d *baseDecoder) nextValue(container containerDecoder) (interface{}, error) {
+	if d.scanner != nil {
+		return d.scanValue(container)
+	}
+
+	token, err := nextStartOrEndElement(d.xmlDecoder, d.strict())
 	if err != nil {
 		return nil, err
 	}
 
 	switch token := token.(type) {
 	case xml.StartElement:
+		if violate := d.strict(); violate != nil && len(token.Attr) > 0 {
+			violate("unexpected attributes on <%s>", token.Name.Local)
+		}
+
 		switch token.Name {
 		case stringStartElement.Name:
-			return finishReadingString(d.xmlDecoder)
+			return finishReadingString(d.xmlDecoder, d.opts().MaxStringBytes)
 		case boolTrueElement.Name:
-			return finishReadingBool(d.xmlDecoder, true, boolTrueElement.End())
+			return finishReadingBool(d.xmlDecoder, true, boolTrueElement.End(), d.strict())
 		case boolFalseElement.Name:
-			return finishReadingBool(d.xmlDecoder, false, boolFalseElement.End())
+			return finishReadingBool(d.xmlDecoder, false, boolFalseElement.End(), d.strict())
 		case integerStartElement.Name:
-			return finishReadingInteger(d.xmlDecoder)
+			return finishReadingInteger(d.xmlDecoder, d.opts().MaxStringBytes, d.lenient())
 		case realStartElement.Name:
-			return finishReadingReal(d.xmlDecoder)
+			return finishReadingReal(d.xmlDecoder, d.opts().MaxStringBytes)
 		case dateStartElement.Name:
-			return finishReadingDate(d.xmlDecoder)
+			return finishReadingDate(d.xmlDecoder, d.opts().MaxStringBytes)
 		case dataStartElement.Name:
-			return finishReadingData(d.xmlDecoder)
+			return finishReadingData(d.xmlDecoder, d.opts().MaxDataBytes, d.lenient())
 		case arrayStartElement.Name:
-			return newArrayDecoder(d, d.xmlDecoder), nil
+			child, err := d.child(container)
+			if err != nil {
+				return nil, err
+			}
+			return newArrayDecoder(child), nil
 		case dictStartElement.Name:
-			return newDictDecoder(d, d.xmlDecoder), nil
+			child, err := d.child(container)
+			if err != nil {
+				return nil, err
+			}
+			return newDictDecoder(child), nil
 		}
 
 	case xml.EndElement:
 		return EndDecodingContainer{}, nil
 	}
 
+	if violate := d.strict(); violate != nil {
+		violate("unexpected element %s", describeToken(token))
+		if err := d.xmlDecoder.Skip(); err != nil {
+			return nil, err
+		}
+		return d.nextValue(container)
+	}
+	if warn := d.lenient(); warn != nil {
+		warn("skipped unknown element %s", describeToken(token))
+		if err := d.xmlDecoder.Skip(); err != nil {
+			return nil, err
+		}
+		return d.nextValue(container)
+	}
 	return nil, fmt.Errorf("Invalid element: %+v", token)
 }
 
@@ -56,31 +116,103 @@ func (d *baseDecoder) ParentDecoder() containerDecoder {
 	return d.parent
 }
 
-func nextStartOrEndElement(xmlDecoder *xml.Decoder) (xml.Token, error) {
+// opts returns the options to enforce, which are all unlimited if
+// no options were given.
+func (d *baseDecoder) opts() DecoderOptions {
+	if d.options == nil {
+		return DecoderOptions{}
+	}
+	return *d.options
+}
+
+// lenient returns a function to record recoveries with if decoding leniently,
+// otherwise nil.
+func (d *baseDecoder) lenient() warnFunc {
+	if !d.opts().Lenient || d.opts().Strict {
+		return nil
+	}
+	return func(format string, args ...interface{}) {
+		if d.warnings != nil {
+			*d.warnings = append(*d.warnings, Warning{
+				Offset: d.xmlDecoder.InputOffset(),
+				Msg:    fmt.Sprintf(format, args...),
+			})
+		}
+	}
+}
+
+// strict returns a function to record violations with if decoding strictly,
+// otherwise nil.
+func (d *baseDecoder) strict() violationFunc {
+	if !d.opts().Strict {
+		return nil
+	}
+	return func(format string, args ...interface{}) {
+		if d.violations != nil {
+			*d.violations = append(*d.violations, validationErrorf(d.xmlDecoder, format, args...))
+		}
+	}
+}
+
+// child returns the state for a container nested inside this one.
+func (d *baseDecoder) child(parent containerDecoder) (baseDecoder, error) {
+	depth := d.depth + 1
+	if max := d.opts().MaxDepth; max > 0 && depth > max {
+		return baseDecoder{}, &LimitError{"MaxDepth", int64(max)}
+	}
+	return baseDecoder{
+		parent:     parent,
+		xmlDecoder: d.xmlDecoder,
+		scanner:    d.scanner,
+		options:    d.options,
+		depth:      depth,
+		warnings:   d.warnings,
+		violations: d.violations,
+	}, nil
+}
+
+// countEntry records that another value has been read out of the container.
+func (d *baseDecoder) countEntry() error {
+	d.entries++
+	if max := d.opts().MaxContainerEntries; max > 0 && d.entries > max {
+		return &LimitError{"MaxContainerEntries", int64(max)}
+	}
+	return nil
+}
+
+// nextStartOrEndElement skips tokens until the next start or end element.
+// If violate is not nil, text is reported to it.
+func nextStartOrEndElement(xmlDecoder *xml.Decoder, violate violationFunc) (xml.Token, error) {
 	for {
 		token, err := nextInterestingToken(xmlDecoder)
 		if err != nil {
 			return nil, err
 		}
 
-		switch token.(type) {
+		switch token := token.(type) {
 		case xml.StartElement, xml.EndElement:
 			return token, nil
+		case xml.CharData:
+			if violate != nil {
+				violate("unexpected %s", describeToken(token))
+			}
 		}
 	}
 }
 
 // finishReadingString consumes tokens, concatenating all CharDatas,
 // until a </string> is encountered.
-func finishReadingString(xmlDecoder *xml.Decoder) (interface{}, error) {
-	str, err := readCharDataUntilEnd(xmlDecoder, stringStartElement.End())
+func finishReadingString(xmlDecoder *xml.Decoder, maxBytes int) (interface{}, error) {
+	str, err := readCharDataUntilEnd(xmlDecoder, stringStartElement.End(), maxBytes)
 	if err != nil {
 		return nil, err
 	}
 	return str, nil
 }
 
-func finishReadingBool(xmlDecoder *xml.Decoder, value bool, end xml.EndElement) (interface{}, error) {
+// finishReadingBool consumes tokens until end. If violate is not nil, content
+// in the element is reported to it.
+func finishReadingBool(xmlDecoder *xml.Decoder, value bool, end xml.EndElement, violate violationFunc) (interface{}, error) {
 	for {
 		token, err := xmlDecoder.Token()
 		if err != nil {
@@ -90,50 +222,122 @@ func finishReadingBool(xmlDecoder *xml.Decoder, value bool, end xml.EndElement)
 		if token == end {
 			return value, nil
 		}
+		if violate != nil {
+			violate("<%s> must be empty", end.Name.Local)
+			// Report the element once, however much is in it.
+			violate = nil
+		}
 	}
 }
 
-// finishReadingInteger tries parsing an int64, then a uint64, and finally a big.Int,
-// as required by the size of the value.
-func finishReadingInteger(xmlDecoder *xml.Decoder) (interface{}, error) {
-	raw, err := readCharDataUntilEnd(xmlDecoder, integerStartElement.End())
+// finishReadingInteger tries parsing an int64, then a uint64, then an Int128,
+// and finally a big.Int, as required by the size of the value.
+// If warn is not nil, surrounding whitespace and a leading + sign are tolerated.
+func finishReadingInteger(xmlDecoder *xml.Decoder, maxBytes int, warn warnFunc) (interface{}, error) {
+	raw, err := readCharDataUntilEnd(xmlDecoder, integerStartElement.End(), maxBytes)
 	if err != nil {
 		return nil, err
 	}
 
+	if warn != nil {
+		raw = repairInteger(raw, warn)
+	}
+	return parseInteger(raw)
+}
+
+// repairInteger strips whitespace, and a + sign before a digit, from raw.
+func repairInteger(raw string, warn warnFunc) string {
+	if trimmed := strings.TrimSpace(raw); trimmed != raw {
+		warn("trimmed whitespace from integer %q", raw)
+		raw = trimmed
+	}
+	if len(raw) > 1 && raw[0] == '+' && raw[1] >= '0' && raw[1] <= '9' {
+		warn("removed + sign from integer %q", raw)
+		raw = raw[1:]
+	}
+	return raw
+}
+
+/*
+parseInteger parses a decimal, 0x-prefixed hexadecimal, or 0o-prefixed octal integer.
+
+Like CoreFoundation, a leading 0 on its own does not make an integer octal, so
+"010" is 10. Values above math.MaxInt64 that fit in 64 bits are returned as
+uint64, so unsigned values survive a round trip.
+*/
+func parseInteger(raw string) (interface{}, error) {
+	digits, base := splitIntegerBase(raw)
+
 	var value interface{}
-	value, err = strconv.ParseInt(raw, 10, 64)
+	value, err := strconv.ParseInt(digits, base, 64)
 	if err == nil {
 		return value, nil
 	} else if !isErrOutOfRange(err) {
-		return nil, err
+		return nil, withNum(err, raw)
 	}
 
-	value, err = strconv.ParseUint(raw, 10, 64)
-	if err == nil {
-		return value, nil
-	} else if !isErrOutOfRange(err) {
-		return nil, err
+	if !strings.HasPrefix(digits, "-") {
+		value, err = strconv.ParseUint(digits, base, 64)
+		if err == nil {
+			return value, nil
+		} else if !isErrOutOfRange(err) {
+			return nil, withNum(err, raw)
+		}
 	}
 
 	var bigValue big.Int
-	if _, ok := bigValue.SetString(raw, 10); ok {
+	if _, ok := bigValue.SetString(digits, base); ok {
+		if value, ok := Int128FromBig(&bigValue); ok {
+			return value, nil
+		}
 		return bigValue, nil
 	}
 
 	return nil, fmt.Errorf("Could not parse '%s' as an integer.", raw)
 }
 
+// splitIntegerBase removes any base prefix from raw, keeping the sign.
+func splitIntegerBase(raw string) (string, int) {
+	sign, unsigned := "", raw
+	if strings.HasPrefix(raw, "-") || strings.HasPrefix(raw, "+") {
+		sign, unsigned = raw[:1], raw[1:]
+	}
+
+	// A sign after the prefix, as in "0x-5", is left for parsing to reject.
+	if len(unsigned) > 2 && unsigned[0] == '0' && unsigned[2] != '-' && unsigned[2] != '+' {
+		switch unsigned[1] {
+		case 'x', 'X':
+			return sign + unsigned[2:], 16
+		case 'o', 'O':
+			return sign + unsigned[2:], 8
+		}
+	}
+	return raw, 10
+}
+
+// withNum makes a *strconv.NumError report the original text, instead of just
+// the digits after the base prefix.
+func withNum(err error, raw string) error {
+	if err, ok := err.(*strconv.NumError); ok {
+		err.Num = raw
+	}
+	return err
+}
+
 // finishReadingReal tries parsing a float64, then a big.Float, as required by the
 // size of the value.
-func finishReadingReal(xmlDecoder *xml.Decoder) (interface{}, error) {
-	raw, err := readCharDataUntilEnd(xmlDecoder, realStartElement.End())
+func finishReadingReal(xmlDecoder *xml.Decoder, maxBytes int) (interface{}, error) {
+	raw, err := readCharDataUntilEnd(xmlDecoder, realStartElement.End(), maxBytes)
 	if err != nil {
 		return nil, err
 	}
+	return parseReal(raw)
+}
 
+// parseReal parses a float64, or a big.Float if raw is out of float64's range.
+func parseReal(raw string) (interface{}, error) {
 	var value interface{}
-	value, err = strconv.ParseFloat(raw, 64)
+	value, err := strconv.ParseFloat(raw, 64)
 	if err == nil {
 		return value, nil
 	} else if !isErrOutOfRange(err) {
@@ -154,12 +358,15 @@ func isErrOutOfRange(err error) bool {
 	return false
 }
 
-func finishReadingDate(xmlDecoder *xml.Decoder) (interface{}, error) {
-	raw, err := readCharDataUntilEnd(xmlDecoder, dateStartElement.End())
+func finishReadingDate(xmlDecoder *xml.Decoder, maxBytes int) (interface{}, error) {
+	raw, err := readCharDataUntilEnd(xmlDecoder, dateStartElement.End(), maxBytes)
 	if err != nil {
 		return nil, err
 	}
+	return parseDate(raw)
+}
 
+func parseDate(raw string) (interface{}, error) {
 	date, err := time.Parse(dateFormat, raw)
 	if err != nil {
 		return nil, err
@@ -167,22 +374,60 @@ func finishReadingDate(xmlDecoder *xml.Decoder) (interface{}, error) {
 	return date, nil
 }
 
-func finishReadingData(xmlDecoder *xml.Decoder) (interface{}, error) {
-	raw, err := readCharDataUntilEnd(xmlDecoder, dataStartElement.End())
-	if err != nil {
+// finishReadingData base64-decodes the element's contents. maxBytes limits the
+// size of the decoded data, and is checked before decoding.
+// If warn is not nil, missing padding is tolerated.
+func finishReadingData(xmlDecoder *xml.Decoder, maxBytes int, warn warnFunc) (interface{}, error) {
+	maxEncodedBytes := 0
+	if maxBytes > 0 {
+		maxEncodedBytes = base64.StdEncoding.EncodedLen(maxBytes)
+	}
+	raw, err := readDataUntilEnd(xmlDecoder, dataStartElement.End(), maxEncodedBytes)
+	if err == errCharDataTooLong {
+		return nil, &LimitError{"MaxDataBytes", int64(maxBytes)}
+	} else if err != nil {
 		return nil, err
 	}
 
+	if padding := len(raw) % 4; warn != nil && padding != 0 {
+		warn("added missing padding to data")
+		raw += strings.Repeat("=", 4-padding)
+	}
+
 	encoded := bytes.NewReader([]byte(raw))
 	decoder := base64.NewDecoder(base64.StdEncoding, encoded)
 	data, err := ioutil.ReadAll(decoder)
 	if err != nil {
 		return nil, err
 	}
+	if maxBytes > 0 && len(data) > maxBytes {
+		return nil, &LimitError{"MaxDataBytes", int64(maxBytes)}
+	}
 	return data, nil
 }
 
-func readCharDataUntilEnd(xmlDecoder *xml.Decoder, end xml.EndElement) (string, error) {
+// errCharDataTooLong is returned by readCharData when an element's contents
+// exceed the maximum length.
+var errCharDataTooLong = errors.New("character data too long")
+
+// readCharDataUntilEnd reads the contents of an element up to end. If maxBytes
+// is positive and the contents are longer than that, a *LimitError is returned.
+func readCharDataUntilEnd(xmlDecoder *xml.Decoder, end xml.EndElement, maxBytes int) (string, error) {
+	str, err := readCharData(xmlDecoder, end, maxBytes, false)
+	if err == errCharDataTooLong {
+		return "", &LimitError{"MaxStringBytes", int64(maxBytes)}
+	}
+	return str, err
+}
+
+// readDataUntilEnd is like readCharDataUntilEnd, but drops whitespace, since
+// base64 data is commonly split across indented lines. Only the remaining
+// characters count towards maxBytes.
+func readDataUntilEnd(xmlDecoder *xml.Decoder, end xml.EndElement, maxBytes int) (string, error) {
+	return readCharData(xmlDecoder, end, maxBytes, true)
+}
+
+func readCharData(xmlDecoder *xml.Decoder, end xml.EndElement, maxBytes int, dropSpace bool) (string, error) {
 	var str bytes.Buffer
 
 	for {
@@ -193,6 +438,12 @@ func readCharDataUntilEnd(xmlDecoder *xml.Decoder, end xml.EndElement) (string,
 
 		switch token := token.(type) {
 		case xml.CharData:
+			if dropSpace {
+				token = bytes.Join(bytes.Fields(token), nil)
+			}
+			if maxBytes > 0 && str.Len()+len(token) > maxBytes {
+				return "", errCharDataTooLong
+			}
 			str.Write(token)
 		case xml.EndElement:
 			if token == end {
@@ -15,7 +15,7 @@ import (
 
 func TestDecodeNothing(t *testing.T) {
 	data := ""
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.Equal(t, io.EOF, err)
@@ -24,7 +24,7 @@ func TestDecodeNothing(t *testing.T) {
 
 func TestDecodeString(t *testing.T) {
 	data := "<string>foo</string>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -37,7 +37,7 @@ func TestDecodeString(t *testing.T) {
 
 func TestDecodeTrueSingleTag(t *testing.T) {
 	data := "<true/>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -50,7 +50,7 @@ func TestDecodeTrueSingleTag(t *testing.T) {
 
 func TestDecodeTrueContainerTag(t *testing.T) {
 	data := "<true></true>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -63,7 +63,7 @@ func TestDecodeTrueContainerTag(t *testing.T) {
 
 func TestDecodeFalse(t *testing.T) {
 	data := "<false/>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -72,7 +72,7 @@ func TestDecodeFalse(t *testing.T) {
 
 func TestDecodePositiveInt(t *testing.T) {
 	data := "<integer>42</integer>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -81,7 +81,7 @@ func TestDecodePositiveInt(t *testing.T) {
 
 func TestDecodeIntInvalid(t *testing.T) {
 	data := "<integer>foo</integer>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.EqualError(t, err, `strconv.ParseInt: parsing "foo": invalid syntax`)
@@ -90,7 +90,7 @@ func TestDecodeIntInvalid(t *testing.T) {
 
 func TestDecodeNegativeInt(t *testing.T) {
 	data := "<integer>-42</integer>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -100,18 +100,73 @@ func TestDecodeNegativeInt(t *testing.T) {
 func TestDecodeUint64(t *testing.T) {
 	var tooBigForAnInt uint64 = uint64(math.MaxInt64) + 1
 	data := fmt.Sprintf("<integer>%d</integer>", tooBigForAnInt)
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
 	assert.Equal(t, tooBigForAnInt, value)
 }
 
+func TestDecodeIntBases(t *testing.T) {
+	for _, test := range []struct {
+		raw   string
+		value interface{}
+	}{
+		{"0x1F", int64(31)},
+		{"0X1f", int64(31)},
+		{"-0x10", int64(-16)},
+		{"0o17", int64(15)},
+		{"010", int64(10)},
+		{"-0", int64(0)},
+		{"-0x0", int64(0)},
+		{"0xFFFFFFFFFFFFFFFF", uint64(math.MaxUint64)},
+		{"0x10000000000000000", Int128{Hi: 1, Lo: 0}},
+	} {
+		data := fmt.Sprintf("<integer>%s</integer>", test.raw)
+		decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
+
+		value, err := decoder.NextValue()
+		assert.NoError(t, err, test.raw)
+		assert.Equal(t, test.value, value, test.raw)
+	}
+}
+
+func TestDecodeIntInvalidHex(t *testing.T) {
+	for _, raw := range []string{"0xZZ", "0x-5", "0x+5", "-0x-5", "0o-7"} {
+		data := fmt.Sprintf("<integer>%s</integer>", raw)
+		decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
+
+		value, err := decoder.NextValue()
+		assert.EqualError(t, err, fmt.Sprintf("strconv.ParseInt: parsing %q: invalid syntax", raw))
+		assert.Nil(t, value)
+	}
+}
+
+func TestDecodeInt128(t *testing.T) {
+	for _, test := range []struct {
+		raw   string
+		value Int128
+	}{
+		{"18446744073709551616", Int128{Hi: 1, Lo: 0}},
+		{"-9223372036854775809", Int128{Hi: -1, Lo: math.MaxInt64}},
+		{"170141183460469231731687303715884105727", Int128{Hi: math.MaxInt64, Lo: math.MaxUint64}},
+		{"-170141183460469231731687303715884105728", Int128{Hi: math.MinInt64, Lo: 0}},
+	} {
+		data := fmt.Sprintf("<integer>%s</integer>", test.raw)
+		decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
+
+		value, err := decoder.NextValue()
+		assert.NoError(t, err, test.raw)
+		assert.Equal(t, test.value, value, test.raw)
+		assert.Equal(t, test.raw, test.value.String())
+	}
+}
+
 func TestDecodeBigInt(t *testing.T) {
 	var hugeInt big.Int
 	hugeInt.SetString("9999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999999", 10)
 	data := fmt.Sprintf("<integer>%s</integer>", hugeInt.String())
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -120,7 +175,7 @@ func TestDecodeBigInt(t *testing.T) {
 
 func TestDecodeReal(t *testing.T) {
 	data := "<real>3.14</real>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -131,7 +186,7 @@ func TestDecodeBigReal(t *testing.T) {
 	var hugeFloat big.Float
 	hugeFloat.SetString("3.14e+99999")
 	data := fmt.Sprintf("<real>%s</real>", hugeFloat.String())
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -140,7 +195,7 @@ func TestDecodeBigReal(t *testing.T) {
 
 func TestDecodeDate(t *testing.T) {
 	data := "<date>2015-08-01T02:03:04Z</date>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -149,7 +204,7 @@ func TestDecodeDate(t *testing.T) {
 
 func TestDecodeData(t *testing.T) {
 	data := "<data>aGVsbG8gd29ybGQ=</data>"
-	decoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	decoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := decoder.NextValue()
 	assert.NoError(t, err)
@@ -158,7 +213,7 @@ func TestDecodeData(t *testing.T) {
 
 func TestDecodeArray(t *testing.T) {
 	data := "<array></array>"
-	rootDecoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	rootDecoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := rootDecoder.NextValue()
 	assert.NoError(t, err)
@@ -176,7 +231,7 @@ func TestDecodeArray(t *testing.T) {
 
 func TestDecodeDict(t *testing.T) {
 	data := "<dict></dict>"
-	rootDecoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	rootDecoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := rootDecoder.NextValue()
 	assert.NoError(t, err)
@@ -142,6 +142,10 @@ func writeUint(e *xml.Encoder, val uint64) error {
 	return e.EncodeElement(val, integerStartElement)
 }
 
+func writeInt128(e *xml.Encoder, val Int128) error {
+	return e.EncodeElement(val.String(), integerStartElement)
+}
+
 func writeBigInt(e *xml.Encoder, val *big.Int) error {
 	return e.EncodeElement(val.String(), integerStartElement)
 }
@@ -0,0 +1,225 @@
+package xml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/*
+CharsetReader returns a reader that converts input from charset to UTF-8.
+It is used by PlistDecoder for plists that declare an encoding other than UTF-8,
+unless DecoderOptions.CharsetReader is set.
+
+Supported charsets are US-ASCII, ISO-8859-1 (Latin-1), and macintosh (Mac Roman).
+UTF-16 input is detected from its byte order mark, or the first characters of
+the XML declaration, before the declaration is read.
+*/
+func CharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "us-ascii", "ascii", "iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "l1":
+		// ASCII is a subset of Latin-1, and we might as well decode it leniently.
+		return newByteTableReader(input, nil), nil
+	case "macintosh", "macroman", "mac", "x-mac-roman":
+		return newByteTableReader(input, &macRomanTable), nil
+	case "utf-16", "utf-16be", "utf-16le":
+		return nil, fmt.Errorf("plist declares %s encoding, but doesn't start with a byte order mark", charset)
+	}
+	return nil, fmt.Errorf("unsupported charset: %s", charset)
+}
+
+// sniffEncoding detects UTF-16 and UTF-8 byte order marks at the start of r,
+// and returns a reader that produces UTF-8 without the mark. transcoded is true
+// if the input was converted from UTF-16, in which case the encoding in the XML
+// declaration should be ignored.
+func sniffEncoding(r io.Reader) (utf8Reader io.Reader, transcoded bool) {
+	buffered := bufio.NewReader(r)
+	// Peek returns whatever it can get on errors, which are returned again by
+	// the next read.
+	start, _ := buffered.Peek(4)
+
+	switch {
+	case bytes.HasPrefix(start, []byte{0xEF, 0xBB, 0xBF}):
+		buffered.Discard(3)
+		return buffered, false
+	case bytes.HasPrefix(start, []byte{0xFE, 0xFF}):
+		buffered.Discard(2)
+		return newUTF16Reader(buffered, binary.BigEndian), true
+	case bytes.HasPrefix(start, []byte{0xFF, 0xFE}):
+		buffered.Discard(2)
+		return newUTF16Reader(buffered, binary.LittleEndian), true
+	case bytes.Equal(start, []byte{0x00, '<', 0x00, '?'}):
+		return newUTF16Reader(buffered, binary.BigEndian), true
+	case bytes.Equal(start, []byte{'<', 0x00, '?', 0x00}):
+		return newUTF16Reader(buffered, binary.LittleEndian), true
+	}
+	return buffered, false
+}
+
+// utf16Reader converts UTF-16 to UTF-8.
+type utf16Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+
+	// raw holds bytes read from r that haven't been decoded yet.
+	raw []byte
+	// decoded holds UTF-8 that hasn't been returned yet.
+	decoded []byte
+	err     error
+}
+
+func newUTF16Reader(r io.Reader, order binary.ByteOrder) *utf16Reader {
+	return &utf16Reader{r: r, order: order}
+}
+
+func (r *utf16Reader) Read(p []byte) (int, error) {
+	for len(r.decoded) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+
+	n := copy(p, r.decoded)
+	r.decoded = r.decoded[n:]
+	return n, nil
+}
+
+// fill reads more input and decodes as much of it as possible.
+func (r *utf16Reader) fill() {
+	var buf [4096]byte
+	n, err := r.r.Read(buf[:])
+	r.raw = append(r.raw, buf[:n]...)
+	r.err = err
+
+	units := make([]uint16, 0, len(r.raw)/2)
+	for i := 0; i+1 < len(r.raw); i += 2 {
+		units = append(units, r.order.Uint16(r.raw[i:]))
+	}
+	// Keep an odd trailing byte and a trailing high surrogate until we
+	// have the rest of the character.
+	if err == nil && len(units) > 0 && isHighSurrogate(units[len(units)-1]) {
+		units = units[:len(units)-1]
+	}
+	r.raw = r.raw[2*len(units):]
+	if err != nil && len(r.raw) > 0 {
+		units = append(units, utf8.RuneError)
+		r.raw = nil
+	}
+
+	for _, rn := range utf16.Decode(units) {
+		r.decoded = appendRune(r.decoded, rn)
+	}
+}
+
+func isHighSurrogate(unit uint16) bool {
+	return unit >= 0xD800 && unit < 0xDC00
+}
+
+// byteTableReader converts a single-byte encoding to UTF-8. Bytes below 0x80
+// are ASCII, and the rest are looked up in table. If table is nil, bytes are
+// interpreted as Latin-1.
+type byteTableReader struct {
+	r       io.Reader
+	table   *[128]rune
+	decoded []byte
+}
+
+func newByteTableReader(r io.Reader, table *[128]rune) *byteTableReader {
+	return &byteTableReader{r: r, table: table}
+}
+
+func (r *byteTableReader) Read(p []byte) (int, error) {
+	if len(r.decoded) == 0 {
+		// Each byte decodes to at most 3 bytes of UTF-8.
+		buf := make([]byte, len(p)/3+1)
+		n, err := r.r.Read(buf)
+		for _, b := range buf[:n] {
+			switch {
+			case b < utf8.RuneSelf:
+				r.decoded = append(r.decoded, b)
+			case r.table == nil:
+				r.decoded = appendRune(r.decoded, rune(b))
+			default:
+				r.decoded = appendRune(r.decoded, r.table[b-0x80])
+			}
+		}
+		if len(r.decoded) == 0 {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.decoded)
+	r.decoded = r.decoded[n:]
+	return n, nil
+}
+
+// utf16Writer converts UTF-8 written to it to UTF-16, and writes it to w.
+type utf16Writer struct {
+	w     io.Writer
+	order binary.ByteOrder
+
+	// partial holds the start of a character split across writes.
+	partial []byte
+}
+
+func newUTF16Writer(w io.Writer, order binary.ByteOrder) *utf16Writer {
+	return &utf16Writer{w: w, order: order}
+}
+
+// writeByteOrderMark writes U+FEFF in the writer's byte order.
+func (w *utf16Writer) writeByteOrderMark() error {
+	_, err := w.Write([]byte("\uFEFF"))
+	return err
+}
+
+func (w *utf16Writer) Write(p []byte) (int, error) {
+	input := append(w.partial, p...)
+	var encoded []byte
+	for len(input) > 0 && utf8.FullRune(input) {
+		rn, size := utf8.DecodeRune(input)
+		input = input[size:]
+		for _, unit := range utf16.Encode([]rune{rn}) {
+			var buf [2]byte
+			w.order.PutUint16(buf[:], unit)
+			encoded = append(encoded, buf[:]...)
+		}
+	}
+	w.partial = append([]byte(nil), input...)
+
+	if _, err := w.w.Write(encoded); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func appendRune(b []byte, r rune) []byte {
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	return append(b, buf[:n]...)
+}
+
+// macRomanTable maps bytes 0x80-0xFF in the Mac OS Roman encoding to Unicode.
+var macRomanTable = [128]rune{
+	0x00C4, 0x00C5, 0x00C7, 0x00C9, 0x00D1, 0x00D6, 0x00DC, 0x00E1,
+	0x00E0, 0x00E2, 0x00E4, 0x00E3, 0x00E5, 0x00E7, 0x00E9, 0x00E8,
+	0x00EA, 0x00EB, 0x00ED, 0x00EC, 0x00EE, 0x00EF, 0x00F1, 0x00F3,
+	0x00F2, 0x00F4, 0x00F6, 0x00F5, 0x00FA, 0x00F9, 0x00FB, 0x00FC,
+	0x2020, 0x00B0, 0x00A2, 0x00A3, 0x00A7, 0x2022, 0x00B6, 0x00DF,
+	0x00AE, 0x00A9, 0x2122, 0x00B4, 0x00A8, 0x2260, 0x00C6, 0x00D8,
+	0x221E, 0x00B1, 0x2264, 0x2265, 0x00A5, 0x00B5, 0x2202, 0x2211,
+	0x220F, 0x03C0, 0x222B, 0x00AA, 0x00BA, 0x03A9, 0x00E6, 0x00F8,
+	0x00BF, 0x00A1, 0x00AC, 0x221A, 0x0192, 0x2248, 0x2206, 0x00AB,
+	0x00BB, 0x2026, 0x00A0, 0x00C0, 0x00C3, 0x00D5, 0x0152, 0x0153,
+	0x2013, 0x2014, 0x201C, 0x201D, 0x2018, 0x2019, 0x00F7, 0x25CA,
+	0x00FF, 0x0178, 0x2044, 0x20AC, 0x2039, 0x203A, 0xFB01, 0xFB02,
+	0x2021, 0x00B7, 0x201A, 0x201E, 0x2030, 0x00C2, 0x00CA, 0x00C1,
+	0x00CB, 0x00C8, 0x00CD, 0x00CE, 0x00CF, 0x00CC, 0x00D3, 0x00D4,
+	0xF8FF, 0x00D2, 0x00DA, 0x00DB, 0x00D9, 0x0131, 0x02C6, 0x02DC,
+	0x00AF, 0x02D8, 0x02D9, 0x02DA, 0x00B8, 0x02DD, 0x02DB, 0x02C7,
+}
@@ -0,0 +1,139 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeUTF16(s string, order binary.ByteOrder) []byte {
+	var buf bytes.Buffer
+	for _, unit := range utf16.Encode([]rune(s)) {
+		binary.Write(&buf, order, unit)
+	}
+	return buf.Bytes()
+}
+
+// decodeFirstString returns the first value in a plist with a root array.
+func decodeFirstString(t *testing.T, decoder *PlistDecoder) interface{} {
+	value, err := decoder.NextValue()
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	assert.Equal(t, StartDecodingArray{}, value)
+
+	value, err = decoder.NextValue()
+	assert.NoError(t, err)
+	return value
+}
+
+func TestDecodeUTF16(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="UTF-16"?>
+<plist version="1.0"><array><string>héllo 𝄞</string></array></plist>`
+
+	for _, input := range [][]byte{
+		encodeUTF16("\uFEFF"+plist, binary.BigEndian),
+		encodeUTF16("\uFEFF"+plist, binary.LittleEndian),
+		encodeUTF16(plist, binary.BigEndian),
+		encodeUTF16(plist, binary.LittleEndian),
+	} {
+		decoder := NewDecoder(bytes.NewReader(input))
+		assert.Equal(t, "héllo 𝄞", decodeFirstString(t, decoder))
+	}
+}
+
+func TestDecodeUTF8ByteOrderMark(t *testing.T) {
+	plist := "\uFEFF" + `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><array><string>héllo</string></array></plist>`
+	decoder := NewDecoder(strings.NewReader(plist))
+	assert.Equal(t, "héllo", decodeFirstString(t, decoder))
+}
+
+func TestDecodeLatin1(t *testing.T) {
+	plist := []byte(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<plist version="1.0"><array><string>h` + "\xe9" + `llo</string></array></plist>`)
+	decoder := NewDecoder(bytes.NewReader(plist))
+	assert.Equal(t, "héllo", decodeFirstString(t, decoder))
+}
+
+func TestDecodeMacRoman(t *testing.T) {
+	plist := []byte(`<?xml version="1.0" encoding="macintosh"?>
+<plist version="1.0"><array><string>h` + "\x8e" + `llo ` + "\xf0" + `</string></array></plist>`)
+	decoder := NewDecoder(bytes.NewReader(plist))
+	assert.Equal(t, "héllo ", decodeFirstString(t, decoder))
+}
+
+func TestDecodeUnsupportedCharset(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="EBCDIC"?><plist version="1.0"><array></array></plist>`
+	decoder := NewDecoder(strings.NewReader(plist))
+	_, err := decoder.NextValue()
+	assert.Error(t, err)
+}
+
+func TestDecodeCustomCharsetReader(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="rot13"?><plist version="1.0"><array><string>uryyb</string></array></plist>`
+	decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{
+		CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+			assert.Equal(t, "rot13", charset)
+			data, err := ioutil.ReadAll(input)
+			rotated := strings.Replace(string(data), "uryyb", "hello", 1)
+			return strings.NewReader(rotated), err
+		},
+	})
+	assert.Equal(t, "hello", decodeFirstString(t, decoder))
+}
+
+func TestEncodeUTF16(t *testing.T) {
+	expected := "\uFEFF" + `<?xml version="1.0" encoding="UTF-16"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+	<array>
+		<string>héllo 𝄞</string>
+	</array>
+</plist>`
+
+	for _, test := range []struct {
+		encoding Encoding
+		order    binary.ByteOrder
+	}{
+		{UTF16BigEndian, binary.BigEndian},
+		{UTF16LittleEndian, binary.LittleEndian},
+	} {
+		var buffer bytes.Buffer
+		assert.NoError(t, EncodeArrayPlistWithOptions(&buffer, EncoderOptions{test.encoding}, func(e *ArrayEncoder) error {
+			return e.WriteString("héllo 𝄞")
+		}))
+		assert.Equal(t, encodeUTF16(expected, test.order), buffer.Bytes())
+
+		decoder := NewDecoder(&buffer)
+		assert.Equal(t, "héllo 𝄞", decodeFirstString(t, decoder))
+	}
+}
+
+func TestUTF16ReaderSplitsSurrogates(t *testing.T) {
+	input := encodeUTF16("a𝄞b", binary.BigEndian)
+	// Read one byte at a time to split the surrogate pair across reads.
+	reader := newUTF16Reader(&oneByteReader{input}, binary.BigEndian)
+	output, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "a𝄞b", string(output))
+}
+
+type oneByteReader struct {
+	data []byte
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p[:1], r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
@@ -0,0 +1,166 @@
+package xml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+DecoderOptions configures a PlistDecoder.
+
+The Max limits bound the resources the decoder will use, for reading plists
+from untrusted sources. A zero value for any limit means that limit is not enforced.
+
+The decoder never expands entities or loads external DTDs, regardless of options.
+*/
+type DecoderOptions struct {
+	// Strict makes the decoder enforce Apple's plist DTD, reporting anything
+	// it would otherwise tolerate: unknown elements or attributes, content
+	// inside <true/> and <false/>, stray text between elements, duplicate dict
+	// keys, a version other than "1.0", and anything but comments and
+	// processing instructions after </plist>. Decoding carries on past each
+	// violation, and once the plist has been read they are all returned in a
+	// ValidationErrors. A <plist> without an <array> or <dict> in it ends
+	// decoding at once.
+	Strict bool
+
+	// Lenient makes the decoder recover from common damage instead of failing:
+	// unknown elements are skipped, integers may have surrounding whitespace
+	// or a + sign, data may be missing its padding, and a
+	// truncated file is treated as if all open containers were closed.
+	// Each recovery is recorded in PlistDecoder.Warnings.
+	// Ignored if Strict is set.
+	Lenient bool
+
+	// FastScanner makes the decoder read plists with a scanner written for the
+	// small subset of XML plists use, instead of encoding/xml. It's several
+	// times faster, and allocates little more than the values it returns, but
+	// isn't as thorough at rejecting malformed XML: it doesn't check that text
+	// is valid UTF-8, or that attributes and the DOCTYPE are well formed.
+	// Strict and lenient decoding always use encoding/xml, so FastScanner is
+	// ignored if either is set.
+	FastScanner bool
+
+	// CharsetReader, if not nil, is used instead of the package-level
+	// CharsetReader to convert plists that declare an encoding other than UTF-8.
+	// UTF-16 is always detected and converted before it is called.
+	CharsetReader func(charset string, input io.Reader) (io.Reader, error)
+
+	// MaxDepth is the maximum number of nested arrays and dicts.
+	// The root container has a depth of 1.
+	MaxDepth int
+
+	// MaxContainerEntries is the maximum number of values in a single array,
+	// or entries in a single dict.
+	MaxContainerEntries int
+
+	// MaxStringBytes is the maximum length of a string or dict key. It also
+	// applies to the text of integers, reals, and dates.
+	MaxStringBytes int
+
+	// MaxDataBytes is the maximum length of a data value, after decoding.
+	MaxDataBytes int
+
+	// MaxTotalBytes is the maximum number of bytes that will be read from the
+	// underlying reader.
+	MaxTotalBytes int64
+}
+
+// LimitError is returned by PlistDecoder when a plist exceeds one of the limits
+// set in its DecoderOptions.
+type LimitError struct {
+	// Limit is the name of the DecoderOptions field that was exceeded.
+	Limit string
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("plist exceeds %s (%d)", e.Limit, e.Max)
+}
+
+// ValidationError describes one way a plist doesn't conform to the plist DTD.
+type ValidationError struct {
+	// Offset is the position in the input just after the offending token.
+	Offset int64
+	Msg    string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid plist at offset %d: %s", e.Offset, e.Msg)
+}
+
+// ValidationErrors is returned by a strict PlistDecoder when the plist doesn't
+// conform to the plist DTD. It holds every violation, in the order they were found.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// violationFunc records a ValidationError.
+type violationFunc func(format string, args ...interface{})
+
+func validationErrorf(xmlDecoder *xml.Decoder, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{
+		Offset: xmlDecoder.InputOffset(),
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// Warning describes something a lenient PlistDecoder recovered from.
+type Warning struct {
+	// Offset is the position in the input just after the damage.
+	Offset int64
+	Msg    string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("offset %d: %s", w.Offset, w.Msg)
+}
+
+// warnFunc records a Warning.
+type warnFunc func(format string, args ...interface{})
+
+// describeToken formats token for error messages.
+func describeToken(token xml.Token) string {
+	switch token := token.(type) {
+	case xml.StartElement:
+		return fmt.Sprintf("<%s>", token.Name.Local)
+	case xml.EndElement:
+		return fmt.Sprintf("</%s>", token.Name.Local)
+	case xml.CharData:
+		return fmt.Sprintf("text %q", string(token))
+	case xml.Directive:
+		return fmt.Sprintf("<!%s>", string(token))
+	}
+	return fmt.Sprintf("%#v", token)
+}
+
+// limitedReader reads from r until more than max bytes have been read, then
+// returns a *LimitError.
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	// Read at most one byte past the limit, so we can tell the difference
+	// between input that is exactly max bytes long and input that is longer.
+	if remaining := r.max - r.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if r.read > r.max {
+		return 0, &LimitError{"MaxTotalBytes", r.max}
+	}
+	return n, err
+}
@@ -0,0 +1,297 @@
+package xml
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeAll reads values out of decoder until the root container is closed
+// or an error is returned.
+func decodeAll(decoder *PlistDecoder) error {
+	depth := 0
+	for {
+		value, err := decoder.NextValue()
+		if err != nil {
+			return err
+		}
+
+		switch value := value.(type) {
+		case StartDecodingArray, StartDecodingDict:
+			depth++
+		case DictEntry:
+			switch value.Value.(type) {
+			case StartDecodingArray, StartDecodingDict:
+				depth++
+			}
+		case EndDecodingContainer:
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+func TestDecodeMaxDepth(t *testing.T) {
+	plist := `<plist><array><dict><key>a</key><array></array></dict></array></plist>`
+
+	decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxDepth: 3})
+	assert.NoError(t, decodeAll(decoder))
+
+	decoder = NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxDepth: 2})
+	assert.Equal(t, &LimitError{"MaxDepth", 2}, decodeAll(decoder))
+}
+
+func TestDecodeMaxContainerEntries(t *testing.T) {
+	plist := `<plist><dict>
+		<key>a</key><integer>1</integer>
+		<key>b</key><array><true/><true/><true/></array>
+	</dict></plist>`
+
+	decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxContainerEntries: 3})
+	assert.NoError(t, decodeAll(decoder))
+
+	decoder = NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxContainerEntries: 2})
+	assert.Equal(t, &LimitError{"MaxContainerEntries", 2}, decodeAll(decoder))
+}
+
+func TestDecodeMaxStringBytes(t *testing.T) {
+	plist := `<plist><dict><key>key</key><string>hello</string></dict></plist>`
+
+	decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxStringBytes: 5})
+	assert.NoError(t, decodeAll(decoder))
+
+	decoder = NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxStringBytes: 4})
+	assert.Equal(t, &LimitError{"MaxStringBytes", 4}, decodeAll(decoder))
+
+	decoder = NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxStringBytes: 2})
+	assert.Equal(t, &LimitError{"MaxStringBytes", 2}, decodeAll(decoder))
+}
+
+func TestDecodeMaxDataBytes(t *testing.T) {
+	// "hello world" is 11 bytes.
+	plist := `<plist><array><data>
+		aGVsbG8g
+		d29ybGQ=
+	</data></array></plist>`
+
+	decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxDataBytes: 11})
+	assert.NoError(t, decodeAll(decoder))
+
+	decoder = NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxDataBytes: 10})
+	assert.Equal(t, &LimitError{"MaxDataBytes", 10}, decodeAll(decoder))
+
+	decoder = NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxDataBytes: 3})
+	assert.Equal(t, &LimitError{"MaxDataBytes", 3}, decodeAll(decoder))
+}
+
+func TestDecodeMaxTotalBytes(t *testing.T) {
+	plist := `<plist><array><string>hello</string></array></plist>`
+
+	decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxTotalBytes: int64(len(plist))})
+	assert.NoError(t, decodeAll(decoder))
+
+	decoder = NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{MaxTotalBytes: 20})
+	assert.Equal(t, &LimitError{"MaxTotalBytes", 20}, decodeAll(decoder))
+}
+
+func TestDecodeEntityDeclaration(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist [
+	<!ENTITY a "aaaaaaaaaa">
+	<!ENTITY b "&a;&a;&a;&a;&a;&a;&a;&a;&a;&a;">
+]>
+<plist version="1.0"><array><string>&b;</string></array></plist>`
+	decoder := NewDecoder(bytes.NewReader([]byte(plist)))
+
+	value, err := decoder.NextValue()
+	assert.EqualError(t, err, "Entity declarations are not supported")
+	assert.Nil(t, value)
+}
+
+func TestDecodeUndeclaredEntity(t *testing.T) {
+	plist := `<plist version="1.0"><array><string>&xxe;</string></array></plist>`
+	decoder := NewDecoder(bytes.NewReader([]byte(plist)))
+
+	value, err := decoder.NextValue()
+	assert.NoError(t, err)
+	assert.Equal(t, StartDecodingArray{}, value)
+
+	value, err = decoder.NextValue()
+	assert.Error(t, err)
+	assert.Nil(t, value)
+}
+
+func TestDecodeStrictValid(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+	<dict>
+		<key>a</key>
+		<true/>
+		<key>b</key>
+		<array>
+			<false></false>
+			<!-- comment -->
+			<string>foo</string>
+		</array>
+	</dict>
+</plist>
+`
+	decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{Strict: true})
+	assert.NoError(t, decodeAll(decoder))
+
+	value, err := decoder.NextValue()
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, value)
+}
+
+func TestDecodeStrictInvalid(t *testing.T) {
+	for _, test := range []struct {
+		plist string
+		msg   string
+	}{
+		{`<plist version="2.0"><array></array></plist>`, `unsupported plist version "2.0"`},
+		{`<plist foo="bar"><array></array></plist>`, `unexpected attribute foo on <plist>`},
+		{`<plist>hello<array></array></plist>`, `unexpected text "hello"`},
+		{`<plist><string>foo</string></plist>`, `expected <array> or <dict>, found <string>`},
+		{`<plist><array><true>yes</true></array></plist>`, `<true> must be empty`},
+		{`<plist><array><false> </false></array></plist>`, `<false> must be empty`},
+		{`<plist><array>hello<true/></array></plist>`, `unexpected text "hello"`},
+		{`<plist><array><foo/></array></plist>`, `unexpected element <foo>`},
+		{`<plist><array><string id="a">foo</string></array></plist>`, `unexpected attributes on <string>`},
+		{`<plist><dict><key>a</key><true/><key>a</key><false/></dict></plist>`, `duplicate key "a"`},
+		{`<plist><dict><string>a</string></dict></plist>`, `expected <key> or </dict>, found <string>`},
+		{`<plist><array></array><array></array></plist>`, `expected </plist>, found <array>`},
+		{`<plist><array></array></plist><plist></plist>`, `unexpected <plist> after </plist>`},
+		{`<plist><array></array></plist>trailing`, `unexpected text "trailing" after </plist>`},
+	} {
+		decoder := NewDecoderWithOptions(strings.NewReader(test.plist), DecoderOptions{Strict: true})
+		err := decodeAll(decoder)
+		if assert.IsType(t, ValidationErrors{}, err, test.plist) && assert.Len(t, err, 1, test.plist) {
+			assert.Equal(t, test.msg, err.(ValidationErrors)[0].Msg, test.plist)
+		}
+	}
+}
+
+func TestDecodeStrictReportsEachViolation(t *testing.T) {
+	plist := `<plist version="2.0">
+	<dict>
+		<key>a</key>
+		<true>yes</true>
+		<key>a</key>
+		<array>
+			<foo><bar/></foo>
+			<string id="x">b</string>stray</array>
+		<string>c</string>
+	</dict>
+</plist>trailing`
+	decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{Strict: true})
+	value, err := decoder.DecodeValue()
+	assert.Nil(t, value)
+	if assert.IsType(t, ValidationErrors{}, err) {
+		var msgs []string
+		for _, err := range err.(ValidationErrors) {
+			msgs = append(msgs, err.Msg)
+		}
+		assert.Equal(t, []string{
+			`unsupported plist version "2.0"`,
+			`<true> must be empty`,
+			`duplicate key "a"`,
+			`unexpected element <foo>`,
+			`unexpected attributes on <string>`,
+			`unexpected text "stray"`,
+			`expected <key> or </dict>, found <string>`,
+			`unexpected text "trailing" after </plist>`,
+		}, msgs)
+	}
+	assert.Contains(t, err.Error(), `invalid plist at offset 21: unsupported plist version "2.0"; `)
+}
+
+func TestDecodeNotStrict(t *testing.T) {
+	plist := `<plist version="2.0"><dict>
+		<key>a</key><true>yes</true>
+		<key>a</key><false/>
+	</dict></plist>`
+	decoder := NewDecoder(strings.NewReader(plist))
+	assert.NoError(t, decodeAll(decoder))
+}
+
+func TestDecodeLenient(t *testing.T) {
+	plist := `<plist version="1.0">
+	<dict>
+		<key>a</key>
+		<integer> 42 </integer>
+		<foo><bar/></foo>
+		<key>b</key>
+		<array>
+			<integer>+7</integer>
+			<unknown>x</unknown>
+			<integer>0x1F</integer>
+			<integer>-0x10</integer>
+			<data>aGVsbG8gd29ybGQ</data>
+		</array>
+		<key>c</key>
+		<array>
+			<string>trunc`
+	decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{Lenient: true})
+
+	for _, expected := range []interface{}{
+		StartDecodingDict{},
+		DictEntry{"a", int64(42)},
+		DictEntry{"b", StartDecodingArray{}},
+		int64(7),
+		int64(31),
+		int64(-16),
+		[]byte("hello world"),
+		EndDecodingContainer{},
+		DictEntry{"c", StartDecodingArray{}},
+		EndDecodingContainer{},
+		EndDecodingContainer{},
+	} {
+		value, err := decoder.NextValue()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value)
+	}
+
+	value, err := decoder.NextValue()
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, value)
+
+	var msgs []string
+	for _, warning := range decoder.Warnings() {
+		msgs = append(msgs, warning.Msg)
+	}
+	assert.Equal(t, []string{
+		`trimmed whitespace from integer " 42 "`,
+		`skipped <foo> in dict`,
+		`removed + sign from integer "+7"`,
+		`skipped unknown element <unknown>`,
+		`added missing padding to data`,
+		`closed containers left open at end of truncated plist`,
+	}, msgs)
+}
+
+func TestDecodeLenientInvalidSign(t *testing.T) {
+	for _, raw := range []string{"+-5", "++5", "+", "+ 5"} {
+		plist := `<plist version="1.0"><array><integer>` + raw + `</integer></array></plist>`
+		decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{Lenient: true})
+		assert.Error(t, decodeAll(decoder), raw)
+		assert.Empty(t, decoder.Warnings(), raw)
+	}
+}
+
+func TestDecodeNotLenient(t *testing.T) {
+	plist := `<plist version="1.0"><array><integer> 42 </integer></array></plist>`
+	decoder := NewDecoder(strings.NewReader(plist))
+	assert.Error(t, decodeAll(decoder))
+	assert.Empty(t, decoder.Warnings())
+
+	plist = `<plist version="1.0"><array><true/>`
+	decoder = NewDecoder(strings.NewReader(plist))
+	assert.Error(t, decodeAll(decoder))
+}
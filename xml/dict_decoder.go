@@ -13,15 +13,22 @@ type DictEntry struct {
 
 type dictDecoder struct {
 	baseDecoder
+
+	// keys is only used to detect duplicate keys when decoding strictly.
+	keys map[string]bool
 }
 
 var _ containerDecoder = &dictDecoder{}
 
-func newDictDecoder(parent containerDecoder, xmlDecoder *xml.Decoder) *dictDecoder {
-	return &dictDecoder{baseDecoder{parent, xmlDecoder}}
+func newDictDecoder(base baseDecoder) *dictDecoder {
+	return &dictDecoder{baseDecoder: base}
 }
 
 func (d *dictDecoder) NextValue() (interface{}, error) {
+	if d.scanner != nil {
+		return d.scanEntry()
+	}
+
 	for {
 		token, err := nextInterestingToken(d.xmlDecoder)
 		if err != nil {
@@ -31,6 +38,12 @@ func (d *dictDecoder) NextValue() (interface{}, error) {
 		switch token := token.(type) {
 		case xml.StartElement:
 			if token.Name == dictKeyElement.Name {
+				if violate := d.strict(); violate != nil && len(token.Attr) > 0 {
+					violate("unexpected attributes on <key>")
+				}
+				if err := d.countEntry(); err != nil {
+					return nil, err
+				}
 				return d.finishReadingEntry()
 			}
 		case xml.EndElement:
@@ -39,18 +52,46 @@ func (d *dictDecoder) NextValue() (interface{}, error) {
 			}
 		}
 
+		if violate := d.strict(); violate != nil {
+			violate("expected <key> or </dict>, found %s", describeToken(token))
+			if _, ok := token.(xml.StartElement); ok {
+				if err := d.xmlDecoder.Skip(); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if warn := d.lenient(); warn != nil {
+			warn("skipped %s in dict", describeToken(token))
+			if _, ok := token.(xml.StartElement); ok {
+				if err := d.xmlDecoder.Skip(); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
 		return nil, fmt.Errorf("Expected %s or %s, found %#v",
 			dictKeyElement.Name.Local, dictStartElement.End().Name.Local, token)
 	}
 }
 
 func (d *dictDecoder) finishReadingEntry() (interface{}, error) {
-	key, err := finishReadingKey(d.xmlDecoder)
+	key, err := finishReadingKey(d.xmlDecoder, d.opts().MaxStringBytes)
 	if err != nil {
 		return nil, err
 	}
 
-	value, err := d.baseDecoder.NextValue()
+	if violate := d.strict(); violate != nil {
+		if d.keys[key] {
+			violate("duplicate key %q", key)
+		}
+		if d.keys == nil {
+			d.keys = make(map[string]bool)
+		}
+		d.keys[key] = true
+	}
+
+	value, err := d.nextValue(d)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +99,8 @@ func (d *dictDecoder) finishReadingEntry() (interface{}, error) {
 	return DictEntry{key, value}, nil
 }
 
-func finishReadingKey(xmlDecoder *xml.Decoder) (string, error) {
-	value, err := readCharDataUntilEnd(xmlDecoder, dictKeyElement.End())
+func finishReadingKey(xmlDecoder *xml.Decoder, maxBytes int) (string, error) {
+	value, err := readCharDataUntilEnd(xmlDecoder, dictKeyElement.End(), maxBytes)
 	if err != nil {
 		return "", err
 	}
@@ -13,7 +13,7 @@ func TestDecodeDictEntries(t *testing.T) {
 		<key>foo</key>
 		<string>bar</string>
 	</dict>`
-	rootDecoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	rootDecoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := rootDecoder.NextValue()
 	assert.NoError(t, err)
@@ -36,7 +36,7 @@ func TestDecodeDictArray(t *testing.T) {
 			<string>foobar</string>
 		</array>
 	</dict>`
-	rootDecoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	rootDecoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := rootDecoder.NextValue()
 	assert.NoError(t, err)
@@ -70,7 +70,7 @@ func TestDecodeDictDict(t *testing.T) {
 			<string>bar</string>
 		</dict>
 	</dict>`
-	rootDecoder := baseDecoder{nil, xml.NewDecoder(bytes.NewReader([]byte(data)))}
+	rootDecoder := baseDecoder{xmlDecoder: xml.NewDecoder(bytes.NewReader([]byte(data)))}
 
 	value, err := rootDecoder.NextValue()
 	assert.NoError(t, err)
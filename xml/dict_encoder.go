@@ -67,6 +67,14 @@ func (e *DictEncoder) WriteUint(key string, val uint64) error {
 	return writeUint(e.xmlEncoder, val)
 }
 
+func (e *DictEncoder) WriteInt128(key string, val Int128) error {
+	e.assertReady()
+	if err := e.writeKey(key); err != nil {
+		return err
+	}
+	return writeInt128(e.xmlEncoder, val)
+}
+
 func (e *DictEncoder) WriteBigInt(key string, val *big.Int) error {
 	e.assertReady()
 	if err := e.writeKey(key); err != nil {
@@ -0,0 +1,47 @@
+package xml
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+)
+
+// Encoding is the character encoding of an encoded plist.
+type Encoding int
+
+const (
+	UTF8 Encoding = iota
+	// UTF16BigEndian is the UTF-16 encoding written by CoreFoundation.
+	UTF16BigEndian
+	UTF16LittleEndian
+)
+
+// EncoderOptions configures EncodeArrayPlistWithOptions and EncodeDictPlistWithOptions.
+type EncoderOptions struct {
+	// Encoding is the character encoding to write. UTF-16 output starts with
+	// a byte order mark.
+	Encoding Encoding
+}
+
+// writer returns a writer that encodes UTF-8 written to it as e, and writes it to w.
+func (e Encoding) writer(w io.Writer) io.Writer {
+	switch e {
+	case UTF16BigEndian:
+		return newUTF16Writer(w, binary.BigEndian)
+	case UTF16LittleEndian:
+		return newUTF16Writer(w, binary.LittleEndian)
+	}
+	return w
+}
+
+// procInst returns the XML declaration for a plist in encoding e.
+func (e Encoding) procInst() xml.ProcInst {
+	switch e {
+	case UTF16BigEndian, UTF16LittleEndian:
+		return xml.ProcInst{
+			Target: procInst.Target,
+			Inst:   []byte(`version="1.0" encoding="UTF-16"`),
+		}
+	}
+	return procInst
+}
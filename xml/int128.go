@@ -0,0 +1,51 @@
+package xml
+
+import (
+	"math"
+	"math/big"
+)
+
+// Int128 is a signed 128-bit integer. Binary plists can hold integers this
+// large, so PlistDecoder returns integers that don't fit in an int64 or uint64
+// as an Int128 if they can, and DictEncoder and ArrayEncoder can write them.
+type Int128 struct {
+	// Hi holds the upper 64 bits, including the sign.
+	Hi int64
+	Lo uint64
+}
+
+var (
+	minInt128 = new(big.Int).Lsh(big.NewInt(-1), 127)
+	maxInt128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+)
+
+// Int128FromBig converts i to an Int128. Returns false if i doesn't fit in 128 bits.
+func Int128FromBig(i *big.Int) (Int128, bool) {
+	if i.Cmp(minInt128) < 0 || i.Cmp(maxInt128) > 0 {
+		return Int128{}, false
+	}
+
+	// Work with the two's complement representation of negative numbers.
+	var unsigned big.Int
+	unsigned.Set(i)
+	if i.Sign() < 0 {
+		unsigned.Add(&unsigned, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+
+	mask := new(big.Int).SetUint64(math.MaxUint64)
+	lo := new(big.Int).And(&unsigned, mask).Uint64()
+	hi := new(big.Int).Rsh(&unsigned, 64).Uint64()
+	return Int128{Hi: int64(hi), Lo: lo}, true
+}
+
+// BigInt returns i as a *big.Int.
+func (i Int128) BigInt() *big.Int {
+	value := new(big.Int).SetInt64(i.Hi)
+	value.Lsh(value, 64)
+	return value.Or(value, new(big.Int).SetUint64(i.Lo))
+}
+
+// String formats i in decimal.
+func (i Int128) String() string {
+	return i.BigInt().String()
+}
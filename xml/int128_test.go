@@ -0,0 +1,57 @@
+package xml
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInt128FromBig(t *testing.T) {
+	for _, raw := range []string{
+		"0",
+		"-1",
+		"18446744073709551615",
+		"-18446744073709551616",
+		"170141183460469231731687303715884105727",
+		"-170141183460469231731687303715884105728",
+	} {
+		var i big.Int
+		i.SetString(raw, 10)
+
+		value, ok := Int128FromBig(&i)
+		assert.True(t, ok, raw)
+		assert.Equal(t, raw, value.BigInt().String())
+	}
+}
+
+func TestInt128FromBigOutOfRange(t *testing.T) {
+	for _, raw := range []string{
+		"170141183460469231731687303715884105728",
+		"-170141183460469231731687303715884105729",
+	} {
+		var i big.Int
+		i.SetString(raw, 10)
+
+		_, ok := Int128FromBig(&i)
+		assert.False(t, ok, raw)
+	}
+}
+
+func TestInt128RoundTrip(t *testing.T) {
+	value := Int128{Hi: -2, Lo: 12345}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, EncodeDictPlist(&buffer, func(e *DictEncoder) error {
+		return e.WriteInt128("a", value)
+	}))
+
+	decoder := NewDecoder(&buffer)
+	_, err := decoder.NextValue()
+	assert.NoError(t, err)
+
+	decoded, err := decoder.NextValue()
+	assert.NoError(t, err)
+	assert.Equal(t, DictEntry{"a", value}, decoded)
+}
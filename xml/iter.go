@@ -0,0 +1,164 @@
+//go:build go1.23
+
+package xml
+
+import (
+	"fmt"
+	"io"
+	"iter"
+)
+
+/*
+Values returns an iterator over the values NextValue returns, for use in a
+range loop. It ends at the end of the plist. If NextValue fails, the error is
+yielded with a nil value, and the iterator ends.
+*/
+func (d *PlistDecoder) Values() iter.Seq2[interface{}, error] {
+	return func(yield func(interface{}, error) bool) {
+		for {
+			value, err := d.NextValue()
+			if err == io.EOF {
+				return
+			}
+			if !yield(value, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+/*
+DictEntries returns an iterator over the keys and values of the rest of the
+current dict: the one whose StartDecodingDict was last returned, or the root
+dict if nothing has been decoded yet. For example:
+
+	for key, value := range decoder.DictEntries() {
+		...
+	}
+	if err := decoder.Err(); err != nil {
+		...
+	}
+
+Values that are arrays or dicts are yielded as StartDecodingArray or
+StartDecodingDict, and only read as far as the loop body reads them, so large
+plists can be streamed: the body can range over ArrayItems or DictEntries to
+stream the container, call FinishDecoding to decode it whole, or ignore it to
+skip it.
+
+The iterator ends after the dict's end, or when the loop ends early, after the
+entry the loop ended on. Either way, the rest of that entry's value is skipped
+if the loop body didn't read it. Errors end the iterator, and are returned by Err.
+*/
+func (d *PlistDecoder) DictEntries() iter.Seq2[string, interface{}] {
+	return func(yield func(string, interface{}) bool) {
+		d.iterate(StartDecodingDict{}, func(value interface{}) bool {
+			entry, ok := value.(DictEntry)
+			if !ok {
+				d.iterErr = fmt.Errorf("Expected dict entry, found %#v", value)
+				return false
+			}
+			return yield(entry.Key, entry.Value)
+		})
+	}
+}
+
+// ArrayItems returns an iterator over the indexes and values of the rest of
+// the current array. It works like DictEntries.
+func (d *PlistDecoder) ArrayItems() iter.Seq2[int, interface{}] {
+	return func(yield func(int, interface{}) bool) {
+		i := 0
+		d.iterate(StartDecodingArray{}, func(value interface{}) bool {
+			i++
+			return yield(i-1, value)
+		})
+	}
+}
+
+// Err returns the error that ended the last DictEntries or ArrayItems loop,
+// or nil if it ended at the end of its container or when the loop body did.
+func (d *PlistDecoder) Err() error {
+	return d.iterErr
+}
+
+// iterate calls yield with each value in the rest of the current container,
+// which must be of the kind start starts.
+func (d *PlistDecoder) iterate(start interface{}, yield func(interface{}) bool) {
+	d.iterErr = nil
+	if d.currentDecoder == nil && !d.truncated {
+		value, err := d.NextValue()
+		if err != nil {
+			d.iterErr = err
+			return
+		}
+		if value != start {
+			d.iterErr = fmt.Errorf("Expected %T, found %#v", start, value)
+			return
+		}
+	}
+
+	container := d.currentDecoder
+	switch container.(type) {
+	case *dictDecoder:
+		if start != (StartDecodingDict{}) {
+			d.iterErr = fmt.Errorf("Expected to be decoding an array, found dict")
+			return
+		}
+	case *arrayDecoder:
+		if start != (StartDecodingArray{}) {
+			d.iterErr = fmt.Errorf("Expected to be decoding a dict, found array")
+			return
+		}
+	default:
+		d.iterErr = fmt.Errorf("Expected to be decoding a container, found end of plist")
+		return
+	}
+
+	for {
+		value, err := d.NextValue()
+		if err != nil {
+			d.iterErr = err
+			return
+		}
+		if _, ok := value.(EndDecodingContainer); ok {
+			return
+		}
+
+		more := yield(value)
+		if d.iterErr != nil {
+			return
+		}
+		open, err := d.skipInto(container)
+		if err != nil {
+			d.iterErr = err
+			return
+		}
+		if !more || !open {
+			return
+		}
+	}
+}
+
+// skipInto skips values until container is the current container again, and
+// returns false if container has already ended.
+func (d *PlistDecoder) skipInto(container containerDecoder) (bool, error) {
+	for d.currentDecoder != container {
+		if !isInside(d.currentDecoder, container) {
+			return false, nil
+		}
+		if _, err := d.NextValue(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// isInside returns whether c is nested in container.
+func isInside(c, container containerDecoder) bool {
+	for c != nil {
+		c = c.ParentDecoder()
+		if c == container {
+			return true
+		}
+	}
+	return false
+}
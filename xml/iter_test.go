@@ -0,0 +1,230 @@
+//go:build go1.23
+
+package xml
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const iterPlist = `<plist version="1.0">
+	<dict>
+		<key>a</key>
+		<array>
+			<string>foo</string>
+			<dict><key>x</key><integer>1</integer></dict>
+		</array>
+		<key>b</key>
+		<dict>
+			<key>c</key>
+			<string>bar</string>
+		</dict>
+		<key>d</key>
+		<string>baz</string>
+	</dict>
+</plist>`
+
+func TestValues(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(iterPlist))
+	var values []interface{}
+	for value, err := range decoder.Values() {
+		assert.NoError(t, err)
+		values = append(values, value)
+	}
+	assert.Equal(t, []interface{}{
+		StartDecodingDict{},
+		DictEntry{"a", StartDecodingArray{}},
+		"foo",
+		StartDecodingDict{},
+		DictEntry{"x", int64(1)},
+		EndDecodingContainer{},
+		EndDecodingContainer{},
+		DictEntry{"b", StartDecodingDict{}},
+		DictEntry{"c", "bar"},
+		EndDecodingContainer{},
+		DictEntry{"d", "baz"},
+		EndDecodingContainer{},
+	}, values)
+
+	decoder = NewDecoder(strings.NewReader(`<plist><array><foo/></array></plist>`))
+	var errs []error
+	for _, err := range decoder.Values() {
+		errs = append(errs, err)
+	}
+	if assert.Len(t, errs, 2) {
+		assert.NoError(t, errs[0])
+		assert.Error(t, errs[1])
+	}
+}
+
+func TestDictEntries(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(iterPlist))
+	var entries []string
+	for key, value := range decoder.DictEntries() {
+		switch key {
+		case "a":
+			assert.Equal(t, StartDecodingArray{}, value)
+			for i, item := range decoder.ArrayItems() {
+				item, err := decoder.FinishDecoding(item)
+				assert.NoError(t, err)
+				entries = append(entries, fmt.Sprintf("a[%d]=%v", i, item))
+			}
+		case "b":
+			// Skipped.
+			assert.Equal(t, StartDecodingDict{}, value)
+			entries = append(entries, "b")
+		default:
+			entries = append(entries, fmt.Sprintf("%s=%v", key, value))
+		}
+	}
+	assert.NoError(t, decoder.Err())
+	assert.Equal(t, []string{"a[0]=foo", "a[1]=map[x:1]", "b", "d=baz"}, entries)
+
+	_, err := decoder.NextValue()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDictEntriesBreak(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(iterPlist))
+	for key := range decoder.DictEntries() {
+		if key == "a" {
+			break
+		}
+	}
+	assert.NoError(t, decoder.Err())
+
+	// The rest of the array was skipped.
+	value, err := decoder.NextValue()
+	assert.NoError(t, err)
+	assert.Equal(t, DictEntry{"b", StartDecodingDict{}}, value)
+
+	var keys []string
+	for key := range decoder.DictEntries() {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"c"}, keys)
+
+	for key := range decoder.DictEntries() {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"c", "d"}, keys)
+}
+
+func TestDictEntriesStartedContainer(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(iterPlist))
+	value, err := decoder.NextValue()
+	assert.NoError(t, err)
+	assert.Equal(t, StartDecodingDict{}, value)
+	value, err = decoder.NextValue()
+	assert.NoError(t, err)
+	assert.Equal(t, DictEntry{"a", StartDecodingArray{}}, value)
+
+	var items []interface{}
+	for _, item := range decoder.ArrayItems() {
+		item, err := decoder.FinishDecoding(item)
+		assert.NoError(t, err)
+		items = append(items, item)
+	}
+	assert.NoError(t, decoder.Err())
+	assert.Equal(t, []interface{}{"foo", map[string]interface{}{"x": int64(1)}}, items)
+}
+
+func TestDictEntriesLoopBodyReadsPastEnd(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(`<plist><array><dict><key>a</key><string>x</string></dict><string>y</string></array></plist>`))
+	assert.NoError(t, decoder.Err())
+
+	var values []interface{}
+	for _, item := range decoder.ArrayItems() {
+		values = append(values, item)
+		for key, value := range decoder.DictEntries() {
+			values = append(values, key, value)
+		}
+		// Reads the next array item, and then the end of the array.
+		value, err := decoder.NextValue()
+		assert.NoError(t, err)
+		values = append(values, value)
+		value, err = decoder.NextValue()
+		assert.NoError(t, err)
+		values = append(values, value)
+	}
+	assert.NoError(t, decoder.Err())
+	assert.Equal(t, []interface{}{StartDecodingDict{}, "a", "x", "y", EndDecodingContainer{}}, values)
+}
+
+func TestIteratorErrors(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(`<plist><array><string>a</string></array></plist>`))
+	for range decoder.DictEntries() {
+		t.Fatal("unexpected entry")
+	}
+	assert.EqualError(t, decoder.Err(), "Expected xml.StartDecodingDict, found xml.StartDecodingArray{}")
+
+	for range decoder.DictEntries() {
+		t.Fatal("unexpected entry")
+	}
+	assert.EqualError(t, decoder.Err(), "Expected to be decoding a dict, found array")
+
+	var items []interface{}
+	for _, item := range decoder.ArrayItems() {
+		items = append(items, item)
+	}
+	assert.NoError(t, decoder.Err())
+	assert.Equal(t, []interface{}{"a"}, items)
+
+	for range decoder.ArrayItems() {
+		t.Fatal("unexpected item")
+	}
+	assert.Equal(t, io.EOF, decoder.Err())
+
+	decoder = NewDecoder(strings.NewReader(`<plist><dict><key>a</key><dict><key>b</key><foo/></dict><key>c</key><true/></dict></plist>`))
+	var keys []string
+	for key := range decoder.DictEntries() {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"a"}, keys)
+	assert.Error(t, decoder.Err())
+}
+
+func TestDictEntriesStreaming(t *testing.T) {
+	r, w := io.Pipe()
+	go func() {
+		fmt.Fprint(w, "<plist><dict>")
+		for i := 0; i < 10000; i++ {
+			fmt.Fprintf(w, "<key>%d</key><dict><key>Name</key><string>%d</string></dict>", i, i)
+		}
+		fmt.Fprint(w, "</dict></plist>")
+		w.Close()
+	}()
+
+	decoder := NewDecoder(r)
+	n := 0
+	for key, value := range decoder.DictEntries() {
+		track, err := decoder.FinishDecoding(value)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"Name": key}, track)
+		n++
+	}
+	assert.NoError(t, decoder.Err())
+	assert.Equal(t, 10000, n)
+}
+
+func TestDictEntriesLenientTruncated(t *testing.T) {
+	decoder := NewDecoderWithOptions(strings.NewReader(`<plist><dict><key>a</key><dict><key>b</key><string>x</string>`), DecoderOptions{Lenient: true})
+	var keys []string
+	for key := range decoder.DictEntries() {
+		keys = append(keys, key)
+		for key := range decoder.DictEntries() {
+			keys = append(keys, key)
+		}
+	}
+	assert.NoError(t, decoder.Err())
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Len(t, decoder.Warnings(), 1)
+
+	for range decoder.DictEntries() {
+	}
+	assert.EqualError(t, decoder.Err(), "Expected to be decoding a container, found end of plist")
+}
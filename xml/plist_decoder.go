@@ -1,7 +1,9 @@
 package xml
 
 import (
+	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -22,7 +24,18 @@ type EndDecodingContainer struct{}
 // PlistDecoder parses XML plist data.
 type PlistDecoder struct {
 	xmlDecoder     *xml.Decoder
+	scanner        *scanner
+	options        DecoderOptions
 	currentDecoder containerDecoder
+	warnings       []Warning
+	violations     []*ValidationError
+
+	// truncated is set when a lenient decoder reaches the end of the input
+	// with containers still open.
+	truncated bool
+
+	// iterErr is the error that ended the last DictEntries or ArrayItems loop.
+	iterErr error
 }
 
 type containerDecoder interface {
@@ -36,8 +49,40 @@ type containerDecoder interface {
 
 // NewDecoder creates a decoder that reads a plist file from r.
 func NewDecoder(r io.Reader) *PlistDecoder {
+	return NewDecoderWithOptions(r, DecoderOptions{})
+}
+
+// NewDecoderWithOptions creates a decoder that reads a plist file from r,
+// and fails with a *LimitError if the file exceeds any of the limits in options.
+func NewDecoderWithOptions(r io.Reader, options DecoderOptions) *PlistDecoder {
+	if options.MaxTotalBytes > 0 {
+		r = &limitedReader{r: r, max: options.MaxTotalBytes}
+	}
+
+	r, transcoded := sniffEncoding(r)
+	charsetReader := func(charset string, input io.Reader) (io.Reader, error) {
+		if transcoded && strings.HasPrefix(strings.ToLower(charset), "utf-16") {
+			// Already converted to UTF-8.
+			return input, nil
+		}
+		if options.CharsetReader != nil {
+			return options.CharsetReader(charset, input)
+		}
+		return CharsetReader(charset, input)
+	}
+
+	if options.FastScanner && !options.Strict && !options.Lenient {
+		return &PlistDecoder{
+			scanner: newScanner(r, charsetReader),
+			options: options,
+		}
+	}
+
+	xmlDecoder := xml.NewDecoder(r)
+	xmlDecoder.CharsetReader = charsetReader
 	return &PlistDecoder{
-		xmlDecoder: xml.NewDecoder(r),
+		xmlDecoder: xmlDecoder,
+		options:    options,
 	}
 }
 
@@ -51,11 +96,21 @@ If StartDecodingArray is returned, the decoder encountered an <array> opening ta
 and will return the values of the array until a matching EndDecodingContainer is returned.
 
 StartDecodingDict means the same thing for dictionaries. Dictionary entries are returned
-as DictEntry values.
+as DictEntry values. If an entry's value is itself an array or dictionary, its Value is
+StartDecodingArray or StartDecodingDict, and the values of that container follow.
 */
 func (d *PlistDecoder) NextValue() (interface{}, error) {
 	var value interface{}
 
+	if d.truncated {
+		// Close the containers that were still open one at a time.
+		if d.currentDecoder == nil {
+			return nil, io.EOF
+		}
+		d.currentDecoder = d.currentDecoder.ParentDecoder()
+		return EndDecodingContainer{}, nil
+	}
+
 	// The first time NextValue() is called, we need to skip past
 	// all the XML header stuff.
 	decoder, err := d.consumeHeader()
@@ -69,6 +124,14 @@ func (d *PlistDecoder) NextValue() (interface{}, error) {
 		value = decoder
 	} else {
 		value, err = d.currentDecoder.NextValue()
+		if d.isLenient() && isUnexpectedEOF(err) {
+			d.truncated = true
+			d.warnings = append(d.warnings, Warning{
+				Offset: d.xmlDecoder.InputOffset(),
+				Msg:    "closed containers left open at end of truncated plist",
+			})
+			value, err = EndDecodingContainer{}, nil
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -86,12 +149,68 @@ func (d *PlistDecoder) NextValue() (interface{}, error) {
 	case EndDecodingContainer:
 		// Pop the current decoder.
 		d.currentDecoder = d.currentDecoder.ParentDecoder()
+		if d.currentDecoder == nil && d.options.Strict {
+			if err := d.finishReadingPlist(); err != nil {
+				return nil, err
+			}
+			if err := d.validationErr(); err != nil {
+				return nil, err
+			}
+		}
 		return EndDecodingContainer{}, nil
+	case DictEntry:
+		switch entryValue := value.Value.(type) {
+		case *arrayDecoder:
+			d.currentDecoder = entryValue
+			value.Value = StartDecodingArray{}
+		case *dictDecoder:
+			d.currentDecoder = entryValue
+			value.Value = StartDecodingDict{}
+		}
+		return value, nil
 	}
 
 	return value, nil
 }
 
+// Warnings returns the recoveries made so far by a lenient decoder.
+func (d *PlistDecoder) Warnings() []Warning {
+	return d.warnings
+}
+
+// strict returns a function to record violations with if decoding strictly,
+// otherwise nil.
+func (d *PlistDecoder) strict() violationFunc {
+	if !d.options.Strict {
+		return nil
+	}
+	return func(format string, args ...interface{}) {
+		d.violations = append(d.violations, validationErrorf(d.xmlDecoder, format, args...))
+	}
+}
+
+// validationErr returns the violations found so far, or nil if there are none.
+func (d *PlistDecoder) validationErr() error {
+	if len(d.violations) == 0 {
+		return nil
+	}
+	return ValidationErrors(d.violations)
+}
+
+func (d *PlistDecoder) isLenient() bool {
+	return d.options.Lenient && !d.options.Strict
+}
+
+func isUnexpectedEOF(err error) bool {
+	if err == io.ErrUnexpectedEOF {
+		return true
+	}
+	if err, ok := err.(*xml.SyntaxError); ok && err.Msg == "unexpected EOF" {
+		return true
+	}
+	return false
+}
+
 // consumeHeader reads tokens until we find a <plist> or an error.
 // It then reads the next container start tag, and returns the appropriate decoder for
 // that type.
@@ -100,13 +219,23 @@ func (d *PlistDecoder) consumeHeader() (containerDecoder, error) {
 	if d.currentDecoder != nil {
 		return nil, nil
 	}
+	if d.scanner != nil {
+		elem, err := d.scanner.readHeader()
+		if err != nil {
+			return nil, err
+		}
+		return d.rootDecoder(elem == dictElement)
+	}
 
-	token, err := nextStartElement(d.xmlDecoder)
+	token, err := nextStartElementAfterHeader(d.xmlDecoder, d.strict())
 	if err != nil {
 		return nil, err
 	}
 
 	if token.Name == plistStartElement.Name {
+		if violate := d.strict(); violate != nil {
+			validatePlistAttrs(token.Attr, violate)
+		}
 		return d.startFirstContainer()
 	}
 
@@ -117,30 +246,134 @@ func (d *PlistDecoder) consumeHeader() (containerDecoder, error) {
 // startFirstContainer reads until the next <array> or <dict> and returns
 // the appopriate containerDecoder.
 func (d *PlistDecoder) startFirstContainer() (containerDecoder, error) {
-	token, err := nextStartElement(d.xmlDecoder)
+	token, err := nextStartElement(d.xmlDecoder, d.strict())
 	if err != nil {
 		return nil, err
 	}
 
 	switch token.Name.Local {
 	case arrayStartElement.Name.Local:
-		return newArrayDecoder(nil, d.xmlDecoder), nil
+		return d.rootDecoder(false)
 	case dictStartElement.Name.Local:
-		return newDictDecoder(nil, d.xmlDecoder), nil
+		return d.rootDecoder(true)
 	}
 
+	if violate := d.strict(); violate != nil {
+		violate("expected <array> or <dict>, found <%s>", token.Name.Local)
+		return nil, d.validationErr()
+	}
 	return nil, fmt.Errorf("Expected container start element, found %#v", token)
 }
 
-func nextStartElement(xmlDecoder *xml.Decoder) (xml.StartElement, error) {
+// rootDecoder returns the decoder for the root container, which is a dict if
+// dict is true, or an array.
+func (d *PlistDecoder) rootDecoder(dict bool) (containerDecoder, error) {
+	root := baseDecoder{
+		xmlDecoder: d.xmlDecoder,
+		scanner:    d.scanner,
+		options:    &d.options,
+		warnings:   &d.warnings,
+		violations: &d.violations,
+	}
+	child, err := root.child(nil)
+	if err != nil {
+		return nil, err
+	}
+	if dict {
+		return newDictDecoder(child), nil
+	}
+	return newArrayDecoder(child), nil
+}
+
+// finishReadingPlist reads the </plist> end tag after the root container,
+// and then checks that there is nothing else in the file, recording any
+// violations. Only used when decoding strictly.
+func (d *PlistDecoder) finishReadingPlist() error {
+	violate := d.strict()
+	token, err := nextInterestingToken(d.xmlDecoder)
+	if err == io.EOF {
+		violate("missing </plist>")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if token != plistStartElement.End() {
+		violate("expected </plist>, found %s", describeToken(token))
+		return nil
+	}
+
+	for {
+		token, err := nextInterestingToken(d.xmlDecoder)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, ok := token.(xml.ProcInst); ok {
+			continue
+		}
+		violate("unexpected %s after </plist>", describeToken(token))
+		if _, ok := token.(xml.StartElement); ok {
+			if err := d.xmlDecoder.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// validatePlistAttrs checks that the only attribute on <plist> is version="1.0".
+func validatePlistAttrs(attrs []xml.Attr, violate violationFunc) {
+	for _, attr := range attrs {
+		if attr.Name != plistStartElement.Attr[0].Name {
+			violate("unexpected attribute %s on <plist>", attr.Name.Local)
+		} else if attr.Value != plistStartElement.Attr[0].Value {
+			violate("unsupported plist version %q", attr.Value)
+		}
+	}
+}
+
+// nextStartElement skips tokens until the next start element.
+// If violate is not nil, text and end elements are reported to it.
+func nextStartElement(xmlDecoder *xml.Decoder, violate violationFunc) (xml.StartElement, error) {
+	for {
+		token, err := nextInterestingToken(xmlDecoder)
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			return token, nil
+		case xml.CharData, xml.EndElement:
+			if violate != nil {
+				violate("unexpected %s", describeToken(token))
+			}
+		}
+	}
+}
+
+// nextStartElementAfterHeader is like nextStartElement, but fails if the
+// document type declaration declares any entities. encoding/xml never expands
+// them, but we reject them outright rather than fail later on an unknown entity
+// reference.
+func nextStartElementAfterHeader(xmlDecoder *xml.Decoder, violate violationFunc) (xml.StartElement, error) {
 	for {
 		token, err := nextInterestingToken(xmlDecoder)
 		if err != nil {
 			return xml.StartElement{}, err
 		}
 
-		if token, ok := token.(xml.StartElement); ok {
+		switch token := token.(type) {
+		case xml.Directive:
+			if bytes.Contains(token, []byte("<!ENTITY")) {
+				return xml.StartElement{}, errors.New("Entity declarations are not supported")
+			}
+		case xml.StartElement:
 			return token, nil
+		case xml.CharData, xml.EndElement:
+			if violate != nil {
+				violate("unexpected %s", describeToken(token))
+			}
 		}
 	}
 }
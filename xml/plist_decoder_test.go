@@ -54,6 +54,45 @@ func TestDecodeEmptyDictPlist(t *testing.T) {
 	assert.Nil(t, value)
 }
 
+func TestDecodeNestedDictEntries(t *testing.T) {
+	plist := `<plist version="1.0">
+	<dict>
+		<key>a</key>
+		<array>
+			<string>foo</string>
+		</array>
+		<key>b</key>
+		<dict>
+			<key>c</key>
+			<string>bar</string>
+		</dict>
+		<key>d</key>
+		<string>baz</string>
+	</dict>
+</plist>`
+	decoder := NewDecoder(bytes.NewReader([]byte(plist)))
+
+	for _, expected := range []interface{}{
+		StartDecodingDict{},
+		DictEntry{"a", StartDecodingArray{}},
+		"foo",
+		EndDecodingContainer{},
+		DictEntry{"b", StartDecodingDict{}},
+		DictEntry{"c", "bar"},
+		EndDecodingContainer{},
+		DictEntry{"d", "baz"},
+		EndDecodingContainer{},
+	} {
+		value, err := decoder.NextValue()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value)
+	}
+
+	value, err := decoder.NextValue()
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, value)
+}
+
 func ExamplePlistDecoder_array() {
 	plist := `<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
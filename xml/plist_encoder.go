@@ -7,7 +7,11 @@ import (
 )
 
 func EncodeArrayPlist(w io.Writer, encode ArrayEncodingFunc) error {
-	encoder, err := startPlist(w)
+	return EncodeArrayPlistWithOptions(w, EncoderOptions{}, encode)
+}
+
+func EncodeArrayPlistWithOptions(w io.Writer, options EncoderOptions, encode ArrayEncodingFunc) error {
+	encoder, err := startPlistWithOptions(w, options)
 	if err != nil {
 		return err
 	}
@@ -18,7 +22,11 @@ func EncodeArrayPlist(w io.Writer, encode ArrayEncodingFunc) error {
 }
 
 func EncodeDictPlist(w io.Writer, encode DictEncodingFunc) error {
-	encoder, err := startPlist(w)
+	return EncodeDictPlistWithOptions(w, EncoderOptions{}, encode)
+}
+
+func EncodeDictPlistWithOptions(w io.Writer, options EncoderOptions, encode DictEncodingFunc) error {
+	encoder, err := startPlistWithOptions(w, options)
 	if err != nil {
 		return err
 	}
@@ -29,8 +37,19 @@ func EncodeDictPlist(w io.Writer, encode DictEncodingFunc) error {
 }
 
 func startPlist(w io.Writer) (*baseEncoder, error) {
+	return startPlistWithOptions(w, EncoderOptions{})
+}
+
+func startPlistWithOptions(w io.Writer, options EncoderOptions) (*baseEncoder, error) {
+	w = options.Encoding.writer(w)
+	if w, ok := w.(*utf16Writer); ok {
+		if err := w.writeByteOrderMark(); err != nil {
+			return nil, err
+		}
+	}
+
 	base := newBaseEncoder(w)
-	if err := writePlistHeader(base); err != nil {
+	if err := writePlistHeader(base, options.Encoding.procInst()); err != nil {
 		return nil, fmt.Errorf("error writing plist header: %s", err)
 	}
 
@@ -40,7 +59,7 @@ func startPlist(w io.Writer) (*baseEncoder, error) {
 	return base, nil
 }
 
-func writePlistHeader(e *baseEncoder) error {
+func writePlistHeader(e *baseEncoder, procInst xml.ProcInst) error {
 	if err := e.xmlEncoder.EncodeToken(procInst); err != nil {
 		return err
 	}
@@ -0,0 +1,912 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// element identifies the elements plists are made of.
+type element int
+
+const (
+	unknownElement element = iota
+	plistElement
+	arrayElement
+	dictElement
+	keyElement
+	stringElement
+	integerElement
+	realElement
+	trueElement
+	falseElement
+	dateElement
+	dataElement
+)
+
+var elementNames = [...]string{"", "plist", "array", "dict", "key", "string", "integer", "real", "true", "false", "date", "data"}
+
+// endTags are the end tags of each element, for matching the usual case of an
+// end tag right after an element's text without parsing it.
+var endTags = func() [len(elementNames)][]byte {
+	var tags [len(elementNames)][]byte
+	for i, name := range elementNames {
+		tags[i] = []byte("</" + name + ">")
+	}
+	return tags
+}()
+
+func lookupElement(name []byte) element {
+	// The compiler doesn't allocate to switch on a converted []byte.
+	switch string(name) {
+	case "plist":
+		return plistElement
+	case "array":
+		return arrayElement
+	case "dict":
+		return dictElement
+	case "key":
+		return keyElement
+	case "string":
+		return stringElement
+	case "integer":
+		return integerElement
+	case "real":
+		return realElement
+	case "true":
+		return trueElement
+	case "false":
+		return falseElement
+	case "date":
+		return dateElement
+	case "data":
+		return dataElement
+	}
+	return unknownElement
+}
+
+// tag is a start or end tag read by a scanner.
+type tag struct {
+	elem        element
+	end         bool
+	selfClosing bool
+	// name is the name of an unknown element, for error messages.
+	name string
+	// text is set instead of the other fields for text that isn't whitespace,
+	// when text isn't allowed.
+	text bool
+}
+
+func (t tag) String() string {
+	if t.text {
+		return "text"
+	}
+	name := t.name
+	if t.elem != unknownElement {
+		name = elementNames[t.elem]
+	}
+	if t.end {
+		return "</" + name + ">"
+	}
+	return "<" + name + ">"
+}
+
+const scannerBufferSize = 16 << 10
+
+// maxEntityBytes bounds the length of an entity reference, which is only
+// longer than a few bytes if it's a character reference padded with zeros.
+const maxEntityBytes = 128
+
+// cdataEnd ends a CDATA section, and isn't allowed in other text.
+var cdataEnd = []byte("]]>")
+
+/*
+scanner reads plists with DecoderOptions.FastScanner set. It reads the subset of
+XML plists use straight out of its buffer: tags are matched to the elements
+plists have without allocating names or attributes, and text is only copied if
+it has entities, CDATA sections, comments or carriage returns in it, so the
+only allocations are for the values decoded.
+*/
+type scanner struct {
+	r   io.Reader
+	buf []byte
+	// pos is the offset of the next unread byte in buf.
+	pos int
+	// err is the error that ended reading from r.
+	err error
+
+	// offset and line are the input offset and line number of buf[0].
+	offset int64
+	line   int
+
+	// text holds text that had to be unescaped.
+	text []byte
+	// cr is set when the last byte appended to text was a carriage return
+	// converted to a newline, so a following newline is dropped.
+	cr bool
+
+	// selfClosed is set after a self-closing <array/> or <dict/>, whose end
+	// tag is returned next.
+	selfClosed element
+	// inPlist is set between <plist> and </plist>.
+	inPlist bool
+
+	// transcoded is set if the input was converted from UTF-16, in which case
+	// the encoding in the XML declaration is ignored.
+	transcoded    bool
+	charsetReader func(charset string, input io.Reader) (io.Reader, error)
+}
+
+func newScanner(r io.Reader, charsetReader func(charset string, input io.Reader) (io.Reader, error)) *scanner {
+	return &scanner{
+		r:             r,
+		buf:           make([]byte, 0, scannerBufferSize),
+		charsetReader: charsetReader,
+	}
+}
+
+// fill reads more input into buf, discarding the bytes before pos, and
+// returns false if there is no more.
+func (s *scanner) fill() bool {
+	if s.err != nil {
+		return false
+	}
+	if s.pos > 0 {
+		s.line += bytes.Count(s.buf[:s.pos], []byte{'\n'})
+		s.offset += int64(s.pos)
+		n := copy(s.buf, s.buf[s.pos:])
+		s.buf = s.buf[:n]
+		s.pos = 0
+	}
+	if len(s.buf) == cap(s.buf) {
+		buf := make([]byte, len(s.buf), 2*cap(s.buf))
+		copy(buf, s.buf)
+		s.buf = buf
+	}
+
+	for i := 0; i < 100; i++ {
+		n, err := s.r.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf = s.buf[:len(s.buf)+n]
+		if err != nil {
+			s.err = err
+		}
+		if n > 0 || err != nil {
+			return n > 0
+		}
+	}
+	s.err = io.ErrNoProgress
+	return false
+}
+
+// ensure fills buf until it has at least n unread bytes, and returns false
+// if the input ends first.
+func (s *scanner) ensure(n int) bool {
+	for len(s.buf)-s.pos < n {
+		if !s.fill() {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPrefix returns whether the unread input starts with prefix.
+func (s *scanner) hasPrefix(prefix string) bool {
+	return s.ensure(len(prefix)) && string(s.buf[s.pos:s.pos+len(prefix)]) == prefix
+}
+
+func (s *scanner) syntaxError(format string, args ...interface{}) error {
+	return &xml.SyntaxError{
+		Msg:  fmt.Sprintf(format, args...),
+		Line: s.line + bytes.Count(s.buf[:s.pos], []byte{'\n'}) + 1,
+	}
+}
+
+// readError returns the error that ended the input in the middle of something.
+func (s *scanner) readError() error {
+	if s.err == nil || s.err == io.EOF {
+		return s.syntaxError("unexpected EOF")
+	}
+	return s.err
+}
+
+// nextTag skips text, comments, processing instructions and directives, and
+// reads the next start or end tag. It returns io.EOF at the end of the input.
+// If textAllowed is false, text other than whitespace is returned as a tag
+// with text set.
+func (s *scanner) nextTag(textAllowed bool) (tag, error) {
+	if s.selfClosed != unknownElement {
+		elem := s.selfClosed
+		s.selfClosed = unknownElement
+		return tag{elem: elem, end: true}, nil
+	}
+
+	for {
+		i := bytes.IndexByte(s.buf[s.pos:], '<')
+		end := len(s.buf)
+		if i >= 0 {
+			end = s.pos + i
+		}
+		if !textAllowed && !isBlank(s.buf[s.pos:end]) {
+			return tag{text: true}, nil
+		}
+		if bytes.Contains(s.buf[s.pos:end], cdataEnd) {
+			return tag{}, s.syntaxError("unescaped ]]> not in CDATA section")
+		}
+		if i < 0 {
+			// Keep the last two bytes, in case they start a ]]>.
+			if end -= 2; end < s.pos {
+				end = s.pos
+			}
+		}
+		s.pos = end
+		if i < 0 {
+			if !s.fill() {
+				if s.err == io.EOF {
+					return tag{}, io.EOF
+				}
+				return tag{}, s.err
+			}
+			continue
+		}
+
+		if !s.ensure(2) {
+			return tag{}, s.readError()
+		}
+		switch s.buf[s.pos+1] {
+		case '/':
+			s.pos += 2
+			return s.readTag(true)
+		case '?':
+			if err := s.readProcInst(); err != nil {
+				return tag{}, err
+			}
+		case '!':
+			switch {
+			case s.hasPrefix("<!--"):
+				if err := s.skipPast("-->", 4); err != nil {
+					return tag{}, err
+				}
+			case s.hasPrefix("<![CDATA["):
+				if !textAllowed {
+					return tag{text: true}, nil
+				}
+				if err := s.skipPast("]]>", 9); err != nil {
+					return tag{}, err
+				}
+			default:
+				if err := s.skipDirective(); err != nil {
+					return tag{}, err
+				}
+			}
+		default:
+			s.pos++
+			return s.readTag(false)
+		}
+	}
+}
+
+// readTag reads the name and attributes of a tag, after its < or </.
+func (s *scanner) readTag(end bool) (tag, error) {
+	n := 0
+	for {
+		if !s.ensure(n + 1) {
+			return tag{}, s.readError()
+		}
+		if c := s.buf[s.pos+n]; c == '>' || c == '/' || isSpace(c) {
+			break
+		}
+		n++
+	}
+	if n == 0 {
+		return tag{}, s.syntaxError("expected element name after <")
+	}
+
+	t := tag{elem: lookupElement(s.buf[s.pos : s.pos+n]), end: end}
+	if t.elem == unknownElement {
+		t.name = string(s.buf[s.pos : s.pos+n])
+	}
+	s.pos += n
+
+	// Skip attributes, which plists only have on <plist>.
+	var quote byte
+	for {
+		if !s.ensure(1) {
+			return tag{}, s.readError()
+		}
+		c := s.buf[s.pos]
+		s.pos++
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/':
+			if !s.ensure(1) {
+				return tag{}, s.readError()
+			}
+			if s.buf[s.pos] == '>' && !end {
+				s.pos++
+				t.selfClosing = true
+				return t, nil
+			}
+		case c == '>':
+			return t, nil
+		}
+	}
+}
+
+// skipPast skips input up to and including delim, starting skip bytes in.
+func (s *scanner) skipPast(delim string, skip int) error {
+	s.pos += skip
+	for {
+		if i := bytes.Index(s.buf[s.pos:], []byte(delim)); i >= 0 {
+			s.pos += i + len(delim)
+			return nil
+		}
+		// Keep enough to find delim if it's split across reads.
+		if keep := len(s.buf) - len(delim) + 1; keep > s.pos {
+			s.pos = keep
+		}
+		if !s.fill() {
+			return s.readError()
+		}
+	}
+}
+
+// readProcInst skips a processing instruction. If it's the XML declaration,
+// the input is converted from the encoding it declares.
+func (s *scanner) readProcInst() error {
+	declaration := s.offset+int64(s.pos) == 0 && s.hasPrefix("<?xml") &&
+		s.ensure(6) && isSpace(s.buf[s.pos+5])
+	if !declaration {
+		return s.skipPast("?>", 2)
+	}
+
+	n := 0
+	for {
+		if i := bytes.Index(s.buf[s.pos+n:], []byte("?>")); i >= 0 {
+			n += i
+			break
+		}
+		// Keep the last byte, in case it's the ?.
+		if n = len(s.buf) - s.pos - 1; n < 0 {
+			n = 0
+		}
+		if !s.fill() {
+			return s.readError()
+		}
+	}
+	charset := procInstParam("encoding", string(s.buf[s.pos+5:s.pos+n]))
+	s.pos += n + 2
+
+	if charset == "" || strings.EqualFold(charset, "utf-8") {
+		return nil
+	}
+	if s.transcoded && strings.HasPrefix(strings.ToLower(charset), "utf-16") {
+		return nil
+	}
+	input := io.MultiReader(bytes.NewReader(append([]byte(nil), s.buf[s.pos:]...)), s.r)
+	r, err := s.charsetReader(charset, input)
+	if err != nil {
+		return fmt.Errorf("xml: opening charset %q: %v", charset, err)
+	}
+	s.line += bytes.Count(s.buf[:s.pos], []byte{'\n'})
+	s.offset += int64(s.pos)
+	s.r, s.buf, s.pos = r, s.buf[:0], 0
+	return nil
+}
+
+// procInstParam returns the value of param in the contents of a processing
+// instruction, or "" if it's missing.
+func procInstParam(param, inst string) string {
+	for _, field := range strings.Fields(inst) {
+		if !strings.HasPrefix(field, param+"=") {
+			continue
+		}
+		value := field[len(param)+1:]
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			return value[1 : len(value)-1]
+		}
+	}
+	return ""
+}
+
+// skipDirective skips a directive like <!DOCTYPE ...>, including any
+// internal subset, but fails if it declares entities, like encoding/xml's
+// decoder does.
+func (s *scanner) skipDirective() error {
+	s.pos += 2
+	depth := 1
+	var quote byte
+	for depth > 0 {
+		if !s.ensure(1) {
+			return s.readError()
+		}
+		c := s.buf[s.pos]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '<':
+			if s.hasPrefix("<!ENTITY") {
+				return errors.New("Entity declarations are not supported")
+			}
+			depth++
+		case c == '>':
+			depth--
+		}
+		s.pos++
+	}
+	return nil
+}
+
+/*
+readText reads the text of elem up to its end tag, and returns it unescaped.
+The text is only valid until the next read. If dropSpace is set, whitespace
+is dropped. If max is positive and the text is longer, a *LimitError for the
+limit named limit is returned.
+*/
+func (s *scanner) readText(elem element, dropSpace bool, max int, limit string) ([]byte, error) {
+	if !dropSpace {
+		// Return text without entities straight from the buffer.
+		if i := bytes.IndexByte(s.buf[s.pos:], '<'); i >= 0 {
+			text := s.buf[s.pos : s.pos+i]
+			end := endTags[elem]
+			if bytes.HasPrefix(s.buf[s.pos+i:], end) && bytes.IndexByte(text, '&') < 0 && bytes.IndexByte(text, '\r') < 0 &&
+				!bytes.Contains(text, cdataEnd) {
+				if max > 0 && len(text) > max {
+					return nil, &LimitError{limit, int64(max)}
+				}
+				s.pos += i + len(end)
+				return text, nil
+			}
+		}
+	}
+
+	s.text, s.cr = s.text[:0], false
+	for {
+		rest := s.buf[s.pos:]
+		i := bytes.IndexAny(rest, "<&")
+		text := rest
+		if i >= 0 {
+			text = rest[:i]
+		}
+		if bytes.Contains(text, cdataEnd) {
+			return nil, s.syntaxError("unescaped ]]> not in CDATA section")
+		}
+		if i < 0 {
+			// Keep the last two bytes, in case they start a ]]>.
+			if n := len(text) - 2; n > 0 {
+				text = text[:n]
+			} else {
+				text = nil
+			}
+		}
+		s.appendText(text, dropSpace)
+		s.pos += len(text)
+		if max > 0 && len(s.text) > max {
+			return nil, &LimitError{limit, int64(max)}
+		}
+		if i < 0 {
+			if !s.fill() {
+				return nil, s.readError()
+			}
+			continue
+		}
+
+		if s.buf[s.pos] == '&' {
+			if err := s.readEntity(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch {
+		case s.hasPrefix("</"):
+			s.pos += 2
+			t, err := s.readTag(true)
+			if err != nil {
+				return nil, err
+			}
+			if t.elem != elem {
+				return nil, s.syntaxError("element <%s> closed by %s", elementNames[elem], t)
+			}
+			return s.text, nil
+		case s.hasPrefix("<!--"):
+			if err := s.skipPast("-->", 4); err != nil {
+				return nil, err
+			}
+		case s.hasPrefix("<![CDATA["):
+			if err := s.readCData(dropSpace); err != nil {
+				return nil, err
+			}
+		case s.hasPrefix("<?"):
+			if err := s.skipPast("?>", 2); err != nil {
+				return nil, err
+			}
+		default:
+			if len(s.buf)-s.pos < 2 {
+				return nil, s.readError()
+			}
+			s.pos++
+			t, err := s.readTag(false)
+			if err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("Expected %s, found %s", endTags[elem], t)
+		}
+	}
+}
+
+// readCData appends the contents of a CDATA section to text.
+func (s *scanner) readCData(dropSpace bool) error {
+	s.pos += len("<![CDATA[")
+	for {
+		rest := s.buf[s.pos:]
+		if i := bytes.Index(rest, cdataEnd); i >= 0 {
+			s.appendText(rest[:i], dropSpace)
+			s.pos += i + 3
+			return nil
+		}
+		// Keep the last two bytes, in case they start the delimiter.
+		if n := len(rest) - 2; n > 0 {
+			s.appendText(rest[:n], dropSpace)
+			s.pos += n
+		}
+		if !s.fill() {
+			return s.readError()
+		}
+	}
+}
+
+// appendText appends b to text, converting carriage returns and CRLFs to
+// newlines as XML requires.
+func (s *scanner) appendText(b []byte, dropSpace bool) {
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\r')
+		if dropSpace {
+			// Whitespace, including carriage returns, is dropped anyway.
+			i = -1
+		}
+		chunk := b
+		if i >= 0 {
+			chunk = b[:i]
+		}
+		if s.cr && len(chunk) > 0 && chunk[0] == '\n' {
+			chunk = chunk[1:]
+		}
+		if len(chunk) > 0 {
+			s.cr = false
+		}
+
+		if dropSpace {
+			for _, c := range chunk {
+				if !isSpace(c) {
+					s.text = append(s.text, c)
+				}
+			}
+		} else {
+			s.text = append(s.text, chunk...)
+		}
+
+		if i < 0 {
+			return
+		}
+		s.text = append(s.text, '\n')
+		s.cr = true
+		b = b[i+1:]
+	}
+}
+
+// readEntity appends the character an entity reference stands for to text.
+func (s *scanner) readEntity() error {
+	s.cr = false
+	n := 1
+	for {
+		if !s.ensure(n + 1) {
+			return s.readError()
+		}
+		if s.buf[s.pos+n] == ';' {
+			break
+		}
+		if n++; n > maxEntityBytes {
+			return s.syntaxError("invalid character entity &%s", s.buf[s.pos+1:s.pos+n])
+		}
+	}
+
+	name := s.buf[s.pos+1 : s.pos+n]
+	var r rune = -1
+	switch string(name) {
+	case "lt":
+		r = '<'
+	case "gt":
+		r = '>'
+	case "amp":
+		r = '&'
+	case "apos":
+		r = '\''
+	case "quot":
+		r = '"'
+	default:
+		if len(name) > 1 && name[0] == '#' {
+			r = parseCharRef(name[1:])
+		}
+	}
+	if r < 0 || !utf8.ValidRune(r) {
+		return s.syntaxError("invalid character entity &%s;", name)
+	}
+	s.text = appendRune(s.text, r)
+	s.pos += n + 1
+	return nil
+}
+
+// parseCharRef parses the number in a character reference like &#65; or
+// &#x41;, and returns -1 if it's invalid.
+func parseCharRef(ref []byte) rune {
+	base := rune(10)
+	if ref[0] == 'x' {
+		base, ref = 16, ref[1:]
+	}
+	if len(ref) == 0 {
+		return -1
+	}
+	var r rune
+	for _, c := range ref {
+		var digit rune
+		switch {
+		case '0' <= c && c <= '9':
+			digit = rune(c - '0')
+		case base == 16 && 'a' <= c && c <= 'f':
+			digit = rune(c-'a') + 10
+		case base == 16 && 'A' <= c && c <= 'F':
+			digit = rune(c-'A') + 10
+		default:
+			return -1
+		}
+		if r = r*base + digit; r > utf8.MaxRune {
+			return -1
+		}
+	}
+	return r
+}
+
+// readHeader reads up to the root container's start tag, and returns its element.
+func (s *scanner) readHeader() (element, error) {
+	t, err := s.nextStartTag()
+	if err != nil {
+		return unknownElement, err
+	}
+	if t.elem != plistElement {
+		return unknownElement, fmt.Errorf("Expected <plist>, found %s", t)
+	}
+	s.inPlist = !t.selfClosing
+
+	if t, err = s.nextStartTag(); err != nil {
+		return unknownElement, err
+	}
+	if t.elem != arrayElement && t.elem != dictElement {
+		return unknownElement, fmt.Errorf("Expected container start element, found %s", t)
+	}
+	if t.selfClosing {
+		s.selfClosed = t.elem
+	}
+	return t.elem, nil
+}
+
+// nextStartTag skips end tags until the next start tag.
+func (s *scanner) nextStartTag() (tag, error) {
+	for {
+		t, err := s.nextTag(true)
+		if err == io.EOF && s.inPlist {
+			return tag{}, s.syntaxError("unexpected EOF")
+		} else if err != nil {
+			return tag{}, err
+		}
+		if !t.end {
+			return t, nil
+		}
+		if t.elem == plistElement {
+			s.inPlist = false
+		}
+	}
+}
+
+// scanValue reads the next value with the decoder's scanner. It's nextValue
+// for DecoderOptions.FastScanner.
+func (d *baseDecoder) scanValue(container containerDecoder) (interface{}, error) {
+	s := d.scanner
+	_, inArray := container.(*arrayDecoder)
+	t, err := s.nextTag(true)
+	if err == io.EOF {
+		return nil, s.syntaxError("unexpected EOF")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if t.end {
+		if inArray && t.elem == arrayElement {
+			return EndDecodingContainer{}, nil
+		}
+		if inArray {
+			return nil, s.syntaxError("element <array> closed by %s", t)
+		}
+		return nil, fmt.Errorf("Expected value, found %s", t)
+	}
+	if t.selfClosing {
+		return d.scanEmptyValue(container, t)
+	}
+
+	switch t.elem {
+	case stringElement:
+		text, err := s.readText(t.elem, false, d.opts().MaxStringBytes, "MaxStringBytes")
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	case trueElement, falseElement:
+		if _, err := s.readText(t.elem, false, 0, ""); err != nil {
+			return nil, err
+		}
+		return t.elem == trueElement, nil
+	case integerElement:
+		text, err := s.readText(t.elem, false, d.opts().MaxStringBytes, "MaxStringBytes")
+		if err != nil {
+			return nil, err
+		}
+		return parseIntegerBytes(text)
+	case realElement:
+		text, err := s.readText(t.elem, false, d.opts().MaxStringBytes, "MaxStringBytes")
+		if err != nil {
+			return nil, err
+		}
+		return parseReal(string(text))
+	case dateElement:
+		text, err := s.readText(t.elem, false, d.opts().MaxStringBytes, "MaxStringBytes")
+		if err != nil {
+			return nil, err
+		}
+		return parseDate(string(text))
+	case dataElement:
+		maxBytes := d.opts().MaxDataBytes
+		maxEncodedBytes := 0
+		if maxBytes > 0 {
+			maxEncodedBytes = base64.StdEncoding.EncodedLen(maxBytes)
+		}
+		text, err := s.readText(t.elem, true, maxEncodedBytes, "MaxDataBytes")
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+		n, err := base64.StdEncoding.Decode(data, text)
+		if err != nil {
+			return nil, err
+		}
+		if maxBytes > 0 && n > maxBytes {
+			return nil, &LimitError{"MaxDataBytes", int64(maxBytes)}
+		}
+		return data[:n], nil
+	case arrayElement, dictElement:
+		return d.scanContainer(container, t.elem)
+	}
+	return nil, fmt.Errorf("Invalid element: %s", t)
+}
+
+// scanEmptyValue returns the value of a self-closing element.
+func (d *baseDecoder) scanEmptyValue(container containerDecoder, t tag) (interface{}, error) {
+	switch t.elem {
+	case stringElement:
+		return "", nil
+	case trueElement, falseElement:
+		return t.elem == trueElement, nil
+	case dataElement:
+		return []byte{}, nil
+	case integerElement:
+		return parseInteger("")
+	case realElement:
+		return parseReal("")
+	case dateElement:
+		return parseDate("")
+	case arrayElement, dictElement:
+		d.scanner.selfClosed = t.elem
+		return d.scanContainer(container, t.elem)
+	}
+	return nil, fmt.Errorf("Invalid element: %s", t)
+}
+
+func (d *baseDecoder) scanContainer(container containerDecoder, elem element) (interface{}, error) {
+	child, err := d.child(container)
+	if err != nil {
+		return nil, err
+	}
+	if elem == arrayElement {
+		return newArrayDecoder(child), nil
+	}
+	return newDictDecoder(child), nil
+}
+
+// scanEntry reads the next entry with the decoder's scanner. It's NextValue
+// for DecoderOptions.FastScanner.
+func (d *dictDecoder) scanEntry() (interface{}, error) {
+	s := d.scanner
+	t, err := s.nextTag(false)
+	if err == io.EOF {
+		return nil, s.syntaxError("unexpected EOF")
+	} else if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case t.end && t.elem == dictElement:
+		return EndDecodingContainer{}, nil
+	case t.end:
+		return nil, s.syntaxError("element <dict> closed by %s", t)
+	case t.elem != keyElement:
+		return nil, fmt.Errorf("Expected <key> or </dict>, found %s", t)
+	}
+
+	if err := d.countEntry(); err != nil {
+		return nil, err
+	}
+	var key string
+	if !t.selfClosing {
+		text, err := s.readText(keyElement, false, d.opts().MaxStringBytes, "MaxStringBytes")
+		if err != nil {
+			return nil, err
+		}
+		key = string(text)
+	}
+
+	value, err := d.nextValue(d)
+	if err != nil {
+		return nil, err
+	}
+	return DictEntry{key, value}, nil
+}
+
+// parseIntegerBytes parses short decimal integers without allocating, and
+// anything else with parseInteger.
+func parseIntegerBytes(b []byte) (interface{}, error) {
+	digits := b
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	// 18 digits always fit in an int64.
+	if len(digits) == 0 || len(digits) > 18 {
+		return parseInteger(string(b))
+	}
+
+	var n int64
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return parseInteger(string(b))
+		}
+		n = n*10 + int64(c-'0')
+	}
+	if len(digits) < len(b) {
+		n = -n
+	}
+	return n, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isBlank(b []byte) bool {
+	for _, c := range b {
+		if !isSpace(c) {
+			return false
+		}
+	}
+	return true
+}
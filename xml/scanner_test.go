@@ -0,0 +1,339 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var scannerPlists = []string{
+	`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>String</key>
+	<string>a &amp; b &lt;c&gt; &#65;&#x42; &quot;&apos; &#x0001F600; &#0000000000065;</string>
+	<key>Brackets</key>
+	<string>]] ] ]&gt; ]]&gt;</string>
+	<key>CDATA</key>
+	<string>x<![CDATA[<not a tag> & ]]>y</string>
+	<key>Comment</key>
+	<string>a<!-- <string> -->b</string>
+	<key>CRLF</key>
+	<string>a` + "\r\n" + `b` + "\r" + `c</string>
+	<key>Empty</key>
+	<string/>
+	<key>Spaces</key>
+	<string>  </string>
+	<key>a &amp; b</key>
+	<true/>
+	<key>False</key>
+	<false></false>
+	<key>Integers</key>
+	<array>
+		<integer>0</integer>
+		<integer>-42</integer>
+		<integer>0x1F</integer>
+		<integer>18446744073709551615</integer>
+		<integer>-170141183460469231731687303715884105728</integer>
+		<integer>1000000000000000000000000000000000000000</integer>
+	</array>
+	<key>Reals</key>
+	<array>
+		<real>1.5</real>
+		<real>-2e10</real>
+		<real>nan</real>
+		<real>1e400</real>
+	</array>
+	<key>Date</key>
+	<date>2016-01-02T03:04:05Z</date>
+	<key>Data</key>
+	<data>
+	aGVsbG8g
+	d29ybGQ=
+	</data>
+	<key>EmptyData</key>
+	<data></data>
+	<key>Nested</key>
+	<array>
+		<dict/>
+		<array/>
+		<dict>
+			<key>a</key>
+			<array><array><string>deep</string></array></array>
+		</dict>
+	</array>
+</dict>
+</plist>`,
+	`<plist version="1.0"><array><string>a</string><dict><key>b</key><integer>1</integer></dict></array></plist>`,
+	`<?xml version="1.0"?><plist><array/></plist>`,
+	`<?xml version="1.0" encoding="ISO-8859-1"?>
+<plist version="1.0"><array><string>h` + "\xe9" + `llo</string></array></plist>`,
+}
+
+func decodeWithScanner(r io.Reader, fast bool) (interface{}, error) {
+	decoder := NewDecoderWithOptions(r, DecoderOptions{FastScanner: fast})
+	return decoder.DecodeValue()
+}
+
+func TestScannerMatchesEncodingXML(t *testing.T) {
+	for _, plist := range scannerPlists {
+		expected, err := decodeWithScanner(strings.NewReader(plist), false)
+		assert.NoError(t, err)
+
+		actual, err := decodeWithScanner(strings.NewReader(plist), true)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%#v", expected), fmt.Sprintf("%#v", actual))
+
+		actual, err = decodeWithScanner(iotest.OneByteReader(strings.NewReader(plist)), true)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%#v", expected), fmt.Sprintf("%#v", actual))
+	}
+}
+
+func TestScannerLargeInput(t *testing.T) {
+	plist := libraryPlist(500)
+	expected, err := decodeWithScanner(bytes.NewReader(plist), false)
+	assert.NoError(t, err)
+	actual, err := decodeWithScanner(iotest.HalfReader(bytes.NewReader(plist)), true)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestScannerUTF16(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="UTF-16"?>
+<plist version="1.0"><array><string>héllo 𝄞</string></array></plist>`
+	decoder := NewDecoderWithOptions(bytes.NewReader(encodeUTF16("\uFEFF"+plist, binary.LittleEndian)), DecoderOptions{FastScanner: true})
+	assert.Equal(t, "héllo 𝄞", decodeFirstString(t, decoder))
+}
+
+func TestScannerEOFAfterRoot(t *testing.T) {
+	decoder := NewDecoderWithOptions(strings.NewReader(`<plist><array></array></plist>`), DecoderOptions{FastScanner: true})
+
+	value, err := decoder.NextValue()
+	assert.NoError(t, err)
+	assert.IsType(t, StartDecodingArray{}, value)
+	value, err = decoder.NextValue()
+	assert.NoError(t, err)
+	assert.IsType(t, EndDecodingContainer{}, value)
+
+	value, err = decoder.NextValue()
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, value)
+}
+
+func TestScannerErrors(t *testing.T) {
+	for _, plist := range []string{
+		`<plist><array><string>a</integer></array></plist>`,
+		`<plist><array><string>a</string>`,
+		`<plist><array><string>a`,
+		`<plist><array><foo/></array></plist>`,
+		`<plist><dict><string>a</string></dict></plist>`,
+		`<plist><dict><key>a</key></dict></plist>`,
+		`<plist><array><integer>x</integer></array></plist>`,
+		`<plist><array><string>&bogus;</string></array></plist>`,
+		`<!DOCTYPE plist [<!ENTITY a "b">]><plist><array/></plist>`,
+		`<plist><array><string>a<b>c</b></string></array></plist>`,
+		`<plist><array><string>a]]>b</string></array></plist>`,
+		`<plist><array><string>a&amp;]]>b</string></array></plist>`,
+		`<plist><array><data>]]></data></array></plist>`,
+		`<plist>]]><array/></plist>`,
+	} {
+		_, err := decodeWithScanner(strings.NewReader(plist), true)
+		assert.Error(t, err, plist)
+		_, err = decodeWithScanner(iotest.OneByteReader(strings.NewReader(plist)), true)
+		assert.Error(t, err, plist)
+	}
+}
+
+func TestScannerLimits(t *testing.T) {
+	for _, test := range []struct {
+		plist   string
+		options DecoderOptions
+		limit   string
+	}{
+		{`<plist><array><array/></array></plist>`, DecoderOptions{MaxDepth: 1}, "MaxDepth"},
+		{`<plist><array><true/><true/></array></plist>`, DecoderOptions{MaxContainerEntries: 1}, "MaxContainerEntries"},
+		{`<plist><dict><key>a</key><true/><key>b</key><true/></dict></plist>`, DecoderOptions{MaxContainerEntries: 1}, "MaxContainerEntries"},
+		{`<plist><array><string>abc</string></array></plist>`, DecoderOptions{MaxStringBytes: 2}, "MaxStringBytes"},
+		{`<plist><dict><key>abc</key><true/></dict></plist>`, DecoderOptions{MaxStringBytes: 2}, "MaxStringBytes"},
+		{`<plist><array><data>aGVsbG8=</data></array></plist>`, DecoderOptions{MaxDataBytes: 4}, "MaxDataBytes"},
+		{`<plist><array><true/><true/><true/></array></plist>`, DecoderOptions{MaxTotalBytes: 20}, "MaxTotalBytes"},
+	} {
+		test.options.FastScanner = true
+		decoder := NewDecoderWithOptions(strings.NewReader(test.plist), test.options)
+		_, err := decoder.DecodeValue()
+		if assert.IsType(t, &LimitError{}, err, test.plist) {
+			assert.Equal(t, test.limit, err.(*LimitError).Limit)
+		}
+	}
+}
+
+func TestScannerAllocations(t *testing.T) {
+	for _, value := range []string{"<true/>", "<integer>12345</integer>"} {
+		plist := "<plist><array>" + strings.Repeat(value, 1000) + "</array></plist>"
+		decoder := NewDecoderWithOptions(strings.NewReader(plist), DecoderOptions{FastScanner: true})
+		_, err := decoder.NextValue()
+		assert.NoError(t, err)
+
+		allocs := testing.AllocsPerRun(500, func() {
+			if _, err := decoder.NextValue(); err != nil {
+				t.Fatal(err)
+			}
+		})
+		// Returning the value in an interface{} may allocate, but nothing else should.
+		assert.True(t, allocs <= 1, "%s: %v allocations per value", value, allocs)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, input := range []struct {
+		name  string
+		plist []byte
+	}{
+		{"Library", libraryPlist(2000)},
+		{"InfoPlist", infoPlist()},
+	} {
+		for _, scanner := range []struct {
+			name string
+			fast bool
+		}{
+			{"EncodingXML", false},
+			{"FastScanner", true},
+		} {
+			b.Run(input.name+"/"+scanner.name, func(b *testing.B) {
+				b.SetBytes(int64(len(input.plist)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := decodeWithScanner(bytes.NewReader(input.plist), scanner.fast); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// libraryPlist returns a plist shaped like an iTunes library with n tracks.
+func libraryPlist(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Major Version</key><integer>1</integer>
+	<key>Minor Version</key><integer>1</integer>
+	<key>Date</key><date>2016-03-01T10:00:00Z</date>
+	<key>Application Version</key><string>12.3.2.35</string>
+	<key>Show Content Ratings</key><true/>
+	<key>Music Folder</key><string>file:///Users/someone/Music/iTunes/iTunes%20Media/</string>
+	<key>Library Persistent ID</key><string>0123456789ABCDEF</string>
+	<key>Tracks</key>
+	<dict>
+`)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, `		<key>%d</key>
+		<dict>
+			<key>Track ID</key><integer>%d</integer>
+			<key>Size</key><integer>%d</integer>
+			<key>Total Time</key><integer>%d</integer>
+			<key>Track Number</key><integer>%d</integer>
+			<key>Year</key><integer>%d</integer>
+			<key>Date Modified</key><date>2015-11-%02dT08:30:00Z</date>
+			<key>Date Added</key><date>2015-12-%02dT19:45:12Z</date>
+			<key>Bit Rate</key><integer>256</integer>
+			<key>Sample Rate</key><integer>44100</integer>
+			<key>Play Count</key><integer>%d</integer>
+			<key>Persistent ID</key><string>%016X</string>
+			<key>Track Type</key><string>File</string>
+			<key>Name</key><string>Song Number %d</string>
+			<key>Artist</key><string>Artist &amp; The Band %d</string>
+			<key>Album</key><string>Album %d</string>
+			<key>Genre</key><string>Alternative</string>
+			<key>Kind</key><string>AAC audio file</string>
+			<key>Location</key><string>file:///Users/someone/Music/iTunes/iTunes%%20Media/Music/Artist%%20%d/Album%%20%d/%02d%%20Song.m4a</string>
+			<key>File Folder Count</key><integer>5</integer>
+			<key>Library Folder Count</key><integer>1</integer>
+		</dict>
+`, i, i, 4000000+i*1000, 180000+i, i%12+1, 1990+i%30, i%28+1, i%28+1, i%50, i, i, i/10, i/10, i/10, i/10, i%12+1)
+	}
+	buf.WriteString(`	</dict>
+	<key>Playlists</key>
+	<array>
+		<dict>
+			<key>Name</key><string>Library</string>
+			<key>Master</key><true/>
+			<key>Playlist Items</key>
+			<array>
+`)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "\t\t\t\t<dict><key>Track ID</key><integer>%d</integer></dict>\n", i)
+	}
+	buf.WriteString(`			</array>
+		</dict>
+	</array>
+</dict>
+</plist>
+`)
+	return buf.Bytes()
+}
+
+// infoPlist returns a plist shaped like a large application Info.plist.
+func infoPlist() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleDevelopmentRegion</key><string>en</string>
+	<key>CFBundleExecutable</key><string>Example</string>
+	<key>CFBundleIdentifier</key><string>com.example.app</string>
+	<key>CFBundleInfoDictionaryVersion</key><string>6.0</string>
+	<key>CFBundlePackageType</key><string>APPL</string>
+	<key>CFBundleShortVersionString</key><string>4.2.1</string>
+	<key>CFBundleVersion</key><string>4210</string>
+	<key>LSMinimumSystemVersion</key><string>10.13</string>
+	<key>NSHighResolutionCapable</key><true/>
+	<key>NSPrincipalClass</key><string>NSApplication</string>
+	<key>CFBundleDocumentTypes</key>
+	<array>
+`)
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&buf, `		<dict>
+			<key>CFBundleTypeExtensions</key>
+			<array>
+				<string>ext%d</string>
+				<string>alt%d</string>
+			</array>
+			<key>CFBundleTypeIconFile</key><string>Document%d.icns</string>
+			<key>CFBundleTypeName</key><string>Example Document Type %d</string>
+			<key>CFBundleTypeRole</key><string>Editor</string>
+			<key>LSHandlerRank</key><string>Owner</string>
+			<key>LSItemContentTypes</key>
+			<array>
+				<string>com.example.document.type%d</string>
+			</array>
+			<key>NSDocumentClass</key><string>Document</string>
+		</dict>
+`, i, i, i, i, i)
+	}
+	buf.WriteString(`	</array>
+	<key>NSAppTransportSecurity</key>
+	<dict>
+		<key>NSAllowsArbitraryLoads</key><false/>
+	</dict>
+	<key>SUPublicEDKey</key>
+	<data>
+	bW9yZSBvciBsZXNzIHJhbmRvbSBrZXkgbWF0ZXJpYWwgZm9yIHRoZSBiZW5jaG1hcms=
+	</data>
+</dict>
+</plist>
+`)
+	return buf.Bytes()
+}
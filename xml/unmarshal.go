@@ -0,0 +1,295 @@
+package xml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Unmarshaler is implemented by types that convert decoded plist values to
+// themselves, e.g. for keys whose value can be one of several types.
+type Unmarshaler interface {
+	// UnmarshalPlist is passed a value as returned by DecodeValue.
+	UnmarshalPlist(value interface{}) error
+}
+
+// UnmarshalTypeError is returned by UnmarshalValue when a plist value can't be
+// stored in a Go value of a particular type.
+type UnmarshalTypeError struct {
+	// Value describes the plist value, e.g. "string" or "dict".
+	Value string
+	Type  reflect.Type
+	// Path is the location of the value in the plist, e.g. "a.b[2]".
+	Path string
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("cannot unmarshal %s into Go value of type %s", e.Value, e.Type)
+	}
+	return fmt.Sprintf("cannot unmarshal %s into Go value of type %s at %s", e.Value, e.Type, e.Path)
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	emptyInterface  = reflect.TypeOf((*interface{})(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+/*
+UnmarshalValue stores a value returned by DecodeValue in the value pointed to by v.
+
+Dicts can be stored in maps with string keys, or structs. Struct fields are
+matched to dict keys by the key in the field's "plist" tag, or the field's name
+if it has no tag. Fields tagged "-" are ignored. A field of type
+map[string]interface{} tagged ",unknown" receives the entries that don't match
+any other field; tagging a field of any other type is an error.
+
+Arrays are stored in slices, integers in any integer type they fit in or in
+floats, reals in floats, dates in time.Time, and data in []byte. Any value can
+be stored in an empty interface, and pointers are allocated as needed. A nil
+value, as in a dict built by hand, leaves the Go value unchanged. Types
+that implement Unmarshaler convert values themselves.
+*/
+func UnmarshalValue(value interface{}, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cannot unmarshal into non-pointer %T", v)
+	}
+	return unmarshal(value, rv.Elem(), "")
+}
+
+func unmarshal(value interface{}, v reflect.Value, path string) error {
+	if value == nil {
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		return v.Addr().Interface().(Unmarshaler).UnmarshalPlist(value)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return unmarshal(value, v.Elem(), path)
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(value))
+			return nil
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		if v.Kind() == reflect.String {
+			v.SetString(value)
+			return nil
+		}
+	case bool:
+		if v.Kind() == reflect.Bool {
+			v.SetBool(value)
+			return nil
+		}
+	case int64:
+		if setInt(v, value) {
+			return nil
+		}
+	case uint64:
+		if setUint(v, value) {
+			return nil
+		}
+	case float64:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(value)
+			return nil
+		}
+	case []byte:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte(nil), value...))
+			return nil
+		}
+	case []interface{}:
+		if v.Kind() == reflect.Slice {
+			return unmarshalArray(value, v, path)
+		}
+	case map[string]interface{}:
+		switch {
+		case v.Kind() == reflect.Struct:
+			return unmarshalStruct(value, v, path)
+		case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			return unmarshalMap(value, v, path)
+		}
+	}
+
+	// Covers time.Time, Int128, and the big types.
+	if value != nil && reflect.TypeOf(value).AssignableTo(v.Type()) {
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	return &UnmarshalTypeError{Value: describeValue(value), Type: v.Type(), Path: path}
+}
+
+func setInt(v reflect.Value, value int64) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.OverflowInt(value) {
+			return false
+		}
+		v.SetInt(value)
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if value < 0 {
+			return false
+		}
+		return setUint(v, uint64(value))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(value))
+		return true
+	}
+	return false
+}
+
+func setUint(v reflect.Value, value uint64) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.OverflowUint(value) {
+			return false
+		}
+		v.SetUint(value)
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if int64(value) < 0 || v.OverflowInt(int64(value)) {
+			return false
+		}
+		v.SetInt(int64(value))
+		return true
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(value))
+		return true
+	}
+	return false
+}
+
+func unmarshalArray(array []interface{}, v reflect.Value, path string) error {
+	slice := reflect.MakeSlice(v.Type(), len(array), len(array))
+	for i, item := range array {
+		if err := unmarshal(item, slice.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+func unmarshalMap(dict map[string]interface{}, v reflect.Value, path string) error {
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	for key, entryValue := range dict {
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := unmarshal(entryValue, elem, joinPath(path, key)); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+	}
+	return nil
+}
+
+func unmarshalStruct(dict map[string]interface{}, v reflect.Value, path string) error {
+	var unknown reflect.Value
+	matched := make(map[string]bool)
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			// Unexported.
+			continue
+		}
+
+		key, options := parseTag(field)
+		if key == "-" {
+			continue
+		}
+		if options == "unknown" {
+			t := field.Type
+			if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String || t.Elem() != emptyInterface {
+				return fmt.Errorf("cannot unmarshal unknown keys into field %s of type %s, must be map[string]interface{}", field.Name, t)
+			}
+			unknown = v.Field(i)
+			continue
+		}
+
+		entryValue, ok := dict[key]
+		if !ok {
+			continue
+		}
+		matched[key] = true
+		if err := unmarshal(entryValue, v.Field(i), joinPath(path, key)); err != nil {
+			return err
+		}
+	}
+
+	if unknown.IsValid() {
+		for key, entryValue := range dict {
+			if matched[key] {
+				continue
+			}
+			if unknown.IsNil() {
+				unknown.Set(reflect.MakeMap(unknown.Type()))
+			}
+			elem := reflect.New(emptyInterface).Elem()
+			if entryValue != nil {
+				elem.Set(reflect.ValueOf(entryValue))
+			}
+			unknown.SetMapIndex(reflect.ValueOf(key).Convert(unknown.Type().Key()), elem)
+		}
+	}
+	return nil
+}
+
+// parseTag returns the dict key for field, and the options after the comma
+// in its tag.
+func parseTag(field reflect.StructField) (key string, options string) {
+	tag := field.Tag.Get("plist")
+	if i := strings.Index(tag, ","); i >= 0 {
+		tag, options = tag[:i], tag[i+1:]
+	}
+	if tag == "" {
+		tag = field.Name
+	}
+	return tag, options
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// describeValue returns the plist type name of a decoded value.
+func describeValue(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int64, uint64, Int128:
+		return "integer"
+	case float64:
+		return "real"
+	case time.Time:
+		return "date"
+	case []byte:
+		return "data"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "dict"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,158 @@
+package xml
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type unmarshalTestStruct struct {
+	Name     string `plist:"name"`
+	Age      uint8  `plist:"age"`
+	Height   float64
+	Birthday *time.Time `plist:"birthday"`
+	Friends  []string   `plist:"friends"`
+	Ignored  string     `plist:"-"`
+	Nested   struct {
+		Value bool `plist:"value"`
+	} `plist:"nested"`
+	Flexible flexibleValue          `plist:"flexible"`
+	Unknown  map[string]interface{} `plist:",unknown"`
+	private  string
+}
+
+type flexibleValue struct {
+	Strings []string
+}
+
+func (f *flexibleValue) UnmarshalPlist(value interface{}) error {
+	switch value := value.(type) {
+	case string:
+		f.Strings = []string{value}
+		return nil
+	case []interface{}:
+		return UnmarshalValue(value, &f.Strings)
+	}
+	return errors.New("bad flexible value")
+}
+
+func TestUnmarshalStruct(t *testing.T) {
+	plist := `<plist version="1.0">
+	<dict>
+		<key>name</key>
+		<string>Bilbo Baggins</string>
+		<key>age</key>
+		<integer>111</integer>
+		<key>Height</key>
+		<integer>1</integer>
+		<key>birthday</key>
+		<date>2890-09-22T00:00:00Z</date>
+		<key>friends</key>
+		<array>
+			<string>Gandalf</string>
+		</array>
+		<key>-</key>
+		<string>not ignored</string>
+		<key>nested</key>
+		<dict>
+			<key>value</key>
+			<true/>
+		</dict>
+		<key>flexible</key>
+		<string>one</string>
+		<key>ring</key>
+		<true/>
+	</dict>
+</plist>`
+	var value unmarshalTestStruct
+	assert.NoError(t, NewDecoder(bytes.NewReader([]byte(plist))).Decode(&value))
+
+	birthday := time.Date(2890, time.September, 22, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "Bilbo Baggins", value.Name)
+	assert.Equal(t, uint8(111), value.Age)
+	assert.Equal(t, float64(1), value.Height)
+	assert.Equal(t, &birthday, value.Birthday)
+	assert.Equal(t, []string{"Gandalf"}, value.Friends)
+	assert.Equal(t, "", value.Ignored)
+	assert.True(t, value.Nested.Value)
+	assert.Equal(t, []string{"one"}, value.Flexible.Strings)
+	assert.Equal(t, map[string]interface{}{
+		"-":    "not ignored",
+		"ring": true,
+	}, value.Unknown)
+}
+
+func TestUnmarshalMap(t *testing.T) {
+	var value map[string]int
+	assert.NoError(t, UnmarshalValue(map[string]interface{}{"a": int64(1)}, &value))
+	assert.Equal(t, map[string]int{"a": 1}, value)
+}
+
+func TestUnmarshalInterface(t *testing.T) {
+	var value interface{}
+	assert.NoError(t, UnmarshalValue([]interface{}{"a"}, &value))
+	assert.Equal(t, []interface{}{"a"}, value)
+}
+
+func TestUnmarshalInt128(t *testing.T) {
+	var value Int128
+	assert.NoError(t, UnmarshalValue(Int128{Hi: 1}, &value))
+	assert.Equal(t, Int128{Hi: 1}, value)
+}
+
+func TestUnmarshalTypeErrors(t *testing.T) {
+	var s struct {
+		A []int `plist:"a"`
+	}
+	err := UnmarshalValue(map[string]interface{}{
+		"a": []interface{}{int64(1), "two"},
+	}, &s)
+	assert.Equal(t, &UnmarshalTypeError{"string", reflect.TypeOf(0), "a[1]"}, err)
+	assert.EqualError(t, err, "cannot unmarshal string into Go value of type int at a[1]")
+
+	var small int8
+	err = UnmarshalValue(int64(math.MaxInt16), &small)
+	assert.Equal(t, &UnmarshalTypeError{"integer", reflect.TypeOf(small), ""}, err)
+
+	var unsigned uint
+	err = UnmarshalValue(int64(-1), &unsigned)
+	assert.Equal(t, &UnmarshalTypeError{"integer", reflect.TypeOf(unsigned), ""}, err)
+
+	var signed int64
+	err = UnmarshalValue(uint64(math.MaxUint64), &signed)
+	assert.Equal(t, &UnmarshalTypeError{"integer", reflect.TypeOf(signed), ""}, err)
+}
+
+func TestUnmarshalNonPointer(t *testing.T) {
+	var value string
+	assert.Error(t, UnmarshalValue("a", value))
+}
+
+func TestUnmarshalNil(t *testing.T) {
+	var value struct {
+		A interface{}
+		B *string
+		C int
+		D map[string]interface{} `plist:",unknown"`
+	}
+	value.C = 1
+	err := UnmarshalValue(map[string]interface{}{"A": nil, "B": nil, "C": nil, "E": nil}, &value)
+	assert.NoError(t, err)
+	assert.Nil(t, value.A)
+	assert.Nil(t, value.B)
+	assert.Equal(t, 1, value.C)
+	assert.Equal(t, map[string]interface{}{"E": nil}, value.D)
+}
+
+func TestUnmarshalUnknownFieldType(t *testing.T) {
+	var value struct {
+		Unknown map[string]string `plist:",unknown"`
+	}
+	err := UnmarshalValue(map[string]interface{}{"a": "b"}, &value)
+	assert.EqualError(t, err, "cannot unmarshal unknown keys into field Unknown of type map[string]string, must be map[string]interface{}")
+}
@@ -0,0 +1,95 @@
+package xml
+
+import "fmt"
+
+/*
+DecodeValue decodes the next value out of the plist, like NextValue, but reads
+containers completely. Arrays are returned as []interface{}, and dicts as
+map[string]interface{}. If the next value is a DictEntry whose value is a
+container, the entry's Value is the decoded container.
+
+DecodeValue can be mixed with calls to NextValue, so a large plist can be
+streamed with NextValue while its smaller parts are decoded in one go.
+When the current container ends, EndDecodingContainer is returned.
+*/
+func (d *PlistDecoder) DecodeValue() (interface{}, error) {
+	value, err := d.NextValue()
+	if err != nil {
+		return nil, err
+	}
+	return d.finishDecodingValue(value)
+}
+
+// Decode decodes the next value out of the plist and stores it in the value
+// pointed to by v. See UnmarshalValue for how values are converted.
+func (d *PlistDecoder) Decode(v interface{}) error {
+	value, err := d.DecodeValue()
+	if err != nil {
+		return err
+	}
+	if _, ok := value.(EndDecodingContainer); ok {
+		return fmt.Errorf("Expected value, found end of container")
+	}
+	return UnmarshalValue(value, v)
+}
+
+/*
+FinishDecoding reads the rest of value, a value just returned by NextValue. If
+value is StartDecodingArray or StartDecodingDict, or a DictEntry whose Value is,
+the container is read to its end and returned as DecodeValue would return it.
+Other values are returned as they are.
+*/
+func (d *PlistDecoder) FinishDecoding(value interface{}) (interface{}, error) {
+	return d.finishDecodingValue(value)
+}
+
+// finishDecodingValue reads the rest of value, if it is the start of a container.
+func (d *PlistDecoder) finishDecodingValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case StartDecodingArray:
+		return d.finishDecodingArray()
+	case StartDecodingDict:
+		return d.finishDecodingDict()
+	case DictEntry:
+		entryValue, err := d.finishDecodingValue(value.Value)
+		if err != nil {
+			return nil, err
+		}
+		value.Value = entryValue
+		return value, nil
+	}
+	return value, nil
+}
+
+func (d *PlistDecoder) finishDecodingArray() ([]interface{}, error) {
+	array := []interface{}{}
+	for {
+		value, err := d.DecodeValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := value.(EndDecodingContainer); ok {
+			return array, nil
+		}
+		array = append(array, value)
+	}
+}
+
+func (d *PlistDecoder) finishDecodingDict() (map[string]interface{}, error) {
+	dict := map[string]interface{}{}
+	for {
+		value, err := d.DecodeValue()
+		if err != nil {
+			return nil, err
+		}
+
+		switch value := value.(type) {
+		case EndDecodingContainer:
+			return dict, nil
+		case DictEntry:
+			dict[value.Key] = value.Value
+		default:
+			return nil, fmt.Errorf("Expected dict entry, found %#v", value)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package xml
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeValuePlist(t *testing.T) {
+	plist := `<plist version="1.0">
+	<dict>
+		<key>a</key>
+		<array>
+			<string>foo</string>
+			<dict></dict>
+		</array>
+		<key>b</key>
+		<dict>
+			<key>c</key>
+			<integer>42</integer>
+		</dict>
+	</dict>
+</plist>`
+	decoder := NewDecoder(bytes.NewReader([]byte(plist)))
+
+	value, err := decoder.DecodeValue()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": []interface{}{"foo", map[string]interface{}{}},
+		"b": map[string]interface{}{"c": int64(42)},
+	}, value)
+
+	value, err = decoder.DecodeValue()
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, value)
+}
+
+func TestDecodeValueMixedWithNextValue(t *testing.T) {
+	plist := `<plist version="1.0">
+	<dict>
+		<key>a</key>
+		<array>
+			<string>foo</string>
+		</array>
+		<key>b</key>
+		<string>bar</string>
+	</dict>
+</plist>`
+	decoder := NewDecoder(bytes.NewReader([]byte(plist)))
+
+	value, err := decoder.NextValue()
+	assert.NoError(t, err)
+	assert.Equal(t, StartDecodingDict{}, value)
+
+	value, err = decoder.DecodeValue()
+	assert.NoError(t, err)
+	assert.Equal(t, DictEntry{"a", []interface{}{"foo"}}, value)
+
+	value, err = decoder.DecodeValue()
+	assert.NoError(t, err)
+	assert.Equal(t, DictEntry{"b", "bar"}, value)
+
+	value, err = decoder.DecodeValue()
+	assert.NoError(t, err)
+	assert.Equal(t, EndDecodingContainer{}, value)
+}
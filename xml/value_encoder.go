@@ -0,0 +1,145 @@
+package xml
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"time"
+)
+
+/*
+WriteValue writes val, which can be any of the types DecodeValue returns, with
+its type chosen at runtime. Slices are written as arrays, and maps with string
+keys as dicts, with their keys sorted. Ints, uints and floats of any size are
+accepted, as are pointers to any supported value.
+*/
+func (e *DictEncoder) WriteValue(key string, val interface{}) error {
+	e.assertReady()
+	if err := checkEncodable(reflect.ValueOf(val)); err != nil {
+		return err
+	}
+	if err := e.writeKey(key); err != nil {
+		return err
+	}
+	return writeValue(e.baseEncoder, reflect.ValueOf(val))
+}
+
+// WriteValue writes val, which can be any of the types DecodeValue returns.
+// See DictEncoder.WriteValue.
+func (e *ArrayEncoder) WriteValue(val interface{}) error {
+	e.assertReady()
+	if err := checkEncodable(reflect.ValueOf(val)); err != nil {
+		return err
+	}
+	return writeValue(e.baseEncoder, reflect.ValueOf(val))
+}
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	int128Type   = reflect.TypeOf(Int128{})
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+// checkEncodable returns an error if v, or anything in it, can't be written,
+// so nothing is written for unsupported values.
+func checkEncodable(v reflect.Value) error {
+	if !v.IsValid() {
+		return fmt.Errorf("cannot encode nil value")
+	}
+	switch v.Type() {
+	case bigIntType, bigFloatType, int128Type, timeType, bytesType:
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return fmt.Errorf("cannot encode nil %s", v.Type())
+		}
+		return checkEncodable(v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkEncodable(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("cannot encode map with %s keys", v.Type().Key())
+		}
+		for _, key := range v.MapKeys() {
+			if err := checkEncodable(v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot encode value of type %s", v.Type())
+}
+
+func writeValue(e *baseEncoder, v reflect.Value) error {
+	switch v.Type() {
+	case bigIntType:
+		i := v.Interface().(big.Int)
+		return writeBigInt(e.xmlEncoder, &i)
+	case bigFloatType:
+		f := v.Interface().(big.Float)
+		return writeBigFloat(e.xmlEncoder, &f)
+	case int128Type:
+		return writeInt128(e.xmlEncoder, v.Interface().(Int128))
+	case timeType:
+		return writeDate(e.xmlEncoder, v.Interface().(time.Time))
+	case bytesType:
+		return writeData(e.xmlEncoder, v.Bytes())
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return writeString(e.xmlEncoder, v.String())
+	case reflect.Bool:
+		return writeBool(e.xmlEncoder, v.Bool())
+	case reflect.Float32, reflect.Float64:
+		return writeFloat(e.xmlEncoder, v.Float())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return writeInt(e.xmlEncoder, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return writeUint(e.xmlEncoder, v.Uint())
+	case reflect.Ptr, reflect.Interface:
+		return writeValue(e, v.Elem())
+	case reflect.Slice, reflect.Array:
+		return e.writeArray(func(e *ArrayEncoder) error {
+			for i := 0; i < v.Len(); i++ {
+				if err := writeValue(e.baseEncoder, v.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case reflect.Map:
+		return e.writeDict(func(e *DictEncoder) error {
+			keys := make([]string, 0, v.Len())
+			for _, key := range v.MapKeys() {
+				keys = append(keys, key.String())
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				if err := e.writeKey(key); err != nil {
+					return err
+				}
+				if err := writeValue(e.baseEncoder, v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	panic("unreachable: value not checked by checkEncodable")
+}
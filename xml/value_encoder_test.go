@@ -0,0 +1,105 @@
+package xml
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteValue(t *testing.T) {
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+	<dict>
+		<key>value</key>
+		<dict>
+			<key>array</key>
+			<array>
+				<string>a</string>
+				<integer>1</integer>
+				<true></true>
+			</array>
+			<key>big</key>
+			<integer>18446744073709551616</integer>
+			<key>data</key>
+			<data>AQI=</data>
+			<key>date</key>
+			<date>2020-01-02T03:04:05Z</date>
+			<key>dict</key>
+			<dict>
+				<key>a</key>
+				<real>1.5</real>
+				<key>b</key>
+				<integer>2</integer>
+			</dict>
+			<key>pointer</key>
+			<string>p</string>
+			<key>uint</key>
+			<integer>18446744073709551615</integer>
+		</dict>
+	</dict>
+</plist>`
+
+	pointer := "p"
+	large := new(big.Int).Lsh(big.NewInt(1), 64)
+	var buffer bytes.Buffer
+	assert.NoError(t, EncodeDictPlist(&buffer, func(e *DictEncoder) error {
+		return e.WriteValue("value", map[string]interface{}{
+			"array":   []interface{}{"a", 1, true},
+			"big":     large,
+			"data":    []byte{1, 2},
+			"date":    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			"dict":    map[string]interface{}{"b": int8(2), "a": float32(1.5)},
+			"pointer": &pointer,
+			"uint":    uint64(1<<64 - 1),
+		})
+	}))
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestWriteValueRoundTrip(t *testing.T) {
+	const plist = `<plist version="1.0">
+<dict>
+	<key>int</key>
+	<integer>1606938044258990275541962092341162602522202993782792835301376</integer>
+	<key>int128</key>
+	<integer>-1267650600228229401496703205376</integer>
+	<key>real</key>
+	<real>1e+400</real>
+</dict>
+</plist>`
+	decoded, err := NewDecoder(strings.NewReader(plist)).DecodeValue()
+	assert.NoError(t, err)
+	assert.IsType(t, big.Int{}, decoded.(map[string]interface{})["int"])
+	assert.IsType(t, big.Float{}, decoded.(map[string]interface{})["real"])
+
+	var buffer bytes.Buffer
+	assert.NoError(t, EncodeDictPlist(&buffer, func(e *DictEncoder) error {
+		return e.WriteValue("value", decoded)
+	}))
+	reencoded, err := NewDecoder(&buffer).DecodeValue()
+	assert.NoError(t, err)
+	assert.Equal(t, decoded, reencoded.(map[string]interface{})["value"])
+}
+
+func TestWriteValueUnsupported(t *testing.T) {
+	for _, value := range []interface{}{
+		nil,
+		struct{}{},
+		map[int]string{1: "a"},
+		[]interface{}{"a", make(chan int)},
+		(*big.Int)(nil),
+		(*big.Float)(nil),
+	} {
+		var buffer bytes.Buffer
+		err := EncodeDictPlist(&buffer, func(e *DictEncoder) error {
+			return e.WriteValue("key", value)
+		})
+		assert.Error(t, err, "%#v", value)
+		assert.NotContains(t, buffer.String(), "<key>key</key>")
+	}
+}